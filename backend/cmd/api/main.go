@@ -36,11 +36,14 @@ func main() {
 		"listen_addr", cfg.ListenAddr,
 	)
 
-	k8sClient, err := k8s.NewClient(cfg.KubeConfig)
+	clusters, err := k8s.NewRegistry(cfg)
 	if err != nil {
-		slog.Error("failed to initialize kubernetes client", "error", err)
+		slog.Error("failed to initialize kubernetes clients", "error", err)
 		os.Exit(1)
 	}
+	for _, c := range clusters.Clusters() {
+		slog.Info("registered cluster", "cluster", c.Name, "region", c.Region)
+	}
 
 	var limiterSvc domain.Limiter
 	if cfg.RedisAddr != "" {
@@ -54,7 +57,7 @@ func main() {
 		)
 	}
 
-	storeSvc := service.NewStoreService(k8sClient, cfg)
+	storeSvc := service.NewStoreService(clusters, cfg)
 
 	router := api.SetupRouter(storeSvc, limiterSvc, cfg)
 
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -18,23 +19,35 @@ func NewStoreHandler(svc *service.StoreService) *StoreHandler {
 }
 
 type storeResponse struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
-	Engine    string `json:"engine"`
-	Plan      string `json:"plan"`
-	Status    string `json:"status"`
-	URL       string `json:"url,omitempty"`
-	CreatedAt string `json:"createdAt"`
+	Name      string               `json:"name"`
+	Cluster   string               `json:"cluster"`
+	Namespace string               `json:"namespace"`
+	Engine    string               `json:"engine"`
+	Plan      string               `json:"plan"`
+	Status    string               `json:"status"`
+	Reason    string               `json:"reason,omitempty"`
+	Message   string               `json:"message,omitempty"`
+	URL       string               `json:"url,omitempty"`
+	Suspended bool                 `json:"suspended"`
+	CloneFrom string               `json:"cloneFrom,omitempty"`
+	Site      *domain.SiteSettings `json:"site,omitempty"`
+	CreatedAt string               `json:"createdAt"`
 }
 
 func toStoreResponse(s domain.Store) storeResponse {
 	return storeResponse{
 		Name:      s.Name,
+		Cluster:   s.Cluster,
 		Namespace: s.Namespace,
 		Engine:    s.Engine,
 		Plan:      s.Plan,
 		Status:    s.Status,
+		Reason:    s.Reason,
+		Message:   s.Message,
 		URL:       s.URL,
+		Suspended: s.Suspended,
+		CloneFrom: s.CloneFrom,
+		Site:      s.Site,
 		CreatedAt: s.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 }
@@ -69,8 +82,9 @@ func (h *StoreHandler) Create(c *gin.Context) {
 
 func (h *StoreHandler) List(c *gin.Context) {
 	namespace := c.Query("namespace")
+	cluster := c.Query("cluster")
 
-	stores, err := h.svc.ListStores(c.Request.Context(), namespace)
+	stores, err := h.svc.ListStores(c.Request.Context(), namespace, cluster)
 	if err != nil {
 		if apiErr, ok := err.(*domain.APIError); ok {
 			c.JSON(apiErr.Code, gin.H{
@@ -96,7 +110,7 @@ func (h *StoreHandler) Get(c *gin.Context) {
 	name := c.Param("name")
 	namespace := c.Query("namespace")
 
-	store, err := h.svc.GetStore(c.Request.Context(), name, namespace)
+	store, err := h.svc.GetStore(c.Request.Context(), name, namespace, c.Query("cluster"))
 	if err != nil {
 		if apiErr, ok := err.(*domain.APIError); ok {
 			c.JSON(apiErr.Code, gin.H{
@@ -118,7 +132,7 @@ func (h *StoreHandler) Delete(c *gin.Context) {
 	name := c.Param("name")
 	namespace := c.Query("namespace")
 
-	err := h.svc.DeleteStore(c.Request.Context(), name, namespace)
+	err := h.svc.DeleteStore(c.Request.Context(), name, namespace, c.Query("cluster"))
 	if err != nil {
 		if apiErr, ok := err.(*domain.APIError); ok {
 			c.JSON(apiErr.Code, gin.H{
@@ -134,4 +148,134 @@ func (h *StoreHandler) Delete(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *StoreHandler) Suspend(c *gin.Context) {
+	h.setSuspended(c, h.svc.SuspendStore)
+}
+
+func (h *StoreHandler) Resume(c *gin.Context) {
+	h.setSuspended(c, h.svc.ResumeStore)
+}
+
+func (h *StoreHandler) setSuspended(c *gin.Context, action func(ctx context.Context, name, namespace, cluster string) (*domain.Store, error)) {
+	name := c.Param("name")
+	namespace := c.Query("namespace")
+
+	store, err := action(c.Request.Context(), name, namespace, c.Query("cluster"))
+	if err != nil {
+		if apiErr, ok := err.(*domain.APIError); ok {
+			c.JSON(apiErr.Code, gin.H{
+				"error": apiErr.Message,
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toStoreResponse(*store))
+}
+
+func (h *StoreHandler) Clone(c *gin.Context) {
+	var req domain.CloneStoreRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body",
+		})
+		return
+	}
+
+	store, err := h.svc.CloneStore(c.Request.Context(), c.Param("name"), c.Query("namespace"), c.Query("cluster"), req)
+	if err != nil {
+		if apiErr, ok := err.(*domain.APIError); ok {
+			c.JSON(apiErr.Code, gin.H{
+				"error": apiErr.Message,
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toStoreResponse(*store))
+}
+
+func (h *StoreHandler) Usage(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Query("namespace")
+
+	usage, err := h.svc.GetStoreUsage(c.Request.Context(), name, namespace, c.Query("cluster"))
+	if err != nil {
+		if apiErr, ok := err.(*domain.APIError); ok {
+			c.JSON(apiErr.Code, gin.H{
+				"error": apiErr.Message,
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+func (h *StoreHandler) FleetUsage(c *gin.Context) {
+	namespace := c.Query("namespace")
+
+	usage, err := h.svc.FleetUsage(c.Request.Context(), namespace, c.Query("cluster"))
+	if err != nil {
+		if apiErr, ok := err.(*domain.APIError); ok {
+			c.JSON(apiErr.Code, gin.H{
+				"error": apiErr.Message,
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+func (h *StoreHandler) ListClusters(c *gin.Context) {
+	c.JSON(http.StatusOK, h.svc.ListClusters(c.Request.Context()))
+}
+
+func (h *StoreHandler) ListEngines(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"engines": h.svc.ListEngines(c.Request.Context()),
+	})
+}
+
+func (h *StoreHandler) ListPlans(c *gin.Context) {
+	plans, err := h.svc.ListPlans(c.Request.Context())
+	if err != nil {
+		if apiErr, ok := err.(*domain.APIError); ok {
+			c.JSON(apiErr.Code, gin.H{
+				"error": apiErr.Message,
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
 }
\ No newline at end of file
@@ -33,6 +33,14 @@ func SetupRouter(storeSvc *service.StoreService, limiter domain.Limiter, cfg *co
 	api.GET("/stores", storeHandler.List)
 	api.GET("/stores/:name", storeHandler.Get)
 	api.DELETE("/stores/:name", storeHandler.Delete)
+	api.POST("/stores/:name/suspend", storeHandler.Suspend)
+	api.POST("/stores/:name/resume", storeHandler.Resume)
+	api.POST("/stores/:name/clone", storeHandler.Clone)
+	api.GET("/stores/:name/usage", storeHandler.Usage)
+	api.GET("/usage", storeHandler.FleetUsage)
+	api.GET("/clusters", storeHandler.ListClusters)
+	api.GET("/engines", storeHandler.ListEngines)
+	api.GET("/plans", storeHandler.ListPlans)
 
 	return r
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,6 +16,17 @@ type Config struct {
 	RateWindow  time.Duration
 	LogLevel    string
 	BaseDomain  string
+
+	// OperatorNamespace is where the operator publishes its capabilities
+	OperatorNamespace string
+
+	// ClusterContexts are the kubeconfig contexts of the clusters stores can
+	// be placed on; when empty the backend manages a single cluster
+	ClusterContexts []string
+	// ClusterRegions maps a context to its region for region placement
+	ClusterRegions map[string]string
+	// PlacementStrategy places stores created without an explicit cluster
+	PlacementStrategy string
 }
 
 func Load() (*Config, error) {
@@ -27,6 +39,12 @@ func Load() (*Config, error) {
 		RateWindow:  getEnvAsDuration("RATE_WINDOW", 1*time.Minute),
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
 		BaseDomain:  getEnv("BASE_DOMAIN", "127.0.0.1.nip.io"),
+
+		OperatorNamespace: getEnv("OPERATOR_NAMESPACE", "default"),
+
+		ClusterContexts:   getEnvAsList("CLUSTER_CONTEXTS"),
+		ClusterRegions:    getEnvAsMap("CLUSTER_REGIONS"),
+		PlacementStrategy: getEnv("PLACEMENT_STRATEGY", "least-loaded"),
 	}
 
 	return cfg, nil
@@ -54,3 +72,26 @@ func getEnvAsDuration(key string, defaultVal time.Duration) time.Duration {
 	}
 	return defaultVal
 }
+
+// getEnvAsList reads a comma-separated list, skipping empty entries.
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// getEnvAsMap reads comma-separated key=value pairs, skipping malformed ones.
+func getEnvAsMap(key string) map[string]string {
+	values := make(map[string]string)
+	for _, pair := range getEnvAsList(key) {
+		k, v, ok := strings.Cut(pair, "=")
+		if k, v = strings.TrimSpace(k), strings.TrimSpace(v); ok && k != "" {
+			values[k] = v
+		}
+	}
+	return values
+}
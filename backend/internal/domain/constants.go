@@ -6,42 +6,68 @@ const (
 	StatusPending      = "Pending"
 	StatusProvisioning = "Provisioning"
 	StatusReady        = "Ready"
+	StatusSuspended    = "Suspended"
 	StatusFailed       = "Failed"
+	// StatusDegraded is a store that was Ready but fails its health checks;
+	// Reason says why (CrashLooping, WorkloadUnavailable, ProbeFailed)
+	StatusDegraded = "Degraded"
 )
 
-// Supported plans — must match operator plans in
-// operator/internal/controller/plans.go
+// Supported engines — the operator advertises the authoritative list in
+// its capabilities ConfigMap; see operator/internal/engine.
 const (
-	PlanSmall  = "small"
-	PlanMedium = "medium"
-	PlanLarge  = "large"
+	EngineWoo    = "woo"
+	EngineMedusa = "medusa"
 )
 
-// AllowedPlans is the set of valid plan names.
-var AllowedPlans = map[string]bool{
-	PlanSmall:  true,
-	PlanMedium: true,
-	PlanLarge:  true,
+// AllowedEngines is the fallback set of valid engine names, used when the
+// operator's capabilities ConfigMap cannot be read. Medusa is left out since
+// the operator only offers it once MEDUSA_IMAGE is configured.
+var AllowedEngines = map[string]bool{
+	EngineWoo: true,
 }
 
-// Supported engines
+// Operator capabilities ConfigMap — must match
+// operator/internal/controller/constants.go
 const (
-	EngineWoo = "woo"
+	CapabilitiesConfigMapName = "store-operator-capabilities"
+	CapabilitiesKeyEngines    = "engines"
 )
 
-// AllowedEngines is the set of valid engine names.
-var AllowedEngines = map[string]bool{
-	EngineWoo: true,
-}
-
 // Default values
 const (
 	DefaultNamespace = "default"
+	// DefaultClusterName names the single cluster used when no kubeconfig
+	// contexts are configured
+	DefaultClusterName = "default"
+)
+
+// Placement strategies for stores created without an explicit cluster
+const (
+	// PlacementLeastLoaded picks the cluster running the fewest stores
+	PlacementLeastLoaded = "least-loaded"
+	// PlacementRegion picks the least-loaded cluster in the requested region
+	PlacementRegion = "region"
 )
 
+// AllowedPlacements is the set of valid placement strategies.
+var AllowedPlacements = map[string]bool{
+	PlacementLeastLoaded: true,
+	PlacementRegion:      true,
+}
+
 // Validation limits
 const (
 	MaxStoreNameLength = 63
+
+	// spec.site limits — must match operator/api/v1alpha1/store_types.go
+	MaxSiteTitleLength     = 100
+	MaxAdminEmailLength    = 254
+	MaxAdminUsernameLength = 60
+	MaxAddressLineLength   = 200
+	MaxCityLength          = 100
+	MaxPostcodeLength      = 20
+	MaxStateLength         = 10
 )
 
 // CRD metadata — must match the operator CRD definition in
@@ -53,3 +79,8 @@ const (
 	CRDKind       = "Store"
 	CRDAPIVersion = CRDGroup + "/" + CRDVersion
 )
+
+// StorePlan CRD metadata — must match operator/api/v1alpha1/storeplan_types.go
+const (
+	PlanCRDResource = "storeplans"
+)
@@ -7,6 +7,32 @@ type StoreRepository interface {
 	List(ctx context.Context, namespace string) ([]Store, error)
 	Get(ctx context.Context, name, namespace string) (*Store, error)
 	Delete(ctx context.Context, name, namespace string) error
+	SetSuspended(ctx context.Context, name, namespace string, suspended bool) error
+}
+
+// EngineCatalog reports the engines the operator currently supports.
+type EngineCatalog interface {
+	ListEngines(ctx context.Context) ([]string, error)
+}
+
+// PlanCatalog lists the StorePlans stores can be created on.
+type PlanCatalog interface {
+	ListPlans(ctx context.Context) ([]Plan, error)
+}
+
+// ClusterClient is what the service needs from a single cluster.
+type ClusterClient interface {
+	StoreRepository
+	EngineCatalog
+	PlanCatalog
+}
+
+// ClusterRegistry holds the clusters stores can be placed on.
+type ClusterRegistry interface {
+	// Clusters lists the registered clusters in a stable order
+	Clusters() []Cluster
+	// Client returns the client of a registered cluster
+	Client(name string) (ClusterClient, bool)
 }
 
 type Limiter interface {
@@ -3,13 +3,68 @@ package domain
 import "time"
 
 type Store struct {
-	Name      string    `json:"name"`
-	Namespace string    `json:"namespace"`
-	Engine    string    `json:"engine"`
-	Plan      string    `json:"plan"`
-	Status    string    `json:"status"`
-	URL       string    `json:"url"`
-	CreatedAt time.Time `json:"createdAt"`
+	Name      string        `json:"name"`
+	Cluster   string        `json:"cluster"`
+	Namespace string        `json:"namespace"`
+	Engine    string        `json:"engine"`
+	Plan      string        `json:"plan"`
+	Status    string        `json:"status"`
+	Reason    string        `json:"reason"`
+	Message   string        `json:"message"`
+	URL       string        `json:"url"`
+	Suspended bool          `json:"suspended"`
+	CloneFrom string        `json:"cloneFrom,omitempty"`
+	Site      *SiteSettings `json:"site,omitempty"`
+	Usage     *StoreUsage   `json:"usage,omitempty"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// StoreUsage mirrors a Store's status.usage: the quota usage and volume sizes
+// of its namespace, sampled periodically by the operator. Quantities keep
+// their Kubernetes notation (e.g. 500m, 2Gi).
+type StoreUsage struct {
+	Used      map[string]string `json:"used"`
+	Hard      map[string]string `json:"hard"`
+	Storage   string            `json:"storage"`
+	Volumes   []VolumeUsage     `json:"volumes"`
+	SampledAt *time.Time        `json:"sampledAt,omitempty"`
+}
+
+// VolumeUsage is the requested size of one of a store's PVCs.
+type VolumeUsage struct {
+	Name string `json:"name"`
+	Size string `json:"size"`
+}
+
+// FleetUsage totals the usage of every store for capacity planning.
+type FleetUsage struct {
+	Stores  int               `json:"stores"`
+	Sampled int               `json:"sampled"`
+	Used    map[string]string `json:"used"`
+	Hard    map[string]string `json:"hard"`
+	Storage string            `json:"storage"`
+	Plans   []PlanUsage       `json:"plans"`
+}
+
+// PlanUsage totals the usage of the stores on one plan.
+type PlanUsage struct {
+	Plan    string            `json:"plan"`
+	Stores  int               `json:"stores"`
+	Sampled int               `json:"sampled"`
+	Used    map[string]string `json:"used"`
+	Hard    map[string]string `json:"hard"`
+	Storage string            `json:"storage"`
+}
+
+// Plan mirrors a StorePlan custom resource.
+type Plan struct {
+	Name           string `json:"name"`
+	RequestsCPU    string `json:"requestsCPU"`
+	RequestsMemory string `json:"requestsMemory"`
+	LimitsCPU      string `json:"limitsCPU"`
+	LimitsMemory   string `json:"limitsMemory"`
+	MaxPods        int64  `json:"maxPods"`
+	Replicas       *int64 `json:"replicas,omitempty"`
 }
 
 type CreateStoreRequest struct {
@@ -17,6 +72,60 @@ type CreateStoreRequest struct {
 	Engine    string `json:"engine" binding:"required"`
 	Plan      string `json:"plan" binding:"required"`
 	Namespace string `json:"namespace"`
+
+	// Cluster places the store on a registered cluster; when empty the
+	// Placement strategy picks one
+	Cluster   string `json:"cluster"`
+	Placement string `json:"placement"`
+	// Region is required by the region placement strategy
+	Region string `json:"region"`
+
+	// Site seeds the new store's title, admin user and shop settings; it
+	// can't be changed once the store exists
+	Site *SiteSettings `json:"site"`
+}
+
+// SiteSettings mirrors a Store's spec.site. Empty fields keep the engine's
+// defaults.
+type SiteSettings struct {
+	Title         string        `json:"title,omitempty"`
+	AdminEmail    string        `json:"adminEmail,omitempty"`
+	AdminUsername string        `json:"adminUsername,omitempty"`
+	Locale        string        `json:"locale,omitempty"`
+	Currency      string        `json:"currency,omitempty"`
+	Address       *StoreAddress `json:"address,omitempty"`
+}
+
+// StoreAddress is the shop's business address. Country is an ISO 3166-1
+// alpha-2 code and State the engine's code for the region within it.
+type StoreAddress struct {
+	Line1    string `json:"line1"`
+	Line2    string `json:"line2,omitempty"`
+	City     string `json:"city"`
+	Postcode string `json:"postcode,omitempty"`
+	Country  string `json:"country"`
+	State    string `json:"state,omitempty"`
+}
+
+// CloneStoreRequest creates a copy of an existing store. The clone runs the
+// source's engine in the source's cluster and namespace, on the source's plan
+// unless Plan is set.
+type CloneStoreRequest struct {
+	Name string `json:"name" binding:"required"`
+	Plan string `json:"plan"`
+}
+
+// Cluster is a target cluster in the registry.
+type Cluster struct {
+	Name   string `json:"name"`
+	Region string `json:"region,omitempty"`
+}
+
+// ClusterSummary reports a registered cluster and how many stores it runs.
+type ClusterSummary struct {
+	Cluster
+	Stores    int  `json:"stores"`
+	Reachable bool `json:"reachable"`
 }
 
 type APIError struct {
@@ -30,10 +139,18 @@ func (e *APIError) Error() string {
 
 // Sentinel errors for structured HTTP error mapping
 var (
-	ErrStoreExists   = &APIError{Code: 409, Message: "store already exists"}
-	ErrStoreNotFound = &APIError{Code: 404, Message: "store not found"}
-	ErrInvalidName   = &APIError{Code: 400, Message: "invalid store name"}
-	ErrInvalidPlan   = &APIError{Code: 400, Message: "invalid plan"}
-	ErrInvalidEngine = &APIError{Code: 400, Message: "invalid engine"}
-	ErrInternal      = &APIError{Code: 500, Message: "internal server error"}
+	ErrStoreExists      = &APIError{Code: 409, Message: "store already exists"}
+	ErrStoreNotFound    = &APIError{Code: 404, Message: "store not found"}
+	ErrInvalidName      = &APIError{Code: 400, Message: "invalid store name"}
+	ErrInvalidPlan      = &APIError{Code: 400, Message: "invalid plan"}
+	ErrInvalidEngine    = &APIError{Code: 400, Message: "invalid engine"}
+	ErrNoUsage          = &APIError{Code: 404, Message: "store usage has not been sampled yet"}
+	ErrInvalidCluster   = &APIError{Code: 400, Message: "invalid cluster"}
+	ErrInvalidPlacement = &APIError{Code: 400, Message: "invalid placement"}
+	ErrInvalidSite      = &APIError{Code: 400, Message: "invalid site settings"}
+	ErrAmbiguousStore   = &APIError{Code: 409, Message: "store exists in more than one cluster"}
+	ErrStoreSuspended   = &APIError{Code: 409, Message: "store is suspended"}
+	ErrInternal         = &APIError{Code: 500, Message: "internal server error"}
+	// ErrClusterUnavailable is returned when a cluster that has to be checked can't be reached
+	ErrClusterUnavailable = &APIError{Code: 503, Message: "cluster unavailable"}
 )
@@ -2,12 +2,17 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Jovial-Kanwadia/store-platform/backend/internal/domain"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -19,17 +24,40 @@ var storeGVR = schema.GroupVersionResource{
 	Resource: domain.CRDResource,
 }
 
+var planGVR = schema.GroupVersionResource{
+	Group:    domain.CRDGroup,
+	Version:  domain.CRDVersion,
+	Resource: domain.PlanCRDResource,
+}
+
+var configMapGVR = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "configmaps",
+}
+
 type Client struct {
-	dynamicClient dynamic.Interface
+	dynamicClient     dynamic.Interface
+	operatorNamespace string
 }
 
-func NewClient(kubeconfigPath string) (*Client, error) {
+// NewClient connects to the cluster of a kubeconfig context. An empty
+// context uses the kubeconfig's current context; with no kubeconfig path
+// either, the in-cluster config is used.
+func NewClient(kubeconfigPath, kubeContext, operatorNamespace string) (*Client, error) {
 	var config *rest.Config
 	var err error
 
-	if kubeconfigPath != "" {
+	switch {
+	case kubeContext != "":
+		rules := clientcmd.NewDefaultClientConfigLoadingRules()
+		if kubeconfigPath != "" {
+			rules.ExplicitPath = kubeconfigPath
+		}
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules,
+			&clientcmd.ConfigOverrides{CurrentContext: kubeContext}).ClientConfig()
+	case kubeconfigPath != "":
 		config, err = clientcmd.BuildConfigFromFlags("", kubeconfigPath)
-	} else {
+	default:
 		config, err = rest.InClusterConfig()
 	}
 
@@ -43,7 +71,8 @@ func NewClient(kubeconfigPath string) (*Client, error) {
 	}
 
 	return &Client{
-		dynamicClient: dynClient,
+		dynamicClient:     dynClient,
+		operatorNamespace: operatorNamespace,
 	}, nil
 }
 
@@ -63,6 +92,16 @@ func (c *Client) Create(ctx context.Context, s domain.Store) error {
 		},
 	}
 
+	if s.CloneFrom != "" {
+		obj.Object["spec"].(map[string]interface{})["cloneFrom"] = map[string]interface{}{
+			"storeName": s.CloneFrom,
+		}
+	}
+
+	if s.Site != nil {
+		obj.Object["spec"].(map[string]interface{})["site"] = siteToSpec(s.Site)
+	}
+
 	_, err := c.dynamicClient.Resource(storeGVR).Namespace(s.Namespace).Create(ctx, obj, metav1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to create store: %w", err)
@@ -99,6 +138,9 @@ func (c *Client) List(ctx context.Context, namespace string) ([]domain.Store, er
 
 func (c *Client) Get(ctx context.Context, name, namespace string) (*domain.Store, error) {
 	obj, err := c.dynamicClient.Resource(storeGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, domain.ErrStoreNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get store: %w", err)
 	}
@@ -115,6 +157,84 @@ func (c *Client) Delete(ctx context.Context, name, namespace string) error {
 	return nil
 }
 
+// SetSuspended patches spec.suspended; the operator scales the store accordingly.
+func (c *Client) SetSuspended(ctx context.Context, name, namespace string, suspended bool) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"suspended": suspended},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build suspend patch: %w", err)
+	}
+
+	_, err = c.dynamicClient.Resource(storeGVR).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to patch store: %w", err)
+	}
+
+	return nil
+}
+
+// ListEngines reads the engines advertised in the operator's capabilities ConfigMap.
+func (c *Client) ListEngines(ctx context.Context) ([]string, error) {
+	obj, err := c.dynamicClient.Resource(configMapGVR).Namespace(c.operatorNamespace).Get(ctx, domain.CapabilitiesConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operator capabilities: %w", err)
+	}
+
+	raw, _, _ := unstructured.NestedString(obj.Object, "data", domain.CapabilitiesKeyEngines)
+
+	engines := make([]string, 0)
+	for _, e := range strings.Split(raw, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			engines = append(engines, e)
+		}
+	}
+
+	return engines, nil
+}
+
+// ListPlans lists the cluster-scoped StorePlans.
+func (c *Client) ListPlans(ctx context.Context) ([]domain.Plan, error) {
+	list, err := c.dynamicClient.Resource(planGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plans: %w", err)
+	}
+
+	plans := make([]domain.Plan, 0, len(list.Items))
+	for _, item := range list.Items {
+		plans = append(plans, unstructuredToPlan(&item))
+	}
+
+	return plans, nil
+}
+
+func unstructuredToPlan(obj *unstructured.Unstructured) domain.Plan {
+	quota, _, _ := unstructured.NestedMap(obj.Object, "spec", "quota")
+
+	plan := domain.Plan{
+		Name:           obj.GetName(),
+		RequestsCPU:    quantityString(quota, "requestsCPU"),
+		RequestsMemory: quantityString(quota, "requestsMemory"),
+		LimitsCPU:      quantityString(quota, "limitsCPU"),
+		LimitsMemory:   quantityString(quota, "limitsMemory"),
+	}
+	plan.MaxPods, _, _ = unstructured.NestedInt64(quota, "maxPods")
+
+	if replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); found {
+		plan.Replicas = &replicas
+	}
+
+	return plan
+}
+
+// quantityString reads a resource.Quantity field, which may be serialized as a string or a number.
+func quantityString(m map[string]interface{}, field string) string {
+	if v, ok := m[field]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
 func unstructuredToStore(obj *unstructured.Unstructured) (*domain.Store, error) {
 	name := obj.GetName()
 	namespace := obj.GetNamespace()
@@ -130,9 +250,13 @@ func unstructuredToStore(obj *unstructured.Unstructured) (*domain.Store, error)
 	statusMap, _, _ := unstructured.NestedMap(obj.Object, "status")
 	phase, _, _ := unstructured.NestedString(statusMap, "phase")
 	url, _, _ := unstructured.NestedString(statusMap, "url")
+	reason, _, _ := unstructured.NestedString(statusMap, "reason")
+	message, _, _ := unstructured.NestedString(statusMap, "message")
 
 	engine, _, _ := unstructured.NestedString(spec, "engine")
 	plan, _, _ := unstructured.NestedString(spec, "plan")
+	suspended, _, _ := unstructured.NestedBool(spec, "suspended")
+	cloneFrom, _, _ := unstructured.NestedString(spec, "cloneFrom", "storeName")
 
 	return &domain.Store{
 		Name:      name,
@@ -140,7 +264,109 @@ func unstructuredToStore(obj *unstructured.Unstructured) (*domain.Store, error)
 		Engine:    engine,
 		Plan:      plan,
 		Status:    phase,
+		Reason:    reason,
+		Message:   message,
 		URL:       url,
+		Suspended: suspended,
+		CloneFrom: cloneFrom,
+		Site:      siteFromSpec(spec),
+		Usage:     usageFromStatus(statusMap),
 		CreatedAt: createdAt,
 	}, nil
 }
+
+// siteToSpec renders site settings as spec.site, leaving out empty fields.
+func siteToSpec(site *domain.SiteSettings) map[string]interface{} {
+	out := map[string]interface{}{}
+	setString(out, "title", site.Title)
+	setString(out, "adminEmail", site.AdminEmail)
+	setString(out, "adminUsername", site.AdminUsername)
+	setString(out, "locale", site.Locale)
+	setString(out, "currency", site.Currency)
+	if a := site.Address; a != nil {
+		address := map[string]interface{}{}
+		setString(address, "line1", a.Line1)
+		setString(address, "line2", a.Line2)
+		setString(address, "city", a.City)
+		setString(address, "postcode", a.Postcode)
+		setString(address, "country", a.Country)
+		setString(address, "state", a.State)
+		out["address"] = address
+	}
+	return out
+}
+
+// setString sets key to value unless value is empty.
+func setString(m map[string]interface{}, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+// siteFromSpec reads spec.site, or returns nil for stores created without it.
+func siteFromSpec(spec map[string]interface{}) *domain.SiteSettings {
+	siteMap, found, _ := unstructured.NestedMap(spec, "site")
+	if !found {
+		return nil
+	}
+
+	site := &domain.SiteSettings{}
+	site.Title, _, _ = unstructured.NestedString(siteMap, "title")
+	site.AdminEmail, _, _ = unstructured.NestedString(siteMap, "adminEmail")
+	site.AdminUsername, _, _ = unstructured.NestedString(siteMap, "adminUsername")
+	site.Locale, _, _ = unstructured.NestedString(siteMap, "locale")
+	site.Currency, _, _ = unstructured.NestedString(siteMap, "currency")
+	if addressMap, found, _ := unstructured.NestedMap(siteMap, "address"); found {
+		address := &domain.StoreAddress{}
+		address.Line1, _, _ = unstructured.NestedString(addressMap, "line1")
+		address.Line2, _, _ = unstructured.NestedString(addressMap, "line2")
+		address.City, _, _ = unstructured.NestedString(addressMap, "city")
+		address.Postcode, _, _ = unstructured.NestedString(addressMap, "postcode")
+		address.Country, _, _ = unstructured.NestedString(addressMap, "country")
+		address.State, _, _ = unstructured.NestedString(addressMap, "state")
+		site.Address = address
+	}
+	return site
+}
+
+// usageFromStatus reads status.usage, or returns nil before the operator's first sample.
+func usageFromStatus(status map[string]interface{}) *domain.StoreUsage {
+	usageMap, found, _ := unstructured.NestedMap(status, "usage")
+	if !found {
+		return nil
+	}
+
+	used, _, _ := unstructured.NestedMap(usageMap, "used")
+	hard, _, _ := unstructured.NestedMap(usageMap, "hard")
+	usage := &domain.StoreUsage{
+		Used:    quantityMap(used),
+		Hard:    quantityMap(hard),
+		Storage: quantityString(usageMap, "storage"),
+		Volumes: make([]domain.VolumeUsage, 0),
+	}
+
+	volumes, _, _ := unstructured.NestedSlice(usageMap, "volumes")
+	for _, v := range volumes {
+		if volume, ok := v.(map[string]interface{}); ok {
+			name, _, _ := unstructured.NestedString(volume, "name")
+			usage.Volumes = append(usage.Volumes, domain.VolumeUsage{Name: name, Size: quantityString(volume, "size")})
+		}
+	}
+
+	if raw, _, _ := unstructured.NestedString(usageMap, "sampledAt"); raw != "" {
+		if sampledAt, err := time.Parse(time.RFC3339, raw); err == nil {
+			usage.SampledAt = &sampledAt
+		}
+	}
+
+	return usage
+}
+
+// quantityMap reads a ResourceList into resource names and quantity strings.
+func quantityMap(m map[string]interface{}) map[string]string {
+	out := make(map[string]string, len(m))
+	for name := range m {
+		out[name] = quantityString(m, name)
+	}
+	return out
+}
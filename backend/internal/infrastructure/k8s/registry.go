@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"fmt"
+
+	"github.com/Jovial-Kanwadia/store-platform/backend/internal/config"
+	"github.com/Jovial-Kanwadia/store-platform/backend/internal/domain"
+)
+
+// Registry is the set of clusters the backend places stores on, one client
+// per kubeconfig context.
+type Registry struct {
+	clusters []domain.Cluster
+	clients  map[string]*Client
+}
+
+// NewRegistry connects to every context in cfg.ClusterContexts. Without
+// contexts it manages a single cluster named domain.DefaultClusterName,
+// reached through KUBECONFIG or the in-cluster config.
+func NewRegistry(cfg *config.Config) (*Registry, error) {
+	r := &Registry{clients: make(map[string]*Client)}
+
+	if len(cfg.ClusterContexts) == 0 {
+		client, err := NewClient(cfg.KubeConfig, "", cfg.OperatorNamespace)
+		if err != nil {
+			return nil, err
+		}
+		r.add(domain.Cluster{Name: domain.DefaultClusterName, Region: cfg.ClusterRegions[domain.DefaultClusterName]}, client)
+		return r, nil
+	}
+
+	for _, kubeContext := range cfg.ClusterContexts {
+		if _, ok := r.clients[kubeContext]; ok {
+			continue
+		}
+		client, err := NewClient(cfg.KubeConfig, kubeContext, cfg.OperatorNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %q: %w", kubeContext, err)
+		}
+		r.add(domain.Cluster{Name: kubeContext, Region: cfg.ClusterRegions[kubeContext]}, client)
+	}
+
+	return r, nil
+}
+
+func (r *Registry) add(cluster domain.Cluster, client *Client) {
+	r.clusters = append(r.clusters, cluster)
+	r.clients[cluster.Name] = client
+}
+
+// Clusters lists the registered clusters in configuration order.
+func (r *Registry) Clusters() []domain.Cluster {
+	clusters := make([]domain.Cluster, len(r.clusters))
+	copy(clusters, r.clusters)
+	return clusters
+}
+
+// Client returns the client of a registered cluster.
+func (r *Registry) Client(name string) (domain.ClusterClient, bool) {
+	client, ok := r.clients[name]
+	if !ok {
+		return nil, false
+	}
+	return client, true
+}
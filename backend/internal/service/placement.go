@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Jovial-Kanwadia/store-platform/backend/internal/domain"
+)
+
+// ListClusters reports every registered cluster with the number of stores it
+// runs. A cluster that can't be reached is listed with Reachable false.
+func (s *StoreService) ListClusters(ctx context.Context) []domain.ClusterSummary {
+	clusters := s.clusters.Clusters()
+	summaries := make([]domain.ClusterSummary, 0, len(clusters))
+	for _, c := range clusters {
+		summary := domain.ClusterSummary{Cluster: c}
+		if n, err := s.storeCount(ctx, c.Name); err == nil {
+			summary.Stores = n
+			summary.Reachable = true
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// place picks the cluster a new store is created on: the requested cluster,
+// or the least-loaded cluster among those matching the request's region.
+// The region strategy requires a region; a region given with the
+// least-loaded strategy still narrows the candidates.
+func (s *StoreService) place(ctx context.Context, req domain.CreateStoreRequest) (string, domain.ClusterClient, error) {
+	if req.Cluster != "" {
+		client, ok := s.clusters.Client(req.Cluster)
+		if !ok {
+			return "", nil, s.unknownCluster(req.Cluster)
+		}
+		return req.Cluster, client, nil
+	}
+
+	strategy := req.Placement
+	if strategy == "" {
+		strategy = s.cfg.PlacementStrategy
+	}
+	if !domain.AllowedPlacements[strategy] {
+		return "", nil, &domain.APIError{
+			Code:    domain.ErrInvalidPlacement.Code,
+			Message: fmt.Sprintf("invalid placement %q: allowed values are %s, %s", strategy, domain.PlacementLeastLoaded, domain.PlacementRegion),
+		}
+	}
+	if strategy == domain.PlacementRegion && req.Region == "" {
+		return "", nil, &domain.APIError{
+			Code:    domain.ErrInvalidPlacement.Code,
+			Message: "region placement needs a region",
+		}
+	}
+
+	candidates := make([]domain.Cluster, 0)
+	for _, c := range s.clusters.Clusters() {
+		if req.Region == "" || c.Region == req.Region {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", nil, &domain.APIError{
+			Code:    domain.ErrInvalidPlacement.Code,
+			Message: fmt.Sprintf("no cluster in region %q", req.Region),
+		}
+	}
+
+	cluster, err := s.leastLoaded(ctx, candidates)
+	if err != nil {
+		return "", nil, err
+	}
+	client, _ := s.clusters.Client(cluster)
+	return cluster, client, nil
+}
+
+// leastLoaded returns the candidate running the fewest stores; ties go to
+// the cluster registered first. Unreachable clusters are skipped.
+func (s *StoreService) leastLoaded(ctx context.Context, candidates []domain.Cluster) (string, error) {
+	if len(candidates) == 1 {
+		return candidates[0].Name, nil
+	}
+
+	best, bestCount := "", 0
+	for _, c := range candidates {
+		n, err := s.storeCount(ctx, c.Name)
+		if err != nil {
+			slog.Warn("skipping unreachable cluster for placement", "cluster", c.Name, "error", err)
+			continue
+		}
+		if best == "" || n < bestCount {
+			best, bestCount = c.Name, n
+		}
+	}
+
+	if best == "" {
+		return "", &domain.APIError{
+			Code:    domain.ErrClusterUnavailable.Code,
+			Message: "no cluster is reachable",
+		}
+	}
+	return best, nil
+}
+
+// storeCount counts the stores in every namespace of a cluster.
+func (s *StoreService) storeCount(ctx context.Context, cluster string) (int, error) {
+	client, _ := s.clusters.Client(cluster)
+	stores, err := client.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	return len(stores), nil
+}
+
+// targetClusters resolves a cluster filter: every cluster when empty,
+// otherwise the named one.
+func (s *StoreService) targetClusters(cluster string) ([]domain.Cluster, error) {
+	if cluster == "" {
+		return s.clusters.Clusters(), nil
+	}
+	for _, c := range s.clusters.Clusters() {
+		if c.Name == cluster {
+			return []domain.Cluster{c}, nil
+		}
+	}
+	return nil, s.unknownCluster(cluster)
+}
+
+// locate gets a store from each of clusters, returning every match. Clusters
+// that can't be asked are reported in an ErrClusterUnavailable error next to
+// the matches from the others.
+func (s *StoreService) locate(ctx context.Context, name, namespace string, clusters []domain.Cluster) ([]domain.Store, error) {
+	stores := make([]domain.Store, 0, 1)
+	var unreachable []string
+	for _, c := range clusters {
+		client, _ := s.clusters.Client(c.Name)
+		store, err := client.Get(ctx, name, namespace)
+		if errors.Is(err, domain.ErrStoreNotFound) {
+			continue
+		}
+		if err != nil {
+			slog.Warn("failed to get store", "cluster", c.Name, "store", name, "error", err)
+			unreachable = append(unreachable, c.Name)
+			continue
+		}
+		store.Cluster = c.Name
+		stores = append(stores, *store)
+	}
+	if len(unreachable) > 0 {
+		return stores, &domain.APIError{
+			Code:    domain.ErrClusterUnavailable.Code,
+			Message: fmt.Sprintf("can't look up store %q in clusters %s", name, strings.Join(unreachable, ", ")),
+		}
+	}
+	return stores, nil
+}
+
+func (s *StoreService) unknownCluster(cluster string) *domain.APIError {
+	clusters := s.clusters.Clusters()
+	names := make([]string, 0, len(clusters))
+	for _, c := range clusters {
+		names = append(names, c.Name)
+	}
+	return &domain.APIError{
+		Code:    domain.ErrInvalidCluster.Code,
+		Message: fmt.Sprintf("invalid cluster %q: registered clusters are %s", cluster, strings.Join(names, ", ")),
+	}
+}
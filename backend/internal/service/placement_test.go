@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Jovial-Kanwadia/store-platform/backend/internal/config"
+	"github.com/Jovial-Kanwadia/store-platform/backend/internal/domain"
+)
+
+// fakeCluster is a ClusterClient holding a fixed set of stores, or failing
+// every call with err.
+type fakeCluster struct {
+	stores []domain.Store
+	err    error
+}
+
+func (f *fakeCluster) Create(ctx context.Context, s domain.Store) error { return f.err }
+
+func (f *fakeCluster) List(ctx context.Context, namespace string) ([]domain.Store, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	stores := make([]domain.Store, 0, len(f.stores))
+	for _, s := range f.stores {
+		if namespace == "" || s.Namespace == namespace {
+			stores = append(stores, s)
+		}
+	}
+	return stores, nil
+}
+
+func (f *fakeCluster) Get(ctx context.Context, name, namespace string) (*domain.Store, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, s := range f.stores {
+		if s.Name == name && s.Namespace == namespace {
+			return &s, nil
+		}
+	}
+	return nil, domain.ErrStoreNotFound
+}
+
+func (f *fakeCluster) Delete(ctx context.Context, name, namespace string) error { return f.err }
+
+func (f *fakeCluster) SetSuspended(ctx context.Context, name, namespace string, suspended bool) error {
+	return f.err
+}
+
+func (f *fakeCluster) ListEngines(ctx context.Context) ([]string, error) { return nil, f.err }
+
+func (f *fakeCluster) ListPlans(ctx context.Context) ([]domain.Plan, error) { return nil, f.err }
+
+// fakeRegistry registers clusters in the order given.
+type fakeRegistry struct {
+	clusters []domain.Cluster
+	clients  map[string]*fakeCluster
+}
+
+func (r *fakeRegistry) Clusters() []domain.Cluster { return r.clusters }
+
+func (r *fakeRegistry) Client(name string) (domain.ClusterClient, bool) {
+	c, ok := r.clients[name]
+	return c, ok
+}
+
+// testCluster describes one registered cluster of a test case.
+type testCluster struct {
+	name        string
+	region      string
+	stores      int
+	namespace   string
+	unreachable bool
+}
+
+func newTestService(strategy string, clusters ...testCluster) *StoreService {
+	registry := &fakeRegistry{clients: map[string]*fakeCluster{}}
+	for _, c := range clusters {
+		client := &fakeCluster{}
+		namespace := c.namespace
+		if namespace == "" {
+			namespace = domain.DefaultNamespace
+		}
+		for i := 0; i < c.stores; i++ {
+			client.stores = append(client.stores, domain.Store{Name: c.name + "-store", Namespace: namespace})
+		}
+		if c.unreachable {
+			client.err = errors.New("connection refused")
+		}
+		registry.clusters = append(registry.clusters, domain.Cluster{Name: c.name, Region: c.region})
+		registry.clients[c.name] = client
+	}
+	return NewStoreService(registry, &config.Config{PlacementStrategy: strategy})
+}
+
+// errorCode returns the HTTP code of an APIError, or 0 for nil.
+func errorCode(t *testing.T, err error) int {
+	t.Helper()
+	if err == nil {
+		return 0
+	}
+	var apiErr *domain.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error %v is not an APIError", err)
+	}
+	return apiErr.Code
+}
+
+func TestPlace(t *testing.T) {
+	threeRegions := []testCluster{
+		{name: "eu-1", region: "eu", stores: 3},
+		{name: "eu-2", region: "eu", stores: 1},
+		{name: "us-1", region: "us", stores: 0},
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		clusters []testCluster
+		req      domain.CreateStoreRequest
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "requested cluster wins over load",
+			strategy: domain.PlacementLeastLoaded,
+			clusters: threeRegions,
+			req:      domain.CreateStoreRequest{Cluster: "eu-1"},
+			want:     "eu-1",
+		},
+		{
+			name:     "unknown requested cluster",
+			strategy: domain.PlacementLeastLoaded,
+			clusters: threeRegions,
+			req:      domain.CreateStoreRequest{Cluster: "ap-1"},
+			wantCode: domain.ErrInvalidCluster.Code,
+		},
+		{
+			name:     "least loaded across every cluster",
+			strategy: domain.PlacementLeastLoaded,
+			clusters: threeRegions,
+			want:     "us-1",
+		},
+		{
+			name:     "region narrows least loaded",
+			strategy: domain.PlacementLeastLoaded,
+			clusters: threeRegions,
+			req:      domain.CreateStoreRequest{Region: "eu"},
+			want:     "eu-2",
+		},
+		{
+			name:     "region strategy picks the least loaded in the region",
+			strategy: domain.PlacementRegion,
+			clusters: threeRegions,
+			req:      domain.CreateStoreRequest{Region: "eu"},
+			want:     "eu-2",
+		},
+		{
+			name:     "request overrides the default strategy",
+			strategy: domain.PlacementRegion,
+			clusters: threeRegions,
+			req:      domain.CreateStoreRequest{Placement: domain.PlacementLeastLoaded},
+			want:     "us-1",
+		},
+		{
+			name:     "region strategy without a region",
+			strategy: domain.PlacementRegion,
+			clusters: threeRegions,
+			wantCode: domain.ErrInvalidPlacement.Code,
+		},
+		{
+			name:     "unknown strategy",
+			strategy: domain.PlacementLeastLoaded,
+			clusters: threeRegions,
+			req:      domain.CreateStoreRequest{Placement: "random"},
+			wantCode: domain.ErrInvalidPlacement.Code,
+		},
+		{
+			name:     "no cluster in the region",
+			strategy: domain.PlacementRegion,
+			clusters: threeRegions,
+			req:      domain.CreateStoreRequest{Region: "ap"},
+			wantCode: domain.ErrInvalidPlacement.Code,
+		},
+		{
+			name:     "unreachable clusters are skipped",
+			strategy: domain.PlacementLeastLoaded,
+			clusters: []testCluster{
+				{name: "a", stores: 2},
+				{name: "b", unreachable: true},
+			},
+			want: "a",
+		},
+		{
+			name:     "no reachable cluster",
+			strategy: domain.PlacementLeastLoaded,
+			clusters: []testCluster{
+				{name: "a", unreachable: true},
+				{name: "b", unreachable: true},
+			},
+			wantCode: domain.ErrClusterUnavailable.Code,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(tt.strategy, tt.clusters...)
+			got, client, err := s.place(context.Background(), tt.req)
+			if code := errorCode(t, err); code != tt.wantCode {
+				t.Fatalf("place() error = %v, want code %d", err, tt.wantCode)
+			}
+			if got != tt.want {
+				t.Errorf("place() cluster = %q, want %q", got, tt.want)
+			}
+			if tt.wantCode == 0 && client == nil {
+				t.Errorf("place() returned no client for %q", got)
+			}
+		})
+	}
+}
+
+func TestLeastLoaded(t *testing.T) {
+	tests := []struct {
+		name     string
+		clusters []testCluster
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "single candidate is not counted",
+			clusters: []testCluster{{name: "a", unreachable: true}},
+			want:     "a",
+		},
+		{
+			name: "fewest stores",
+			clusters: []testCluster{
+				{name: "a", stores: 4},
+				{name: "b", stores: 2},
+				{name: "c", stores: 3},
+			},
+			want: "b",
+		},
+		{
+			name: "ties go to the cluster registered first",
+			clusters: []testCluster{
+				{name: "a", stores: 1},
+				{name: "b", stores: 1},
+			},
+			want: "a",
+		},
+		{
+			name: "unreachable clusters are skipped",
+			clusters: []testCluster{
+				{name: "a", unreachable: true},
+				{name: "b", stores: 5},
+			},
+			want: "b",
+		},
+		{
+			name: "none reachable",
+			clusters: []testCluster{
+				{name: "a", unreachable: true},
+				{name: "b", unreachable: true},
+			},
+			wantCode: domain.ErrClusterUnavailable.Code,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(domain.PlacementLeastLoaded, tt.clusters...)
+			got, err := s.leastLoaded(context.Background(), s.clusters.Clusters())
+			if code := errorCode(t, err); code != tt.wantCode {
+				t.Fatalf("leastLoaded() error = %v, want code %d", err, tt.wantCode)
+			}
+			if got != tt.want {
+				t.Errorf("leastLoaded() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckUnique(t *testing.T) {
+	tests := []struct {
+		name     string
+		clusters []testCluster
+		wantCode int
+	}{
+		{
+			name:     "free everywhere",
+			clusters: []testCluster{{name: "a"}, {name: "b"}},
+		},
+		{
+			name:     "taken in another cluster",
+			clusters: []testCluster{{name: "a"}, {name: "b", stores: 1}},
+			wantCode: domain.ErrStoreExists.Code,
+		},
+		{
+			name:     "taken in another namespace",
+			clusters: []testCluster{{name: "a"}, {name: "b", stores: 1, namespace: "team-b"}},
+			wantCode: domain.ErrStoreExists.Code,
+		},
+		{
+			name:     "a cluster can't be asked",
+			clusters: []testCluster{{name: "a"}, {name: "b", unreachable: true}},
+			wantCode: domain.ErrClusterUnavailable.Code,
+		},
+		{
+			name:     "taken wins over unreachable",
+			clusters: []testCluster{{name: "a", unreachable: true}, {name: "b", stores: 1}},
+			wantCode: domain.ErrStoreExists.Code,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(domain.PlacementLeastLoaded, tt.clusters...)
+			err := s.checkUnique(context.Background(), "b-store")
+			if code := errorCode(t, err); code != tt.wantCode {
+				t.Errorf("checkUnique() error = %v, want code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
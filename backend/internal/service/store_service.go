@@ -3,22 +3,36 @@ package service
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/mail"
 	"regexp"
+	"slices"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/resource"
+
 	"github.com/Jovial-Kanwadia/store-platform/backend/internal/config"
 	"github.com/Jovial-Kanwadia/store-platform/backend/internal/domain"
 )
 
 var dnsNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
 
+// spec.site patterns — must match operator/api/v1alpha1/store_types.go
+var (
+	adminEmailRegex    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	adminUsernameRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+	localeRegex        = regexp.MustCompile(`^[a-z]{2,3}(_[A-Z]{2})?$`)
+	currencyRegex      = regexp.MustCompile(`^[A-Z]{3}$`)
+	countryRegex       = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
 type StoreService struct {
-	repo domain.StoreRepository
-	cfg  *config.Config
+	clusters domain.ClusterRegistry
+	cfg      *config.Config
 }
 
-func NewStoreService(repo domain.StoreRepository, cfg *config.Config) *StoreService {
-	return &StoreService{repo: repo, cfg: cfg}
+func NewStoreService(clusters domain.ClusterRegistry, cfg *config.Config) *StoreService {
+	return &StoreService{clusters: clusters, cfg: cfg}
 }
 
 func (s *StoreService) CreateStore(ctx context.Context, req domain.CreateStoreRequest) (*domain.Store, error) {
@@ -26,17 +40,24 @@ func (s *StoreService) CreateStore(ctx context.Context, req domain.CreateStoreRe
 		return nil, &domain.APIError{Code: domain.ErrInvalidName.Code, Message: err.Error()}
 	}
 
-	if !domain.AllowedPlans[req.Plan] {
-		return nil, &domain.APIError{
-			Code:    domain.ErrInvalidPlan.Code,
-			Message: fmt.Sprintf("invalid plan %q: allowed values are small, medium, large", req.Plan),
-		}
+	if err := validateSite(req.Site); err != nil {
+		return nil, &domain.APIError{Code: domain.ErrInvalidSite.Code, Message: err.Error()}
 	}
 
-	if !domain.AllowedEngines[req.Engine] {
+	cluster, client, err := s.place(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validatePlan(ctx, client, req.Plan); err != nil {
+		return nil, err
+	}
+
+	engines := listEngines(ctx, client)
+	if !slices.Contains(engines, req.Engine) {
 		return nil, &domain.APIError{
 			Code:    domain.ErrInvalidEngine.Code,
-			Message: fmt.Sprintf("invalid engine %q: allowed values are woo", req.Engine),
+			Message: fmt.Sprintf("invalid engine %q: allowed values are %s", req.Engine, strings.Join(engines, ", ")),
 		}
 	}
 
@@ -45,25 +66,22 @@ func (s *StoreService) CreateStore(ctx context.Context, req domain.CreateStoreRe
 		namespace = domain.DefaultNamespace
 	}
 
-	// Duplicate check
-	existing, _ := s.repo.Get(ctx, strings.ToLower(req.Name), namespace)
-	if existing != nil {
-		return nil, &domain.APIError{
-			Code:    domain.ErrStoreExists.Code,
-			Message: fmt.Sprintf("store %q already exists", req.Name),
-		}
+	if err := s.checkUnique(ctx, req.Name); err != nil {
+		return nil, err
 	}
 
 	store := domain.Store{
 		Name:      strings.ToLower(req.Name),
+		Cluster:   cluster,
 		Namespace: namespace,
 		Engine:    req.Engine,
 		Plan:      req.Plan,
+		Site:      req.Site,
 		Status:    domain.StatusPending,
 		URL:       fmt.Sprintf("https://%s.%s", req.Name, s.cfg.BaseDomain),
 	}
 
-	if err := s.repo.Create(ctx, store); err != nil {
+	if err := client.Create(ctx, store); err != nil {
 		return nil, &domain.APIError{
 			Code:    domain.ErrInternal.Code,
 			Message: "failed to create store",
@@ -73,9 +91,120 @@ func (s *StoreService) CreateStore(ctx context.Context, req domain.CreateStoreRe
 	return &store, nil
 }
 
-func (s *StoreService) ListStores(ctx context.Context, namespace string) ([]domain.Store, error) {
-	stores, err := s.repo.List(ctx, namespace)
+// CloneStore provisions a new store next to an existing one and has the
+// operator copy the source's database and content into it. The clone gets
+// its own credentials and hostname.
+func (s *StoreService) CloneStore(ctx context.Context, name, namespace, cluster string, req domain.CloneStoreRequest) (*domain.Store, error) {
+	if err := validateStoreName(req.Name); err != nil {
+		return nil, &domain.APIError{Code: domain.ErrInvalidName.Code, Message: err.Error()}
+	}
+
+	source, err := s.GetStore(ctx, name, namespace, cluster)
 	if err != nil {
+		return nil, err
+	}
+
+	// The operator reads the source's data from its running pods
+	if source.Suspended {
+		return nil, &domain.APIError{
+			Code:    domain.ErrStoreSuspended.Code,
+			Message: fmt.Sprintf("store %q is suspended: resume it before cloning", source.Name),
+		}
+	}
+
+	plan := req.Plan
+	if plan == "" {
+		plan = source.Plan
+	}
+	client, _ := s.clusters.Client(source.Cluster)
+	if err := validatePlan(ctx, client, plan); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkUnique(ctx, req.Name); err != nil {
+		return nil, err
+	}
+
+	store := domain.Store{
+		Name:      strings.ToLower(req.Name),
+		Cluster:   source.Cluster,
+		Namespace: source.Namespace,
+		Engine:    source.Engine,
+		Plan:      plan,
+		CloneFrom: source.Name,
+		Status:    domain.StatusPending,
+		URL:       fmt.Sprintf("https://%s.%s", req.Name, s.cfg.BaseDomain),
+	}
+
+	if err := client.Create(ctx, store); err != nil {
+		return nil, &domain.APIError{
+			Code:    domain.ErrInternal.Code,
+			Message: "failed to create store",
+		}
+	}
+
+	return &store, nil
+}
+
+// checkUnique rejects a store name already used in any namespace of any
+// cluster: all stores share BASE_DOMAIN, so the name must stay unique for
+// their hostnames to. It also fails while any cluster can't be asked, since
+// the name might be taken there.
+func (s *StoreService) checkUnique(ctx context.Context, name string) error {
+	name = strings.ToLower(name)
+	var unreachable []string
+	for _, c := range s.clusters.Clusters() {
+		client, _ := s.clusters.Client(c.Name)
+		stores, err := client.List(ctx, "")
+		if err != nil {
+			slog.Warn("failed to list stores", "cluster", c.Name, "error", err)
+			unreachable = append(unreachable, c.Name)
+			continue
+		}
+		for _, store := range stores {
+			if store.Name == name {
+				return &domain.APIError{
+					Code:    domain.ErrStoreExists.Code,
+					Message: fmt.Sprintf("store %q already exists in cluster %s", name, c.Name),
+				}
+			}
+		}
+	}
+	if len(unreachable) > 0 {
+		return &domain.APIError{
+			Code:    domain.ErrClusterUnavailable.Code,
+			Message: fmt.Sprintf("can't check store name %q in clusters %s", name, strings.Join(unreachable, ", ")),
+		}
+	}
+	return nil
+}
+
+// ListStores lists the stores of one cluster, or of every cluster when
+// cluster is empty. Unreachable clusters are left out of an aggregated list
+// unless none can be reached.
+func (s *StoreService) ListStores(ctx context.Context, namespace, cluster string) ([]domain.Store, error) {
+	clusters, err := s.targetClusters(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	stores := make([]domain.Store, 0)
+	failed := 0
+	for _, c := range clusters {
+		client, _ := s.clusters.Client(c.Name)
+		list, err := client.List(ctx, namespace)
+		if err != nil {
+			slog.Warn("failed to list stores", "cluster", c.Name, "error", err)
+			failed++
+			continue
+		}
+		for _, store := range list {
+			store.Cluster = c.Name
+			stores = append(stores, store)
+		}
+	}
+
+	if failed == len(clusters) {
 		return nil, &domain.APIError{
 			Code:    domain.ErrInternal.Code,
 			Message: "failed to list stores",
@@ -85,28 +214,52 @@ func (s *StoreService) ListStores(ctx context.Context, namespace string) ([]doma
 	return stores, nil
 }
 
-func (s *StoreService) GetStore(ctx context.Context, name, namespace string) (*domain.Store, error) {
+// GetStore finds a store in one cluster, or searches every cluster when
+// cluster is empty.
+func (s *StoreService) GetStore(ctx context.Context, name, namespace, cluster string) (*domain.Store, error) {
 	if namespace == "" {
 		namespace = domain.DefaultNamespace
 	}
 
-	store, err := s.repo.Get(ctx, name, namespace)
+	clusters, err := s.targetClusters(cluster)
 	if err != nil {
+		return nil, err
+	}
+
+	// A match is served even when another cluster can't be asked; without
+	// one, an unreachable cluster means the store might still exist
+	stores, err := s.locate(ctx, name, namespace, clusters)
+	switch len(stores) {
+	case 0:
+		if err != nil {
+			return nil, err
+		}
 		return nil, &domain.APIError{
 			Code:    domain.ErrStoreNotFound.Code,
 			Message: "store not found",
 		}
+	case 1:
+		return &stores[0], nil
 	}
 
-	return store, nil
+	names := make([]string, 0, len(stores))
+	for _, store := range stores {
+		names = append(names, store.Cluster)
+	}
+	return nil, &domain.APIError{
+		Code:    domain.ErrAmbiguousStore.Code,
+		Message: fmt.Sprintf("store %q exists in clusters %s: pass ?cluster= to pick one", name, strings.Join(names, ", ")),
+	}
 }
 
-func (s *StoreService) DeleteStore(ctx context.Context, name, namespace string) error {
-	if namespace == "" {
-		namespace = domain.DefaultNamespace
+func (s *StoreService) DeleteStore(ctx context.Context, name, namespace, cluster string) error {
+	store, err := s.GetStore(ctx, name, namespace, cluster)
+	if err != nil {
+		return err
 	}
 
-	if err := s.repo.Delete(ctx, name, namespace); err != nil {
+	client, _ := s.clusters.Client(store.Cluster)
+	if err := client.Delete(ctx, store.Name, store.Namespace); err != nil {
 		return &domain.APIError{
 			Code:    domain.ErrInternal.Code,
 			Message: "failed to delete store",
@@ -116,6 +269,256 @@ func (s *StoreService) DeleteStore(ctx context.Context, name, namespace string)
 	return nil
 }
 
+// SuspendStore scales a store to zero while keeping its data.
+func (s *StoreService) SuspendStore(ctx context.Context, name, namespace, cluster string) (*domain.Store, error) {
+	return s.setSuspended(ctx, name, namespace, cluster, true)
+}
+
+// ResumeStore brings a suspended store back up.
+func (s *StoreService) ResumeStore(ctx context.Context, name, namespace, cluster string) (*domain.Store, error) {
+	return s.setSuspended(ctx, name, namespace, cluster, false)
+}
+
+func (s *StoreService) setSuspended(ctx context.Context, name, namespace, cluster string, suspended bool) (*domain.Store, error) {
+	store, err := s.GetStore(ctx, name, namespace, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	if store.Suspended == suspended {
+		return store, nil
+	}
+
+	client, _ := s.clusters.Client(store.Cluster)
+	if err := client.SetSuspended(ctx, store.Name, store.Namespace, suspended); err != nil {
+		return nil, &domain.APIError{
+			Code:    domain.ErrInternal.Code,
+			Message: "failed to update store",
+		}
+	}
+
+	store.Suspended = suspended
+	return store, nil
+}
+
+// GetStoreUsage returns the latest usage sample the operator recorded for a store.
+func (s *StoreService) GetStoreUsage(ctx context.Context, name, namespace, cluster string) (*domain.StoreUsage, error) {
+	store, err := s.GetStore(ctx, name, namespace, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	if store.Usage == nil {
+		return nil, domain.ErrNoUsage
+	}
+
+	return store.Usage, nil
+}
+
+// FleetUsage totals the sampled usage of every store, overall and per plan.
+// Stores the operator hasn't sampled yet are counted but add no usage.
+func (s *StoreService) FleetUsage(ctx context.Context, namespace, cluster string) (*domain.FleetUsage, error) {
+	stores, err := s.ListStores(ctx, namespace, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	total := newUsageTotals()
+	perPlan := make(map[string]*usageTotals)
+	for _, store := range stores {
+		plan, ok := perPlan[store.Plan]
+		if !ok {
+			plan = newUsageTotals()
+			perPlan[store.Plan] = plan
+		}
+		total.add(store.Usage)
+		plan.add(store.Usage)
+	}
+
+	fleet := &domain.FleetUsage{
+		Stores:  total.stores,
+		Sampled: total.sampled,
+		Used:    quantityStrings(total.used),
+		Hard:    quantityStrings(total.hard),
+		Storage: total.storage.String(),
+		Plans:   make([]domain.PlanUsage, 0, len(perPlan)),
+	}
+	for name, plan := range perPlan {
+		fleet.Plans = append(fleet.Plans, domain.PlanUsage{
+			Plan:    name,
+			Stores:  plan.stores,
+			Sampled: plan.sampled,
+			Used:    quantityStrings(plan.used),
+			Hard:    quantityStrings(plan.hard),
+			Storage: plan.storage.String(),
+		})
+	}
+	slices.SortFunc(fleet.Plans, func(a, b domain.PlanUsage) int {
+		return strings.Compare(a.Plan, b.Plan)
+	})
+
+	return fleet, nil
+}
+
+// usageTotals sums store usage samples as Kubernetes quantities.
+type usageTotals struct {
+	stores  int
+	sampled int
+	used    map[string]*resource.Quantity
+	hard    map[string]*resource.Quantity
+	storage *resource.Quantity
+}
+
+func newUsageTotals() *usageTotals {
+	return &usageTotals{
+		used:    make(map[string]*resource.Quantity),
+		hard:    make(map[string]*resource.Quantity),
+		storage: resource.NewQuantity(0, resource.BinarySI),
+	}
+}
+
+func (t *usageTotals) add(usage *domain.StoreUsage) {
+	t.stores++
+	if usage == nil {
+		return
+	}
+
+	t.sampled++
+	addQuantities(t.used, usage.Used)
+	addQuantities(t.hard, usage.Hard)
+	if q, err := resource.ParseQuantity(usage.Storage); err == nil {
+		t.storage.Add(q)
+	}
+}
+
+// addQuantities adds each parseable quantity in values to sums; values the
+// operator wrote in a form we can't parse are skipped rather than failing the summary.
+func addQuantities(sums map[string]*resource.Quantity, values map[string]string) {
+	for name, value := range values {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			slog.Warn("skipping unparseable usage quantity", "resource", name, "value", value)
+			continue
+		}
+		if sum, ok := sums[name]; ok {
+			sum.Add(q)
+		} else {
+			sums[name] = &q
+		}
+	}
+}
+
+func quantityStrings(sums map[string]*resource.Quantity) map[string]string {
+	out := make(map[string]string, len(sums))
+	for name, q := range sums {
+		out[name] = q.String()
+	}
+	return out
+}
+
+// ListPlans returns the StorePlans defined in any registered cluster.
+func (s *StoreService) ListPlans(ctx context.Context) ([]domain.Plan, error) {
+	plans := make([]domain.Plan, 0)
+	seen := make(map[string]bool)
+	var lastErr error
+	for _, c := range s.clusters.Clusters() {
+		client, _ := s.clusters.Client(c.Name)
+		clusterPlans, err := listPlans(ctx, client)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, p := range clusterPlans {
+			if !seen[p.Name] {
+				seen[p.Name] = true
+				plans = append(plans, p)
+			}
+		}
+	}
+
+	if len(plans) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+
+	slices.SortFunc(plans, func(a, b domain.Plan) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return plans, nil
+}
+
+// ListEngines returns the engines supported in any registered cluster.
+func (s *StoreService) ListEngines(ctx context.Context) []string {
+	engines := make([]string, 0)
+	for _, c := range s.clusters.Clusters() {
+		client, _ := s.clusters.Client(c.Name)
+		for _, e := range listEngines(ctx, client) {
+			if !slices.Contains(engines, e) {
+				engines = append(engines, e)
+			}
+		}
+	}
+
+	slices.Sort(engines)
+	return engines
+}
+
+// listPlans returns the StorePlans currently defined in a cluster.
+func listPlans(ctx context.Context, catalog domain.PlanCatalog) ([]domain.Plan, error) {
+	plans, err := catalog.ListPlans(ctx)
+	if err != nil {
+		return nil, &domain.APIError{
+			Code:    domain.ErrInternal.Code,
+			Message: "failed to list plans",
+		}
+	}
+
+	slices.SortFunc(plans, func(a, b domain.Plan) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return plans, nil
+}
+
+// validatePlan checks that a plan is defined in a cluster.
+func validatePlan(ctx context.Context, catalog domain.PlanCatalog, plan string) error {
+	plans, err := listPlans(ctx, catalog)
+	if err != nil {
+		return err
+	}
+
+	planNames := make([]string, 0, len(plans))
+	for _, p := range plans {
+		planNames = append(planNames, p.Name)
+	}
+
+	if !slices.Contains(planNames, plan) {
+		return &domain.APIError{
+			Code:    domain.ErrInvalidPlan.Code,
+			Message: fmt.Sprintf("invalid plan %q: allowed values are %s", plan, strings.Join(planNames, ", ")),
+		}
+	}
+	return nil
+}
+
+// listEngines returns the engines a cluster's operator advertises, falling
+// back to the built-in list when the operator's capabilities cannot be read.
+func listEngines(ctx context.Context, catalog domain.EngineCatalog) []string {
+	engines, err := catalog.ListEngines(ctx)
+	if err == nil && len(engines) > 0 {
+		return engines
+	}
+
+	if err != nil {
+		slog.Warn("falling back to built-in engine list", "error", err)
+	}
+
+	fallback := make([]string, 0, len(domain.AllowedEngines))
+	for e := range domain.AllowedEngines {
+		fallback = append(fallback, e)
+	}
+	slices.Sort(fallback)
+	return fallback
+}
+
 func validateStoreName(name string) error {
 	if name == "" {
 		return fmt.Errorf("store name cannot be empty")
@@ -131,3 +534,66 @@ func validateStoreName(name string) error {
 
 	return nil
 }
+
+// validateSite checks site settings against the limits the Store CRD
+// enforces, so a bad request fails here rather than at admission.
+func validateSite(site *domain.SiteSettings) error {
+	if site == nil {
+		return nil
+	}
+
+	if len(site.Title) > domain.MaxSiteTitleLength {
+		return fmt.Errorf("site title must be %d characters or less", domain.MaxSiteTitleLength)
+	}
+
+	if site.AdminEmail != "" {
+		if len(site.AdminEmail) > domain.MaxAdminEmailLength {
+			return fmt.Errorf("admin email must be %d characters or less", domain.MaxAdminEmailLength)
+		}
+		// The CRD's pattern also wants a dot in the domain, which mail accepts without
+		if addr, err := mail.ParseAddress(site.AdminEmail); err != nil || addr.Address != site.AdminEmail ||
+			!adminEmailRegex.MatchString(site.AdminEmail) {
+			return fmt.Errorf("admin email %q is not a valid address", site.AdminEmail)
+		}
+	}
+
+	if site.AdminUsername != "" {
+		if len(site.AdminUsername) > domain.MaxAdminUsernameLength {
+			return fmt.Errorf("admin username must be %d characters or less", domain.MaxAdminUsernameLength)
+		}
+		if !adminUsernameRegex.MatchString(site.AdminUsername) {
+			return fmt.Errorf("admin username must be letters, digits, dots, hyphens and underscores")
+		}
+	}
+
+	if site.Locale != "" && !localeRegex.MatchString(site.Locale) {
+		return fmt.Errorf("locale %q must look like en_US or de", site.Locale)
+	}
+
+	if site.Currency != "" && !currencyRegex.MatchString(site.Currency) {
+		return fmt.Errorf("currency %q must be an uppercase ISO 4217 code such as USD", site.Currency)
+	}
+
+	if a := site.Address; a != nil {
+		if a.Line1 == "" || a.City == "" || a.Country == "" {
+			return fmt.Errorf("store address needs line1, city and country")
+		}
+		if len(a.Line1) > domain.MaxAddressLineLength || len(a.Line2) > domain.MaxAddressLineLength {
+			return fmt.Errorf("store address lines must be %d characters or less", domain.MaxAddressLineLength)
+		}
+		if len(a.City) > domain.MaxCityLength {
+			return fmt.Errorf("store city must be %d characters or less", domain.MaxCityLength)
+		}
+		if len(a.Postcode) > domain.MaxPostcodeLength {
+			return fmt.Errorf("store postcode must be %d characters or less", domain.MaxPostcodeLength)
+		}
+		if !countryRegex.MatchString(a.Country) {
+			return fmt.Errorf("store country %q must be an uppercase ISO 3166-1 alpha-2 code such as US", a.Country)
+		}
+		if len(a.State) > domain.MaxStateLength {
+			return fmt.Errorf("store state must be %d characters or less", domain.MaxStateLength)
+		}
+	}
+
+	return nil
+}
@@ -26,6 +26,14 @@ export function StatusBadge({ status }: { status: unknown }) {
     )
   }
 
+  if (normalized === "Degraded") {
+    return (
+      <Badge className={cn("bg-orange-500 text-black hover:bg-orange-500")}>
+        Degraded
+      </Badge>
+    )
+  }
+
   if (normalized === "Failed") {
     return (
       <Badge variant="destructive" className={cn("hover:bg-destructive")}>
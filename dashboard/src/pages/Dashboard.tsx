@@ -89,6 +89,7 @@ export default function Dashboard() {
           <TableHeader>
             <TableRow>
               <TableHead>Name</TableHead>
+              <TableHead>Cluster</TableHead>
               <TableHead>Plan</TableHead>
               <TableHead>Status</TableHead>
               <TableHead>URL</TableHead>
@@ -98,20 +99,21 @@ export default function Dashboard() {
           <TableBody>
             {storesQuery.isLoading ? (
               <TableRow>
-                <TableCell colSpan={5} className="text-muted-foreground">
+                <TableCell colSpan={6} className="text-muted-foreground">
                   Loading...
                 </TableCell>
               </TableRow>
             ) : stores.length === 0 ? (
               <TableRow>
-                <TableCell colSpan={5} className="text-muted-foreground">
+                <TableCell colSpan={6} className="text-muted-foreground">
                   No stores found.
                 </TableCell>
               </TableRow>
             ) : (
               stores.map((s) => (
-                <TableRow key={`${s.namespace}/${s.name}`}>
+                <TableRow key={`${s.cluster}/${s.namespace}/${s.name}`}>
                   <TableCell className="font-medium">{s.name}</TableCell>
+                  <TableCell>{s.cluster}</TableCell>
                   <TableCell>{s.plan}</TableCell>
                   <TableCell>
                     <StatusBadge
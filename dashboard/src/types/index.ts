@@ -1,11 +1,16 @@
 export interface Store {
   name: string
+  cluster: string
   namespace: string
   engine: string
   plan: string
 
   status: string
+  reason?: string
+  message?: string
   url?: string
+  cloneFrom?: string
+  site?: SiteSettings
   createdAt: string
 }
 
@@ -14,4 +19,26 @@ export interface CreateStoreRequest {
   plan: string
   engine: string
   namespace?: string
+  cluster?: string
+  placement?: "least-loaded" | "region"
+  region?: string
+  site?: SiteSettings
+}
+
+export interface StoreAddress {
+  line1: string
+  line2?: string
+  city: string
+  postcode?: string
+  country: string
+  state?: string
+}
+
+export interface SiteSettings {
+  title?: string
+  adminEmail?: string
+  adminUsername?: string
+  locale?: string
+  currency?: string
+  address?: StoreAddress
 }
@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -24,26 +26,333 @@ import (
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
 // StoreSpec defines the desired state of Store
+// +kubebuilder:validation:XValidation:rule="!(has(self.restoreFrom) && has(self.cloneFrom))",message="restoreFrom and cloneFrom are mutually exclusive"
+// +kubebuilder:validation:XValidation:rule="has(self.site) == has(oldSelf.site)",message="site can only be set when the store is created"
 type StoreSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
 	// Engine type: woo | medusa
+	// +kubebuilder:validation:Enum=woo;medusa
 	Engine string `json:"engine"`
 
 	// Plan or size (small, medium, etc)
 	Plan string `json:"plan"`
+
+	// Replicas pins the number of application replicas, replacing the plan's
+	// replicas and autoscaling; it must fit within the plan's maxPods
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Suspended scales the store's workloads to zero while keeping its data
+	// +optional
+	Suspended bool `json:"suspended,omitempty"`
+
+	// Credentials controls rotation of the store's generated passwords
+	// +optional
+	Credentials *CredentialsSpec `json:"credentials,omitempty"`
+
+	// RestoreFrom loads a StoreBackup into the store before it becomes Ready
+	// +optional
+	RestoreFrom *RestoreSource `json:"restoreFrom,omitempty"`
+
+	// CloneFrom copies another store's database and content into the store
+	// before it becomes Ready
+	// +optional
+	CloneFrom *CloneSource `json:"cloneFrom,omitempty"`
+
+	// Site seeds the new store's title, admin user, locale, currency and
+	// address. The engine applies it when the store is first installed, so it
+	// can't be changed afterwards.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="site can only be set when the store is created"
+	// +optional
+	Site *SiteSpec `json:"site,omitempty"`
+
+	// Chart overrides the engine's default chart source or version
+	// +optional
+	Chart *ChartSource `json:"chart,omitempty"`
+
+	// DeletionPolicy decides what happens to the store's data when the Store
+	// is deleted: Delete removes it, Retain keeps the volumes for adoption by a
+	// later store, Snapshot captures it before teardown
+	// +kubebuilder:validation:Enum=Delete;Retain;Snapshot
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// SnapshotTarget receives the final backup taken by the Snapshot policy on
+	// clusters without VolumeSnapshot support
+	// +optional
+	SnapshotTarget *S3BackupTarget `json:"snapshotTarget,omitempty"`
+
+	// Network allow-lists outbound traffic from the store's pods
+	// +optional
+	Network *NetworkSpec `json:"network,omitempty"`
+
+	// Routing overrides how the store is exposed: its ingress class and
+	// annotations, or a Gateway API HTTPRoute instead of an Ingress
+	// +optional
+	Routing *RoutingSpec `json:"routing,omitempty"`
+}
+
+// SiteSpec holds a store's initial settings
+type SiteSpec struct {
+	// Title is the site title; defaults to the store name
+	// +kubebuilder:validation:MaxLength=100
+	// +optional
+	Title string `json:"title,omitempty"`
+
+	// AdminEmail is the admin user's email address
+	// +kubebuilder:validation:MaxLength=254
+	// +kubebuilder:validation:Pattern=`^[^@\s]+@[^@\s]+\.[^@\s]+$`
+	// +optional
+	AdminEmail string `json:"adminEmail,omitempty"`
+
+	// AdminUsername is the admin user's login; defaults to the chart's
+	// +kubebuilder:validation:MaxLength=60
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9._-]+$`
+	// +optional
+	AdminUsername string `json:"adminUsername,omitempty"`
+
+	// Locale is the site language, e.g. de_DE
+	// +kubebuilder:validation:Pattern=`^[a-z]{2,3}(_[A-Z]{2})?$`
+	// +optional
+	Locale string `json:"locale,omitempty"`
+
+	// Currency is the ISO 4217 code prices are shown in, e.g. EUR
+	// +kubebuilder:validation:Pattern=`^[A-Z]{3}$`
+	// +optional
+	Currency string `json:"currency,omitempty"`
+
+	// Address is the store's business address, used for taxes and shipping
+	// +optional
+	Address *StoreAddress `json:"address,omitempty"`
+}
+
+// StoreAddress is a postal address
+type StoreAddress struct {
+	// +kubebuilder:validation:MaxLength=200
+	Line1 string `json:"line1"`
+
+	// +kubebuilder:validation:MaxLength=200
+	// +optional
+	Line2 string `json:"line2,omitempty"`
+
+	// +kubebuilder:validation:MaxLength=100
+	City string `json:"city"`
+
+	// +kubebuilder:validation:MaxLength=20
+	// +optional
+	Postcode string `json:"postcode,omitempty"`
+
+	// Country is an ISO 3166-1 alpha-2 code, e.g. DE
+	// +kubebuilder:validation:Pattern=`^[A-Z]{2}$`
+	Country string `json:"country"`
+
+	// State is the subdivision code within Country, e.g. CA for California
+	// +kubebuilder:validation:MaxLength=10
+	// +optional
+	State string `json:"state,omitempty"`
+}
+
+// RoutingSpec overrides the operator's routing defaults for one store
+type RoutingSpec struct {
+	// Mode exposes the store through an Ingress or a Gateway API HTTPRoute;
+	// empty uses the operator's ROUTING_MODE
+	// +kubebuilder:validation:Enum=Ingress;Gateway
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// IngressClassName replaces the operator's INGRESS_CLASS
+	// +optional
+	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// Annotations are added to the Ingress or HTTPRoute on top of the
+	// operator's INGRESS_ANNOTATIONS, e.g. nginx.ingress.kubernetes.io/proxy-body-size
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Gateway replaces the operator's GATEWAY_NAME and GATEWAY_NAMESPACE
+	// +optional
+	Gateway *GatewayRef `json:"gateway,omitempty"`
+}
+
+// GatewayRef names the Gateway an HTTPRoute attaches to
+type GatewayRef struct {
+	Name string `json:"name"`
+
+	// Namespace defaults to the operator's GATEWAY_NAMESPACE
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName attaches to a single listener of the Gateway
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// NetworkSpec configures the store namespace's NetworkPolicy
+type NetworkSpec struct {
+	// Egress allow-lists outbound destinations beyond DNS and the store's own
+	// namespace, e.g. payment gateways or an SMTP relay
+	// +optional
+	Egress []EgressRule `json:"egress,omitempty"`
+}
+
+// EgressRule allows traffic to a CIDR, to a set of ports, or to both
+// +kubebuilder:validation:XValidation:rule="has(self.cidr) || has(self.ports)",message="an egress rule needs a cidr, ports or both"
+type EgressRule struct {
+	// CIDR is the destination block, e.g. 203.0.113.0/24; without it the
+	// ports are open to any destination
+	// +optional
+	CIDR string `json:"cidr,omitempty"`
+
+	// Except lists blocks inside CIDR that stay denied
+	// +optional
+	Except []string `json:"except,omitempty"`
+
+	// Ports limits the rule to these ports; without them every port is open
+	// +optional
+	Ports []EgressPort `json:"ports,omitempty"`
+}
+
+// EgressPort is a destination port and protocol
+type EgressPort struct {
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	// +kubebuilder:validation:Enum=TCP;UDP;SCTP
+	// +kubebuilder:default=TCP
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// ChartSource locates a Helm chart
+type ChartSource struct {
+	// URL is an oci:// reference, a chart repository URL or a path on the
+	// operator's filesystem. When empty the engine's default source is used.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Name is the chart's name in a chart repository
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Version pins the chart version; required for OCI and repository charts
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// CredentialsSpec configures credential rotation
+type CredentialsSpec struct {
+	// RotateAfter rotates the store's passwords once they are this old, e.g. 720h
+	// +optional
+	RotateAfter *metav1.Duration `json:"rotateAfter,omitempty"`
+}
+
+// RestoreSource names the backup a store is restored from
+type RestoreSource struct {
+	// BackupName is a Completed StoreBackup in the store's namespace
+	BackupName string `json:"backupName"`
+}
+
+// RestoreStatus tracks the restore of a store from a backup
+type RestoreStatus struct {
+	// BackupName is the StoreBackup being restored
+	BackupName string `json:"backupName"`
+
+	// Phase is the restore phase (Running, Completed, Failed)
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// JobName is the restore Job in the store namespace
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// StartTime is when the restore Job was created
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the restore finished
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// CloneSource names the store a store is copied from
+type CloneSource struct {
+	// StoreName is a Ready store of the same engine in the store's namespace
+	StoreName string `json:"storeName"`
+}
+
+// CloneStatus tracks the copy of another store's data
+type CloneStatus struct {
+	// StoreName is the store being copied
+	StoreName string `json:"storeName"`
+
+	// Phase is the clone phase (Running, Completed, Failed)
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// JobName is the clone Job in the store namespace
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// StartTime is when the clone Job was created
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the clone finished
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// StoreUsage is a periodic sample of what a store's namespace consumes
+type StoreUsage struct {
+	// Used is the namespace ResourceQuota's status.used
+	// +optional
+	Used corev1.ResourceList `json:"used,omitempty"`
+
+	// Hard is the quota the store's plan grants
+	// +optional
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+
+	// Storage is the total requested size of the store's PVCs
+	// +optional
+	Storage *resource.Quantity `json:"storage,omitempty"`
+
+	// Volumes lists the requested size of each PVC
+	// +optional
+	Volumes []VolumeUsage `json:"volumes,omitempty"`
+
+	// SampledAt is when the sample was taken
+	SampledAt metav1.Time `json:"sampledAt"`
+}
+
+// VolumeUsage is the requested size of one PVC
+type VolumeUsage struct {
+	Name string            `json:"name"`
+	Size resource.Quantity `json:"size"`
 }
 
 // StoreStatus defines the observed state of Store
 type StoreStatus struct {
-	// Phase is the current lifecycle phase (Provisioning, Ready, Failed)
+	// Phase is the current lifecycle phase (Provisioning, Ready, Degraded, Suspended, Failed)
 	Phase string `json:"phase,omitempty"`
 
 	// ObservedGeneration is the last generation of the Store that was successfully reconciled
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
+	// ObservedPlanGeneration is the generation of the StorePlan last applied to the store
+	// +optional
+	ObservedPlanGeneration int64 `json:"observedPlanGeneration,omitempty"`
+
+	// AppliedPlan is the StorePlan last applied to the store. It trails
+	// spec.plan while a move to a smaller plan is blocked by current usage.
+	// +optional
+	AppliedPlan string `json:"appliedPlan,omitempty"`
+
 	// URL is the external endpoint for the store
 	URL string `json:"url,omitempty"`
 
@@ -55,8 +364,46 @@ type StoreStatus struct {
 	// +optional
 	Reason string `json:"reason,omitempty"`
 
-	// Conditions store the detailed state history
+	// CredentialsRotatedAt is when the store's passwords were last rotated
+	// +optional
+	CredentialsRotatedAt *metav1.Time `json:"credentialsRotatedAt,omitempty"`
+
+	// LastAppliedRevision is the Helm revision the operator last installed or upgraded to
+	// +optional
+	LastAppliedRevision int `json:"lastAppliedRevision,omitempty"`
+
+	// LastSuccessfulRevision is the last Helm revision the store was Ready on;
+	// failed upgrades are rolled back to it
+	// +optional
+	LastSuccessfulRevision int `json:"lastSuccessfulRevision,omitempty"`
+
+	// FailureCount is the number of consecutive failed Helm attempts; it resets
+	// when an attempt succeeds or the retry-now annotation is set
+	// +optional
+	FailureCount int `json:"failureCount,omitempty"`
+
+	// LastFailureTime is when the most recent Helm attempt failed
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+
+	// Usage samples the store namespace's quota usage and volume sizes
+	// +optional
+	Usage *StoreUsage `json:"usage,omitempty"`
+
+	// Restore reports progress of spec.restoreFrom
+	// +optional
+	Restore *RestoreStatus `json:"restore,omitempty"`
+
+	// Clone reports progress of spec.cloneFrom
+	// +optional
+	Clone *CloneStatus `json:"clone,omitempty"`
+
+	// Conditions report NamespaceReady, CredentialsReady, GuardrailsApplied,
+	// ReleaseInstalled, WorkloadReady, Drifted, VolumesResized, PlanChangeBlocked
+	// and the overall Ready
 	// +optional
+	// +listType=map
+	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
@@ -0,0 +1,151 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PVCBackupTarget writes the backup archive to a PersistentVolumeClaim in the
+// store namespace. Its volume is retained when the store is deleted.
+type PVCBackupTarget struct {
+	// ClaimName is the PVC to write to
+	ClaimName string `json:"claimName"`
+
+	// Path is the directory inside the PVC
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// S3BackupTarget uploads the backup archive to an S3-compatible endpoint
+type S3BackupTarget struct {
+	// Endpoint is the S3 API URL, e.g. https://minio.example.com
+	Endpoint string `json:"endpoint"`
+
+	// Bucket receives the archive
+	Bucket string `json:"bucket"`
+
+	// Prefix is prepended to the object key
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Region is passed to the S3 client
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecret names a Secret in the StoreBackup's namespace holding
+	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+	CredentialsSecret string `json:"credentialsSecret"`
+}
+
+// BackupTarget selects where a backup is written. Exactly one must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.pvc) != has(self.s3)",message="exactly one of pvc or s3 must be set"
+type BackupTarget struct {
+	// +optional
+	PVC *PVCBackupTarget `json:"pvc,omitempty"`
+
+	// +optional
+	S3 *S3BackupTarget `json:"s3,omitempty"`
+}
+
+// StoreBackupSpec defines the desired state of StoreBackup
+type StoreBackupSpec struct {
+	// StoreName is the Store to back up, in the same namespace
+	StoreName string `json:"storeName"`
+
+	// Target is where the archive is written
+	Target BackupTarget `json:"target"`
+}
+
+// StoreBackupStatus defines the observed state of StoreBackup
+type StoreBackupStatus struct {
+	// Phase is the current lifecycle phase (Pending, Running, Completed, Failed)
+	Phase string `json:"phase,omitempty"`
+
+	// Progress is the step the backup Job is currently running
+	// +optional
+	Progress string `json:"progress,omitempty"`
+
+	// Message is a human-readable description of the current state
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Reason is a machine-readable reason code for the current phase
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// JobName is the backup Job in the store namespace
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// Location is where the archive was written (pvc://claim/path or s3://bucket/key)
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// SizeBytes is the size of the archive
+	// +optional
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+
+	// Checksum is the archive digest, e.g. sha256:abc...
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// StartTime is when the backup Job was created
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the backup finished
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Store",type=string,JSONPath=`.spec.storeName`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.status.sizeBytes`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// StoreBackup is the Schema for the storebackups API
+type StoreBackup struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the desired state of StoreBackup
+	// +required
+	Spec StoreBackupSpec `json:"spec"`
+
+	// status defines the observed state of StoreBackup
+	// +optional
+	Status StoreBackupStatus `json:"status,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// StoreBackupList contains a list of StoreBackup
+type StoreBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []StoreBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&StoreBackup{}, &StoreBackupList{})
+}
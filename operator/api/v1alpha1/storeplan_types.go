@@ -0,0 +1,175 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// PlanQuota defines the ResourceQuota applied to every store namespace on the plan
+type PlanQuota struct {
+	// RequestsCPU is the total CPU requests allowed in the namespace
+	RequestsCPU resource.Quantity `json:"requestsCPU"`
+
+	// RequestsMemory is the total memory requests allowed in the namespace
+	RequestsMemory resource.Quantity `json:"requestsMemory"`
+
+	// LimitsCPU is the total CPU limits allowed in the namespace
+	LimitsCPU resource.Quantity `json:"limitsCPU"`
+
+	// LimitsMemory is the total memory limits allowed in the namespace
+	LimitsMemory resource.Quantity `json:"limitsMemory"`
+
+	// MaxPods is the maximum number of pods in the namespace
+	// +kubebuilder:validation:Minimum=1
+	MaxPods int64 `json:"maxPods"`
+}
+
+// PlanLimitRange defines container defaults applied to every store namespace on the plan
+type PlanLimitRange struct {
+	// DefaultCPU is the default container CPU limit
+	DefaultCPU resource.Quantity `json:"defaultCPU"`
+
+	// DefaultMemory is the default container memory limit
+	DefaultMemory resource.Quantity `json:"defaultMemory"`
+
+	// DefaultRequestCPU is the default container CPU request
+	DefaultRequestCPU resource.Quantity `json:"defaultRequestCPU"`
+
+	// DefaultRequestMemory is the default container memory request
+	DefaultRequestMemory resource.Quantity `json:"defaultRequestMemory"`
+}
+
+// PlanPersistence configures one of the store's volumes
+type PlanPersistence struct {
+	// Enabled turns on persistent storage; unset falls back to the operator default
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Size is the requested volume size
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// StorageClassName selects the StorageClass; empty uses the cluster default
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+
+	// AccessModes of the volume; empty uses ReadWriteOnce. Every application
+	// replica mounts the content volume, so plans running more than one
+	// replica need ReadWriteMany and a StorageClass that supports it.
+	// +optional
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+}
+
+// PlanAutoscaling bounds the HorizontalPodAutoscaler of the application
+// +kubebuilder:validation:XValidation:rule="self.maxReplicas >= self.minReplicas",message="maxReplicas must be at least minReplicas"
+type PlanAutoscaling struct {
+	// MinReplicas is the fewest replicas the autoscaler scales down to
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas int32 `json:"minReplicas"`
+
+	// MaxReplicas is the most replicas the autoscaler scales up to
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// TargetCPU is the average CPU utilisation, in percent of requests, to scale on
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetCPU *int32 `json:"targetCPU,omitempty"`
+
+	// TargetMemory is the average memory utilisation, in percent of requests, to scale on
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetMemory *int32 `json:"targetMemory,omitempty"`
+}
+
+// PlanDisruptionBudget configures the PodDisruptionBudget of the application
+type PlanDisruptionBudget struct {
+	// MinAvailable is the number or percentage of replicas kept up during voluntary disruptions
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// MaxUnavailable is the number or percentage of replicas that may be down at once
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// StorePlanSpec defines the resources granted to stores on this plan
+type StorePlanSpec struct {
+	// Quota is the namespace-wide ResourceQuota
+	Quota PlanQuota `json:"quota"`
+
+	// LimitRange holds per-container defaults
+	LimitRange PlanLimitRange `json:"limitRange"`
+
+	// Replicas is the number of application replicas; unset uses the chart default
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Autoscaling scales the application between these bounds instead of
+	// running a fixed number of replicas
+	// +optional
+	Autoscaling *PlanAutoscaling `json:"autoscaling,omitempty"`
+
+	// DisruptionBudget configures the application's PodDisruptionBudget;
+	// unset keeps the chart default
+	// +optional
+	DisruptionBudget *PlanDisruptionBudget `json:"disruptionBudget,omitempty"`
+
+	// Persistence configures the application volume (WordPress content);
+	// growing Size expands existing stores' volumes in place
+	// +optional
+	Persistence PlanPersistence `json:"persistence,omitempty"`
+
+	// DatabasePersistence configures the database volume (MariaDB or
+	// PostgreSQL); growing Size expands existing stores' volumes in place
+	// +optional
+	DatabasePersistence PlanPersistence `json:"databasePersistence,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// StorePlan is the Schema for the storeplans API
+type StorePlan struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the resources granted to stores on this plan
+	// +required
+	Spec StorePlanSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// StorePlanList contains a list of StorePlan
+type StorePlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []StorePlan `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&StorePlan{}, &StorePlanList{})
+}
@@ -0,0 +1,84 @@
+{{/* Mirrors medusaFullname in the operator's medusa engine */}}
+{{- define "medusa.fullname" -}}
+{{- if contains "medusa" .Release.Name -}}
+{{- .Release.Name | trunc 63 | trimSuffix "-" -}}
+{{- else -}}
+{{- printf "%s-medusa" .Release.Name | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+{{- end -}}
+
+{{- define "medusa.postgresql.fullname" -}}
+{{- printf "%s-postgresql" .Release.Name | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+
+{{- define "medusa.redis.fullname" -}}
+{{- printf "%s-redis" .Release.Name | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+
+{{/* Labels for a component; the operator's readiness check selects name=medusa */}}
+{{- define "medusa.labels" -}}
+app.kubernetes.io/name: {{ .name }}
+app.kubernetes.io/instance: {{ .root.Release.Name }}
+app.kubernetes.io/managed-by: {{ .root.Release.Service }}
+helm.sh/chart: {{ printf "%s-%s" .root.Chart.Name .root.Chart.Version }}
+{{- with .root.Values.commonLabels }}
+{{ toYaml . }}
+{{- end }}
+{{- end -}}
+
+{{- define "medusa.selectorLabels" -}}
+app.kubernetes.io/name: {{ .name }}
+app.kubernetes.io/instance: {{ .root.Release.Name }}
+{{- end -}}
+
+{{/* Pod template labels: the selector plus commonLabels, which the operator's caches select on */}}
+{{- define "medusa.podLabels" -}}
+{{ include "medusa.selectorLabels" . }}
+{{- with .root.Values.commonLabels }}
+{{ toYaml . }}
+{{- end }}
+{{- end -}}
+
+{{- define "medusa.image" -}}
+{{- required "image.repository is required: build a Medusa server image and set MEDUSA_IMAGE" .Values.image.repository -}}
+{{- with .Values.image.tag }}:{{ . }}{{ end -}}
+{{- end -}}
+
+{{- define "medusa.env" -}}
+- name: PORT
+  value: {{ .Values.service.port | quote }}
+- name: STORE_NAME
+  value: {{ .Values.medusa.storeName | quote }}
+- name: MEDUSA_ADMIN_EMAIL
+  value: {{ .Values.medusa.adminEmail | quote }}
+- name: MEDUSA_ADMIN_PASSWORD
+  valueFrom:
+    secretKeyRef:
+      name: {{ include "medusa.fullname" . }}
+      key: admin-password
+- name: JWT_SECRET
+  valueFrom:
+    secretKeyRef:
+      name: {{ include "medusa.fullname" . }}
+      key: jwt-secret
+- name: COOKIE_SECRET
+  valueFrom:
+    secretKeyRef:
+      name: {{ include "medusa.fullname" . }}
+      key: cookie-secret
+- name: DATABASE_PASSWORD
+  valueFrom:
+    secretKeyRef:
+      name: {{ include "medusa.postgresql.fullname" . }}
+      key: password
+- name: DATABASE_URL
+  value: {{ printf "postgres://%s:$(DATABASE_PASSWORD)@%s:5432/%s" .Values.postgresql.auth.username (include "medusa.postgresql.fullname" .) .Values.postgresql.auth.database | quote }}
+- name: REDIS_URL
+  value: {{ printf "redis://%s:6379" (include "medusa.redis.fullname" .) | quote }}
+- name: STORE_CORS
+  value: {{ .Values.medusa.storeCors | quote }}
+- name: ADMIN_CORS
+  value: {{ .Values.medusa.adminCors | quote }}
+- name: AUTH_CORS
+  value: {{ .Values.medusa.adminCors | quote }}
+{{- end -}}
@@ -29,6 +29,7 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
@@ -39,6 +40,7 @@ import (
 	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
 	"github.com/Jovial-Kanwadia/store-operator/internal/config"
 	"github.com/Jovial-Kanwadia/store-operator/internal/controller"
+	"github.com/Jovial-Kanwadia/store-operator/internal/helm"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -156,11 +158,17 @@ func main() {
 		metricsServerOptions.KeyName = metricsCertKey
 	}
 
+	workloadCache, err := controller.WorkloadCache()
+	if err != nil {
+		setupLog.Error(err, "unable to build the workload cache selector")
+		os.Exit(1)
+	}
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
+		Cache:                  cache.Options{ByObject: workloadCache},
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "3e625f29.store.io",
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
@@ -184,20 +192,50 @@ func main() {
 	operatorConfig := config.Load()
 	setupLog.Info("Loaded operator configuration",
 		"chartPath", operatorConfig.WordPressChartPath,
+		"medusaChartPath", operatorConfig.MedusaChartPath,
+		"chartCacheDir", operatorConfig.ChartCacheDir,
 		"baseDomain", operatorConfig.BaseDomain,
 		"persistenceEnabled", operatorConfig.PersistenceEnabled)
 
-	if err := (&controller.StoreReconciler{
+	charts := helm.NewChartCache(operatorConfig.ChartCacheDir)
+	charts.PlainHTTP = operatorConfig.ChartRegistryPlainHTTP
+
+	storeReconciler := &controller.StoreReconciler{
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),
 		Recorder: mgr.GetEventRecorderFor("store-controller"),
 		Config:   operatorConfig,
-	}).SetupWithManager(mgr); err != nil {
+		Releases: helm.NewSDKReleaseManager(mgr.GetConfig(), charts),
+	}
+	// Service DNS names only resolve when the operator runs in the cluster
+	if operatorConfig.HealthProbeEnabled {
+		storeReconciler.HealthProbe = controller.HTTPProbe(operatorConfig.HealthProbeTimeout)
+	}
+	if err := storeReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Store")
 		os.Exit(1)
 	}
+	if err := (&controller.StoreBackupReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("storebackup-controller"),
+		Config:   operatorConfig,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "StoreBackup")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
+	// Advertise supported engines to the backend
+	if err := mgr.Add(&controller.CapabilitiesPublisher{
+		Client:    mgr.GetClient(),
+		Namespace: operatorConfig.OperatorNamespace,
+		Config:    operatorConfig,
+	}); err != nil {
+		setupLog.Error(err, "unable to add capabilities publisher")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -1,22 +1,95 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // OperatorConfig holds all operator configuration
 type OperatorConfig struct {
-	// Chart configuration
-	WordPressChartPath string
-	BaseDomain         string
+	// Chart configuration: each *ChartPath is a local path, an oci://
+	// reference or a chart repository URL (with *ChartName set)
+	WordPressChartPath    string
+	WordPressChartName    string
+	WordPressChartVersion string
+	MedusaChartPath       string
+	MedusaChartName       string
+	MedusaChartVersion    string
+	BaseDomain            string
+
+	// MedusaImage is the Medusa server image; there is no official one, so
+	// the medusa engine stays unavailable until it is set
+	MedusaImage string
+
+	// Remote charts are pulled into ChartCacheDir
+	ChartCacheDir          string
+	ChartRegistryPlainHTTP bool
+
+	// OperatorNamespace is where operator-owned objects (capabilities ConfigMap) live
+	OperatorNamespace string
 
 	// Timing configuration (for reconciliation intervals)
 	NamespaceRequeueInterval  time.Duration
 	HelmFailureRetryInterval  time.Duration
 	PodReadinessCheckInterval time.Duration
 	DeletionRequeueInterval   time.Duration
+	DriftCheckInterval        time.Duration
+	HelmTimeout               time.Duration
+
+	// Failed Helm attempts back off exponentially from HelmFailureRetryInterval
+	// up to HelmRetryMaxInterval; after MaxFailedAttempts (0 = unlimited) the
+	// store stays Failed until retried by annotation
+	HelmRetryMaxInterval time.Duration
+	MaxFailedAttempts    int
+
+	// PlanChangeRecheckInterval is how often a store whose move to another
+	// plan is blocked by its usage checks again
+	PlanChangeRecheckInterval time.Duration
+
+	// Ready and Degraded stores are health-checked every HealthCheckInterval:
+	// their workloads are inspected and, with HealthProbeEnabled, their
+	// Service is probed over HTTP
+	HealthCheckInterval time.Duration
+	HealthProbeEnabled  bool
+	HealthProbeTimeout  time.Duration
+
+	// UsageSampleInterval is how often status.usage is refreshed
+	UsageSampleInterval time.Duration
+
+	// Backup configuration
+	MariaDBClientImage    string
+	BackupUploaderImage   string
+	BackupPollInterval    time.Duration
+	BackupJobBackoffLimit int
+
+	// CloneKubectlImage streams a source store's data into a clone
+	CloneKubectlImage string
+
+	// VolumeSnapshotClass is used by the Snapshot deletion policy; empty uses the cluster default
+	VolumeSnapshotClass string
+
+	// Routing configuration: stores are exposed through an Ingress of
+	// IngressClassName or, in Gateway mode, an HTTPRoute on the named Gateway
+	RoutingMode        string
+	IngressClassName   string
+	IngressAnnotations map[string]string
+	GatewayName        string
+	GatewayNamespace   string
+	GatewaySectionName string
+
+	// NetworkPolicy configuration: ingress is admitted from the ingress
+	// controller's pods, and in Gateway mode from the Gateway's proxy pods
+	// (in the Gateway's own namespace unless GatewayProxyNamespace is set);
+	// DNS egress goes to the cluster DNS pods
+	IngressNamespace      string
+	IngressPodLabels      map[string]string
+	GatewayProxyNamespace string
+	GatewayProxyPodLabels map[string]string
+	DNSNamespace          string
+	DNSPodLabels          map[string]string
 
 	// Helm values configuration
 	PersistenceEnabled         bool
@@ -30,19 +103,74 @@ type OperatorConfig struct {
 func Load() *OperatorConfig {
 	return &OperatorConfig{
 		// Chart path: default to embedded charts in container
-		WordPressChartPath: getEnv("WORDPRESS_CHART_PATH", "/charts/engine-woo"),
+		WordPressChartPath:    getEnv("WORDPRESS_CHART_PATH", "/charts/engine-woo"),
+		WordPressChartName:    getEnv("WORDPRESS_CHART_NAME", "engine-woo"),
+		WordPressChartVersion: getEnv("WORDPRESS_CHART_VERSION", ""),
+		MedusaChartPath:       getEnv("MEDUSA_CHART_PATH", "/charts/engine-medusa"),
+		MedusaChartName:       getEnv("MEDUSA_CHART_NAME", "engine-medusa"),
+		MedusaChartVersion:    getEnv("MEDUSA_CHART_VERSION", ""),
+		MedusaImage:           getEnv("MEDUSA_IMAGE", ""),
+
+		// Chart cache for OCI and repository charts
+		ChartCacheDir:          getEnv("CHART_CACHE_DIR", "/tmp/store-operator/charts"),
+		ChartRegistryPlainHTTP: parseBool(getEnv("CHART_REGISTRY_PLAIN_HTTP", "false")),
 
 		// Base domain for store URLs
 		BaseDomain: getEnv("BASE_DOMAIN", "127.0.0.1.nip.io"),
 
+		// Namespace the operator runs in (downward API)
+		OperatorNamespace: getEnv("POD_NAMESPACE", "default"),
+
 		// Reconciliation timing
 		NamespaceRequeueInterval:  parseDuration(getEnv("NAMESPACE_REQUEUE_INTERVAL", "1s")),
 		HelmFailureRetryInterval:  parseDuration(getEnv("HELM_RETRY_INTERVAL", "20s")),
-		PodReadinessCheckInterval: parseDuration(getEnv("POD_CHECK_INTERVAL", "5s")),
+		PodReadinessCheckInterval: parseDuration(getEnv("POD_CHECK_INTERVAL", "2m")),
 		DeletionRequeueInterval:   parseDuration(getEnv("DELETION_REQUEUE_INTERVAL", "5s")),
+		DriftCheckInterval:        parseDuration(getEnv("DRIFT_CHECK_INTERVAL", "10m")),
+		HelmTimeout:               parseDuration(getEnv("HELM_TIMEOUT", "5m")),
+
+		// Retry budget for failed stores
+		HelmRetryMaxInterval: parseDuration(getEnv("HELM_RETRY_MAX_INTERVAL", "10m")),
+		MaxFailedAttempts:    parseInt(getEnv("MAX_FAILED_ATTEMPTS", "10")),
+
+		PlanChangeRecheckInterval: parseDuration(getEnv("PLAN_CHANGE_RECHECK_INTERVAL", "1m")),
+
+		HealthCheckInterval: parseDuration(getEnv("HEALTH_CHECK_INTERVAL", "1m")),
+		HealthProbeEnabled:  parseBool(getEnv("HEALTH_PROBE_ENABLED", "true")),
+		HealthProbeTimeout:  parseDuration(getEnv("HEALTH_PROBE_TIMEOUT", "5s")),
+
+		UsageSampleInterval: parseDuration(getEnv("USAGE_SAMPLE_INTERVAL", "5m")),
+
+		// Backup Jobs
+		MariaDBClientImage:    getEnv("MARIADB_CLIENT_IMAGE", "docker.io/bitnami/mariadb:12.1.2"),
+		BackupUploaderImage:   getEnv("BACKUP_UPLOADER_IMAGE", "docker.io/amazon/aws-cli:2.17.0"),
+		BackupPollInterval:    parseDuration(getEnv("BACKUP_POLL_INTERVAL", "10s")),
+		BackupJobBackoffLimit: parseInt(getEnv("BACKUP_JOB_BACKOFF_LIMIT", "1")),
+
+		// Clone Jobs
+		CloneKubectlImage: getEnv("CLONE_KUBECTL_IMAGE", "docker.io/bitnami/kubectl:1.30"),
+
+		// Snapshots taken before a store is deleted
+		VolumeSnapshotClass: getEnv("VOLUME_SNAPSHOT_CLASS", ""),
+
+		// How stores are exposed
+		RoutingMode:        getEnv("ROUTING_MODE", "Ingress"),
+		IngressClassName:   getEnv("INGRESS_CLASS", "nginx"),
+		IngressAnnotations: parseAnnotations(getEnv("INGRESS_ANNOTATIONS", "")),
+		GatewayName:        getEnv("GATEWAY_NAME", "gateway"),
+		GatewayNamespace:   getEnv("GATEWAY_NAMESPACE", "gateway-system"),
+		GatewaySectionName: getEnv("GATEWAY_SECTION_NAME", ""),
+
+		// NetworkPolicy peers
+		IngressNamespace:      getEnv("INGRESS_NAMESPACE", "ingress-nginx"),
+		IngressPodLabels:      parseLabels(getEnv("INGRESS_POD_LABELS", "")),
+		GatewayProxyNamespace: getEnv("GATEWAY_PROXY_NAMESPACE", ""),
+		GatewayProxyPodLabels: parseLabels(getEnv("GATEWAY_PROXY_POD_LABELS", "")),
+		DNSNamespace:          getEnv("DNS_NAMESPACE", "kube-system"),
+		DNSPodLabels:          parseLabels(getEnv("DNS_POD_LABELS", "k8s-app=kube-dns")),
 
 		// Helm values defaults
-		PersistenceEnabled:         parseBool(getEnv("PERSISTENCE_ENABLED", "false")),
+		PersistenceEnabled:         parseBool(getEnv("PERSISTENCE_ENABLED", "true")),
 		LivenessProbeInitialDelay:  parseInt(getEnv("LIVENESS_INITIAL_DELAY", "120")),
 		LivenessProbePeriod:        parseInt(getEnv("LIVENESS_PERIOD", "20")),
 		ReadinessProbeInitialDelay: parseInt(getEnv("READINESS_INITIAL_DELAY", "60")),
@@ -76,6 +204,33 @@ func parseBool(s string) bool {
 	return val
 }
 
+// parseLabels parses comma-separated key=value pairs, skipping malformed entries
+func parseLabels(s string) map[string]string {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			continue
+		}
+		labels[key] = value
+	}
+	return labels
+}
+
+// parseAnnotations parses a JSON object of annotations, returning an empty map
+// on error. Annotation values often hold commas, so they can't be given as
+// key=value pairs.
+func parseAnnotations(s string) map[string]string {
+	annotations := map[string]string{}
+	if s == "" {
+		return annotations
+	}
+	if err := json.Unmarshal([]byte(s), &annotations); err != nil {
+		return map[string]string{}
+	}
+	return annotations
+}
+
 // parseDuration parses a time.Duration from a string, returning 0 on error
 func parseDuration(s string) time.Duration {
 	val, err := time.ParseDuration(s)
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Jovial-Kanwadia/store-operator/internal/config"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+)
+
+// CapabilitiesPublisher writes what this operator build supports into a
+// well-known ConfigMap so the backend can validate requests against it.
+type CapabilitiesPublisher struct {
+	Client    client.Client
+	Namespace string
+	// Config leaves out engines that aren't configured
+	Config *config.OperatorConfig
+}
+
+// Start implements manager.Runnable. It publishes once and returns.
+func (p *CapabilitiesPublisher) Start(ctx context.Context) error {
+	logger := ctrl.LoggerFrom(ctx).WithName("capabilities")
+
+	data := map[string]string{
+		CapabilitiesKeyEngines: strings.Join(engine.Available(p.Config), ","),
+	}
+
+	var existing corev1.ConfigMap
+	err := p.Client.Get(ctx, clientObjectKey(p.Namespace, CapabilitiesConfigMapName), &existing)
+	if err == nil {
+		existing.Data = data
+		if err := p.Client.Update(ctx, &existing); err != nil {
+			logger.Error(err, "updating capabilities ConfigMap")
+			return err
+		}
+		logger.Info("Published operator capabilities", "engines", data[CapabilitiesKeyEngines])
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CapabilitiesConfigMapName,
+			Namespace: p.Namespace,
+		},
+		Data: data,
+	}
+	if err := p.Client.Create(ctx, cm); err != nil {
+		logger.Error(err, "creating capabilities ConfigMap")
+		return err
+	}
+	logger.Info("Published operator capabilities", "engines", data[CapabilitiesKeyEngines])
+	return nil
+}
@@ -1,13 +1,19 @@
 package controller
 
-// Finalizer name
-const storeFinalizer = "infra.store.io/finalizer"
+// Finalizer names
+const (
+	storeFinalizer  = "infra.store.io/finalizer"
+	backupFinalizer = "infra.store.io/backup-finalizer"
+)
 
 // Store status phases
 const (
 	PhaseProvisioning = "Provisioning"
 	PhaseReady        = "Ready"
+	PhaseSuspended    = "Suspended"
 	PhaseFailed       = "Failed"
+	// PhaseDegraded is a store that was Ready but now fails its health checks
+	PhaseDegraded = "Degraded"
 )
 
 // Store status reasons
@@ -15,6 +21,80 @@ const (
 	ReasonProvisioning   = "Provisioning"
 	ReasonHelmError      = "HelmError"
 	ReasonWaitingForPods = "WaitingForPods"
+	ReasonUnknownEngine  = "UnknownEngine"
+	ReasonPlanNotFound   = "PlanNotFound"
+	// ReasonReplicasExceedPlan is set when spec.replicas or the plan's
+	// autoscaling ceiling doesn't fit the plan's maxPods
+	ReasonReplicasExceedPlan = "ReplicasExceedPlan"
+	// ReasonReadWriteOnceVolume is set when more than one application replica
+	// would share a content volume that isn't ReadWriteMany
+	ReasonReadWriteOnceVolume = "ReadWriteOnceVolume"
+	ReasonWaitingBackup       = "WaitingForBackup"
+	ReasonRestoring           = "Restoring"
+	ReasonRestoreFailed       = "RestoreFailed"
+	ReasonWaitingSource       = "WaitingForSource"
+	ReasonCloning             = "Cloning"
+	ReasonCloneFailed         = "CloneFailed"
+	ReasonSuspended           = "Suspended"
+	ReasonResuming            = "Resuming"
+	ReasonRotating            = "RotatingCredentials"
+	ReasonRotationFailed      = "CredentialRotationFailed"
+	ReasonRolledBack          = "UpgradeRolledBack"
+	// ReasonRetriesExhausted is terminal: the store is not retried until the
+	// retry-now annotation is set
+	ReasonRetriesExhausted = "RetriesExhausted"
+	ReasonSnapshotting     = "Snapshotting"
+	ReasonSnapshotFailed   = "SnapshotFailed"
+	// Degraded reasons
+	ReasonCrashLooping        = "CrashLooping"
+	ReasonWorkloadUnavailable = "WorkloadUnavailable"
+	ReasonProbeFailed         = "ProbeFailed"
+)
+
+// Store deletion policies
+const (
+	DeletionPolicyDelete   = "Delete"
+	DeletionPolicyRetain   = "Retain"
+	DeletionPolicySnapshot = "Snapshot"
+)
+
+// Store condition types
+const (
+	ConditionNamespaceReady    = "NamespaceReady"
+	ConditionCredentialsReady  = "CredentialsReady"
+	ConditionGuardrailsApplied = "GuardrailsApplied"
+	ConditionReleaseInstalled  = "ReleaseInstalled"
+	ConditionWorkloadReady     = "WorkloadReady"
+	// ConditionReady mirrors the phase; kubectl wait --for=condition=Ready uses it
+	ConditionReady = "Ready"
+	// ConditionDrifted is True while the deployed release differs from the desired state
+	ConditionDrifted = "Drifted"
+	// ConditionVolumesResized is False while a volume can't follow the plan's persistence settings
+	ConditionVolumesResized = "VolumesResized"
+	// ConditionPlanChangeBlocked is True while a store stays on its old plan
+	// because its usage doesn't fit the one in spec.plan
+	ConditionPlanChangeBlocked = "PlanChangeBlocked"
+	// ConditionScaleBlocked is True while an installed store keeps its
+	// release because its replicas would share a ReadWriteOnce content volume
+	ConditionScaleBlocked = "ScaleBlocked"
+)
+
+// Condition reasons; False conditions otherwise reuse the status reasons
+const (
+	ConditionReasonCreated          = "Created"
+	ConditionReasonGenerated        = "Generated"
+	ConditionReasonApplied          = "Applied"
+	ConditionReasonApplyFailed      = "ApplyFailed"
+	ConditionReasonInstalled        = "Installed"
+	ConditionReasonPodsReady        = "PodsReady"
+	ConditionReasonStoreReady       = "StoreReady"
+	ConditionReasonInSync           = "InSync"
+	ConditionReasonDriftDetected    = "DriftDetected"
+	ConditionReasonRepaired         = "Repaired"
+	ConditionReasonSized            = "Sized"
+	ConditionReasonExpansionBlocked = "ExpansionBlocked"
+	ConditionReasonPlanApplied      = "PlanApplied"
+	ConditionReasonUsageExceedsPlan = "UsageExceedsPlan"
 )
 
 // Kubernetes resource names
@@ -22,50 +102,129 @@ const (
 	ResourceQuotaName = "store-resource-quota"
 	LimitRangeName    = "store-limit-range"
 	NetworkPolicyName = "store-default-deny"
+	// DataJobNetworkPolicyName opens egress for the operator's backup and restore Jobs
+	DataJobNetworkPolicyName = "store-data-jobs"
+
+	// CapabilitiesConfigMapName advertises supported engines to the backend
+	CapabilitiesConfigMapName = "store-operator-capabilities"
 )
 
-// Namespace naming
+// Capabilities ConfigMap keys
 const (
-	StoreNamespacePrefix = "store-"
+	CapabilitiesKeyEngines = "engines"
 )
 
-// Secret keys
+// StoreBackup phases
 const (
-	SecretKeyMariaDBRoot = "mariadb-root-password"
-	SecretKeyMariaDBUser = "mariadb-user-password"
-	SecretKeyWordPress   = "wordpress-password"
+	BackupPhasePending   = "Pending"
+	BackupPhaseRunning   = "Running"
+	BackupPhaseCompleted = "Completed"
+	BackupPhaseFailed    = "Failed"
 )
 
-// WordPress Helm chart labels
+// StoreBackup reasons
 const (
-	WordPressAppLabel = "app.kubernetes.io/name"
-	WordPressAppValue = "wordpress"
+	ReasonStoreNotFound      = "StoreNotFound"
+	ReasonStoreNotReady      = "StoreNotReady"
+	ReasonBackupUnsupported  = "BackupUnsupported"
+	ReasonCredentialsMissing = "CredentialsMissing"
+	ReasonJobFailed          = "JobFailed"
 )
 
-// Password generation lengths
+// Backup Job steps, reported as StoreBackup progress
 const (
-	MariaDBRootPasswordLength = 20
-	MariaDBUserPasswordLength = 20
-	WordPressPasswordLength   = 16
+	BackupStepDumpDatabase   = "dump-database"
+	BackupStepArchiveContent = "archive-content"
+	BackupStepPackage        = "package"
+	BackupStepUpload         = "upload"
 )
 
-// Event reasons
+// Store restore phases
 const (
-	EventReasonDeleteFailed = "DeleteFailed"
-	EventReasonProvisioning = "Provisioning"
-	EventReasonFailed       = "Failed"
-	EventReasonReady        = "Ready"
+	RestorePhaseRunning   = "Running"
+	RestorePhaseCompleted = "Completed"
+	RestorePhaseFailed    = "Failed"
 )
 
-// Helm values keys (for documentation and consistency)
+// Restore Job steps
+const (
+	RestoreStepFetch           = "fetch"
+	RestoreStepUnpack          = "unpack"
+	RestoreStepRestoreDatabase = "restore-database"
+	RestoreStepRestoreContent  = "restore-content"
+)
+
+// Store clone phases
+const (
+	ClonePhaseRunning   = "Running"
+	ClonePhaseCompleted = "Completed"
+	ClonePhaseFailed    = "Failed"
+)
+
+// Clone Job steps; the database and content are loaded by the restore steps
+const (
+	CloneStepCopyDatabase = "copy-database"
+	CloneStepCopyContent  = "copy-content"
+	CloneStepRewriteURLs  = "rewrite-urls"
+)
+
+// Store annotations
+const (
+	// AnnotationRotateCredentials requests a one-off credential rotation; the
+	// operator removes it once the rotation completes
+	AnnotationRotateCredentials = "infra.store.io/rotate-credentials"
+	// AnnotationRetryNow resets the failure budget and retries immediately;
+	// the operator removes it once seen
+	AnnotationRetryNow = "infra.store.io/retry-now"
+)
+
+// Credential rotation Job step
+const (
+	RotationStepChangePasswords = "change-passwords"
+)
+
+// Labels on operator-created objects
+const (
+	LabelManagedBy       = "app.kubernetes.io/managed-by"
+	ManagedByValue       = "store-operator"
+	LabelBackupName      = "infra.store.io/backup-name"
+	LabelBackupNamespace = "infra.store.io/backup-namespace"
+	LabelStoreName       = "infra.store.io/store-name"
+	LabelStoreNamespace  = "infra.store.io/store-namespace"
+	// LabelRetainedClaim names the PVC a retained PersistentVolume was bound to
+	LabelRetainedClaim = "infra.store.io/retained-claim"
+)
+
+// Namespace naming
+const (
+	StoreNamespacePrefix = "store-"
+)
+
+// Event reasons
 const (
-	HelmKeyWordPressBlogName = "wordpressBlogName"
-	HelmKeyService           = "service"
-	HelmKeyVolumePermissions = "volumePermissions"
-	HelmKeyWordPressPassword = "wordpressPassword"
-	HelmKeyIngress           = "ingress"
-	HelmKeyMariaDB           = "mariadb"
-	HelmKeyPersistence       = "persistence"
-	HelmKeyLivenessProbe     = "livenessProbe"
-	HelmKeyReadinessProbe    = "readinessProbe"
+	EventReasonDeleteFailed      = "DeleteFailed"
+	EventReasonProvisioning      = "Provisioning"
+	EventReasonFailed            = "Failed"
+	EventReasonReady             = "Ready"
+	EventReasonBackupDone        = "BackupCompleted"
+	EventReasonRestoring         = "Restoring"
+	EventReasonRestored          = "Restored"
+	EventReasonCloning           = "Cloning"
+	EventReasonCloned            = "Cloned"
+	EventReasonSuspended         = "Suspended"
+	EventReasonResumed           = "Resumed"
+	EventReasonRotated           = "CredentialsRotated"
+	EventReasonDrifted           = "DriftDetected"
+	EventReasonRepaired          = "DriftRepaired"
+	EventReasonRolledBack        = "RolledBack"
+	EventReasonGaveUp            = "RetriesExhausted"
+	EventReasonRetrying          = "RetryRequested"
+	EventReasonRetained          = "VolumesRetained"
+	EventReasonSnapshotted       = "Snapshotted"
+	EventReasonVolumeExpanded    = "VolumeExpanded"
+	EventReasonExpansionBlocked  = "ExpansionBlocked"
+	EventReasonPlanChangeBlocked = "PlanChangeBlocked"
+	EventReasonScaleBlocked      = "ScaleBlocked"
+	EventReasonDegraded          = "Degraded"
+	EventReasonRecovered         = "Recovered"
 )
@@ -0,0 +1,611 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+)
+
+// Mount paths shared by data Jobs
+const (
+	dataJobWorkDir    = "/work"
+	dataJobContentDir = "/content"
+	dataJobTargetDir  = "/target"
+	dataJobSourceDir  = "/source"
+)
+
+// Files inside a backup archive
+const (
+	backupDatabaseFile = "database.sql.gz"
+	backupContentFile  = "content.tar.gz"
+)
+
+// Keys in a data Job's env Secret
+const (
+	jobSecretKeyDBPassword   = "DB_PASSWORD"
+	jobSecretKeyAWSAccessKey = "AWS_ACCESS_KEY_ID"
+	jobSecretKeyAWSSecretKey = "AWS_SECRET_ACCESS_KEY"
+	// jobSecretKeySourceDBPassword is the database password of a clone's source
+	jobSecretKeySourceDBPassword = "SOURCE_DB_PASSWORD"
+)
+
+// backupResult is what the final backup container writes to its termination message
+type backupResult struct {
+	SizeBytes int64  `json:"sizeBytes"`
+	Checksum  string `json:"checksum"`
+}
+
+// backupJobName is the Job (and env Secret prefix) for a StoreBackup
+func backupJobName(backup *infrav1alpha1.StoreBackup) string {
+	return "backup-" + backup.Name
+}
+
+// backupArchiveName is the file name of a StoreBackup's archive
+func backupArchiveName(backup *infrav1alpha1.StoreBackup) string {
+	return fmt.Sprintf("%s-%s.tar", backup.Spec.StoreName, backup.Name)
+}
+
+// backupLocation renders where a StoreBackup's archive ends up
+func backupLocation(backup *infrav1alpha1.StoreBackup) string {
+	target := backup.Spec.Target
+	if target.S3 != nil {
+		return fmt.Sprintf("s3://%s/%s", target.S3.Bucket, path.Join(target.S3.Prefix, backupArchiveName(backup)))
+	}
+	return fmt.Sprintf("pvc://%s/%s", target.PVC.ClaimName, path.Join(target.PVC.Path, backupArchiveName(backup)))
+}
+
+// restoreJobName is the Job (and env Secret) that restores a StoreBackup into a store
+func restoreJobName(backupName string) string {
+	return "restore-" + backupName
+}
+
+// cloneJobName is the Job (and env Secret, ServiceAccount and source
+// namespace Role) that copies another store's data into a store
+func cloneJobName(store *infrav1alpha1.Store) string {
+	return "clone-" + store.Name
+}
+
+// rotationJobName is the Job (and env Secret) that rotates a store's passwords
+func rotationJobName(store *infrav1alpha1.Store) string {
+	return "rotate-" + store.Name
+}
+
+// databaseEnv is the environment engine dump and restore scripts expect
+func databaseEnv(jobName string, data engine.DataSpec) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "DB_HOST", Value: data.DatabaseHost},
+		{Name: "DB_NAME", Value: data.DatabaseName},
+		secretEnv("DB_PASSWORD", jobName, jobSecretKeyDBPassword),
+		{Name: "DUMP_FILE", Value: path.Join(dataJobWorkDir, backupDatabaseFile)},
+	}
+}
+
+// contentPodLabels selects a store's application pods, the ones that mount
+// its content volume
+func contentPodLabels(store *infrav1alpha1.Store, provider engine.Provider) map[string]string {
+	labels := workloadLabels(store)
+	for k, v := range provider.ReadinessLabels() {
+		labels[k] = v
+	}
+	return labels
+}
+
+// contentAffinity keeps a data Job on a node running one of contentPods: a
+// ReadWriteOnce content volume they mount can't be attached anywhere else
+func contentAffinity(contentPods map[string]string) *corev1.Affinity {
+	return &corev1.Affinity{
+		PodAffinity: &corev1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: contentPods},
+				TopologyKey:   corev1.LabelHostname,
+			}},
+		},
+	}
+}
+
+// bashStep is a Job container running a bash script with strict error handling
+func bashStep(name, image, script string, env []corev1.EnvVar, mounts []corev1.VolumeMount) corev1.Container {
+	return corev1.Container{
+		Name:                     name,
+		Image:                    image,
+		Command:                  []string{"/bin/bash", "-c", "set -euo pipefail\n" + script},
+		Env:                      env,
+		VolumeMounts:             mounts,
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	}
+}
+
+// secretEnv maps an env var to a key in the Job's env Secret
+func secretEnv(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+// buildBackupJob renders the Job that dumps the database, archives content and
+// writes a single tarball to the backup target. The content is read next to
+// contentPods, which have its volume attached.
+func buildBackupJob(backup *infrav1alpha1.StoreBackup, namespace string, data engine.DataSpec,
+	hasContent bool, contentPods map[string]string, uploaderImage string, backoffLimit int32) *batchv1.Job {
+
+	jobName := backupJobName(backup)
+	archive := backupArchiveName(backup)
+	archivePath := path.Join(dataJobWorkDir, archive)
+
+	workMount := corev1.VolumeMount{Name: "work", MountPath: dataJobWorkDir}
+	volumes := []corev1.Volume{
+		{Name: "work", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+
+	dbEnv := databaseEnv(jobName, data)
+
+	initContainers := []corev1.Container{
+		bashStep(BackupStepDumpDatabase, data.Image, data.DumpScript, dbEnv, []corev1.VolumeMount{workMount}),
+	}
+
+	parts := []string{backupDatabaseFile}
+	var affinity *corev1.Affinity
+	if hasContent {
+		affinity = contentAffinity(contentPods)
+		volumes = append(volumes, corev1.Volume{
+			Name: "content",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: data.ContentClaim,
+				ReadOnly:  true,
+			}},
+		})
+		initContainers = append(initContainers, bashStep(BackupStepArchiveContent, data.Image,
+			fmt.Sprintf(`tar -czf %q -C %q %q`, path.Join(dataJobWorkDir, backupContentFile), dataJobContentDir, data.ContentDir),
+			nil,
+			[]corev1.VolumeMount{workMount, {Name: "content", MountPath: dataJobContentDir, ReadOnly: true}},
+		))
+		parts = append(parts, backupContentFile)
+	}
+
+	initContainers = append(initContainers, bashStep(BackupStepPackage, data.Image,
+		fmt.Sprintf(`tar -cf %q -C %q %s`, archivePath, dataJobWorkDir, strings.Join(parts, " ")),
+		nil, []corev1.VolumeMount{workMount},
+	))
+
+	// The final container reports size and checksum through its termination message
+	report := fmt.Sprintf(`size=$(stat -c %%s %[1]q)
+sum=$(sha256sum %[1]q | cut -d' ' -f1)
+printf '{"sizeBytes":%%s,"checksum":"sha256:%%s"}' "$size" "$sum" > /dev/termination-log`, archivePath)
+
+	var final corev1.Container
+	target := backup.Spec.Target
+	if target.S3 != nil {
+		key := path.Join(target.S3.Prefix, archive)
+		env := []corev1.EnvVar{
+			secretEnv("AWS_ACCESS_KEY_ID", jobName, jobSecretKeyAWSAccessKey),
+			secretEnv("AWS_SECRET_ACCESS_KEY", jobName, jobSecretKeyAWSSecretKey),
+		}
+		if target.S3.Region != "" {
+			env = append(env, corev1.EnvVar{Name: "AWS_DEFAULT_REGION", Value: target.S3.Region})
+		}
+		final = bashStep(BackupStepUpload, uploaderImage,
+			fmt.Sprintf(`aws s3 cp %q %q --endpoint-url %q
+%s`, archivePath, fmt.Sprintf("s3://%s/%s", target.S3.Bucket, key), target.S3.Endpoint, report),
+			env, []corev1.VolumeMount{workMount},
+		)
+	} else {
+		dir := path.Join(dataJobTargetDir, target.PVC.Path)
+		volumes = append(volumes, corev1.Volume{
+			Name: "target",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: target.PVC.ClaimName,
+			}},
+		})
+		final = bashStep(BackupStepUpload, data.Image,
+			fmt.Sprintf(`mkdir -p %[1]q
+cp %[2]q %[1]q/
+%[3]s`, dir, archivePath, report),
+			nil, []corev1.VolumeMount{workMount, {Name: "target", MountPath: dataJobTargetDir}},
+		)
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+			Labels:    backupLabels(backup),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: backupPodLabels(backup)},
+				Spec: corev1.PodSpec{
+					RestartPolicy:  corev1.RestartPolicyNever,
+					Affinity:       affinity,
+					InitContainers: initContainers,
+					Containers:     []corev1.Container{final},
+					Volumes:        volumes,
+				},
+			},
+		},
+	}
+}
+
+// buildRestoreJob renders the Job that fetches a backup archive, loads its
+// database dump and unpacks its content into the store's volume, next to
+// contentPods
+func buildRestoreJob(store *infrav1alpha1.Store, backup *infrav1alpha1.StoreBackup, namespace string,
+	data engine.DataSpec, hasContent bool, contentPods map[string]string, uploaderImage string, backoffLimit int32) *batchv1.Job {
+
+	jobName := restoreJobName(backup.Name)
+	archive := backupArchiveName(backup)
+	archivePath := path.Join(dataJobWorkDir, archive)
+
+	workMount := corev1.VolumeMount{Name: "work", MountPath: dataJobWorkDir}
+	volumes := []corev1.Volume{
+		{Name: "work", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+
+	// Archives written before checksums were recorded can't be verified
+	verify := ""
+	if sum := strings.TrimPrefix(backup.Status.Checksum, "sha256:"); sum != "" {
+		verify = fmt.Sprintf("\necho %q | sha256sum -c -", sum+"  "+archivePath)
+	}
+
+	var fetch corev1.Container
+	target := backup.Spec.Target
+	if target.S3 != nil {
+		env := []corev1.EnvVar{
+			secretEnv("AWS_ACCESS_KEY_ID", jobName, jobSecretKeyAWSAccessKey),
+			secretEnv("AWS_SECRET_ACCESS_KEY", jobName, jobSecretKeyAWSSecretKey),
+		}
+		if target.S3.Region != "" {
+			env = append(env, corev1.EnvVar{Name: "AWS_DEFAULT_REGION", Value: target.S3.Region})
+		}
+		key := path.Join(target.S3.Prefix, archive)
+		fetch = bashStep(RestoreStepFetch, uploaderImage,
+			fmt.Sprintf(`aws s3 cp %q %q --endpoint-url %q`, fmt.Sprintf("s3://%s/%s", target.S3.Bucket, key), archivePath, target.S3.Endpoint)+verify,
+			env, []corev1.VolumeMount{workMount},
+		)
+	} else {
+		volumes = append(volumes, corev1.Volume{
+			Name: "source",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: target.PVC.ClaimName,
+				ReadOnly:  true,
+			}},
+		})
+		fetch = bashStep(RestoreStepFetch, data.Image,
+			fmt.Sprintf(`cp %q %q`, path.Join(dataJobSourceDir, target.PVC.Path, archive), archivePath)+verify,
+			nil, []corev1.VolumeMount{workMount, {Name: "source", MountPath: dataJobSourceDir, ReadOnly: true}},
+		)
+	}
+
+	initContainers := []corev1.Container{
+		fetch,
+		bashStep(RestoreStepUnpack, data.Image,
+			fmt.Sprintf(`tar -xf %q -C %q`, archivePath, dataJobWorkDir),
+			nil, []corev1.VolumeMount{workMount},
+		),
+		bashStep(RestoreStepRestoreDatabase, data.Image, data.RestoreScript,
+			databaseEnv(jobName, data), []corev1.VolumeMount{workMount},
+		),
+	}
+
+	// Content is unpacked over the store's volume when both sides have one
+	contentFile := path.Join(dataJobWorkDir, backupContentFile)
+	contentMounts := []corev1.VolumeMount{workMount}
+	script := `echo "No content to restore"`
+	var affinity *corev1.Affinity
+	if hasContent {
+		affinity = contentAffinity(contentPods)
+		volumes = append(volumes, corev1.Volume{
+			Name: "content",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: data.ContentClaim,
+			}},
+		})
+		contentMounts = append(contentMounts, corev1.VolumeMount{Name: "content", MountPath: dataJobContentDir})
+		script = fmt.Sprintf(`if [ -f %[1]q ]; then
+  tar -xzf %[1]q -C %[2]q --no-same-owner
+else
+  echo "Backup has no content archive"
+fi`, contentFile, dataJobContentDir)
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+			Labels:    storeLabels(store),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: storeLabels(store)},
+				Spec: corev1.PodSpec{
+					RestartPolicy:  corev1.RestartPolicyNever,
+					Affinity:       affinity,
+					InitContainers: initContainers,
+					Containers: []corev1.Container{
+						bashStep(RestoreStepRestoreContent, data.Image, script, nil, contentMounts),
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+}
+
+// buildCloneJob renders the Job that streams a running store's database dump
+// and content out of its pods with kubectl exec, loads them into the store
+// and points the copy from sourceHost at targetHost and the store's NEW_*
+// credentials. The content is unpacked next to contentPods.
+func buildCloneJob(store *infrav1alpha1.Store, namespace, sourceNamespace, sourceHost, targetHost string,
+	data engine.DataSpec, clone engine.CloneSpec, keys []engine.CredentialKey, hasContent bool,
+	contentPods map[string]string, kubectlImage string, backoffLimit int32) *batchv1.Job {
+
+	jobName := cloneJobName(store)
+	workMount := corev1.VolumeMount{Name: "work", MountPath: dataJobWorkDir}
+	volumes := []corev1.Volume{
+		{Name: "work", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+
+	// The source's password reaches its dump over stdin, not the exec command line
+	initContainers := []corev1.Container{
+		bashStep(CloneStepCopyDatabase, kubectlImage,
+			fmt.Sprintf(`printf '%%s\n' "$SOURCE_DB_PASSWORD" | kubectl exec -i -n %q %q -c %q -- \
+  env DB_HOST=127.0.0.1 DB_NAME=%q DUMP_FILE=/dev/stdout bash -c "$DUMP_SCRIPT" > %q`,
+				sourceNamespace, clone.DatabaseTarget, clone.DatabaseContainer, data.DatabaseName,
+				path.Join(dataJobWorkDir, backupDatabaseFile)),
+			[]corev1.EnvVar{
+				secretEnv("SOURCE_DB_PASSWORD", jobName, jobSecretKeySourceDBPassword),
+				{Name: "DUMP_SCRIPT", Value: "set -euo pipefail\nread -r DB_PASSWORD\nexport DB_PASSWORD\n" + data.DumpScript},
+			},
+			[]corev1.VolumeMount{workMount},
+		),
+	}
+
+	// Stores without persistence only get the source's database
+	var affinity *corev1.Affinity
+	if hasContent {
+		affinity = contentAffinity(contentPods)
+		volumes = append(volumes, corev1.Volume{
+			Name: "content",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: data.ContentClaim,
+			}},
+		})
+		initContainers = append(initContainers, bashStep(CloneStepCopyContent, kubectlImage,
+			fmt.Sprintf(`kubectl exec -n %q %q -c %q -- tar -czf - -C %q %q > %q`,
+				sourceNamespace, clone.ContentTarget, clone.ContentContainer, clone.ContentPath, data.ContentDir,
+				path.Join(dataJobWorkDir, backupContentFile)),
+			nil, []corev1.VolumeMount{workMount},
+		))
+	}
+
+	dbEnv := databaseEnv(jobName, data)
+	initContainers = append(initContainers, bashStep(RestoreStepRestoreDatabase, data.Image, data.RestoreScript,
+		dbEnv, []corev1.VolumeMount{workMount}))
+	if hasContent {
+		initContainers = append(initContainers, bashStep(RestoreStepRestoreContent, data.Image,
+			fmt.Sprintf(`tar -xzf %q -C %q --no-same-owner`, path.Join(dataJobWorkDir, backupContentFile), dataJobContentDir),
+			nil, []corev1.VolumeMount{workMount, {Name: "content", MountPath: dataJobContentDir}},
+		))
+	}
+
+	rewriteEnv := append(dbEnv,
+		corev1.EnvVar{Name: "SOURCE_HOST", Value: sourceHost},
+		corev1.EnvVar{Name: "TARGET_HOST", Value: targetHost},
+	)
+	for _, key := range keys {
+		name := "NEW_" + engine.CredentialEnvName(key.Name)
+		rewriteEnv = append(rewriteEnv, secretEnv(name, jobName, name))
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+			Labels:    storeLabels(store),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: storeLabels(store)},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: jobName,
+					Affinity:           affinity,
+					InitContainers:     initContainers,
+					Containers: []corev1.Container{
+						bashStep(CloneStepRewriteURLs, data.Image, clone.RewriteScript, rewriteEnv, nil),
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+}
+
+// buildRotationJob renders the Job that changes a store's passwords from the
+// OLD_* to the NEW_* values held in its env Secret
+func buildRotationJob(store *infrav1alpha1.Store, namespace string, data engine.DataSpec,
+	keys []engine.CredentialKey, script string, backoffLimit int32) *batchv1.Job {
+
+	jobName := rotationJobName(store)
+	env := []corev1.EnvVar{
+		{Name: "DB_HOST", Value: data.DatabaseHost},
+		{Name: "DB_NAME", Value: data.DatabaseName},
+	}
+	for _, key := range keys {
+		name := engine.CredentialEnvName(key.Name)
+		env = append(env,
+			secretEnv("OLD_"+name, jobName, "OLD_"+name),
+			secretEnv("NEW_"+name, jobName, "NEW_"+name),
+		)
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+			Labels:    storeLabels(store),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: storeLabels(store)},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						bashStep(RotationStepChangePasswords, data.Image, script, env, nil),
+					},
+				},
+			},
+		},
+	}
+}
+
+// backupLabels ties a Job back to its StoreBackup across namespaces
+func backupLabels(backup *infrav1alpha1.StoreBackup) map[string]string {
+	return map[string]string{
+		LabelManagedBy:       ManagedByValue,
+		LabelBackupName:      backup.Name,
+		LabelBackupNamespace: backup.Namespace,
+	}
+}
+
+// backupPodLabels also name the backed-up store so the pod is cached with
+// the store's other workloads; the Job itself stays out of storeForJob
+func backupPodLabels(backup *infrav1alpha1.StoreBackup) map[string]string {
+	labels := backupLabels(backup)
+	labels[LabelStoreName] = backup.Spec.StoreName
+	labels[LabelStoreNamespace] = backup.Namespace
+	return labels
+}
+
+// storeLabels ties a Job back to its Store across namespaces
+func storeLabels(store *infrav1alpha1.Store) map[string]string {
+	return map[string]string{
+		LabelManagedBy:      ManagedByValue,
+		LabelStoreName:      store.Name,
+		LabelStoreNamespace: store.Namespace,
+	}
+}
+
+// jobProgress reports the container a data Job's pod is currently running
+func jobProgress(pods []corev1.Pod) string {
+	for _, pod := range pods {
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if cs.State.Running != nil {
+				return cs.Name
+			}
+		}
+	}
+	return ""
+}
+
+// parseBackupResult reads the termination message of the final backup container
+func parseBackupResult(pods []corev1.Pod) (backupResult, error) {
+	var result backupResult
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name != BackupStepUpload || cs.State.Terminated == nil {
+				continue
+			}
+			if err := json.Unmarshal([]byte(cs.State.Terminated.Message), &result); err != nil {
+				return result, fmt.Errorf("parsing backup result: %w", err)
+			}
+			return result, nil
+		}
+	}
+	return result, fmt.Errorf("no succeeded backup pod reported a result")
+}
+
+// jobFinished returns the terminal condition of a Job, if any
+func jobFinished(job *batchv1.Job) (batchv1.JobConditionType, string, bool) {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return c.Type, c.Message, true
+		}
+	}
+	return "", "", false
+}
+
+// dataJobSecretData collects the store's database password and, for S3
+// targets, the backup's access keys; backup may be nil. A non-empty reason marks errors the user has to fix.
+func dataJobSecretData(ctx context.Context, c client.Reader, store *infrav1alpha1.Store,
+	backup *infrav1alpha1.StoreBackup, data engine.DataSpec) (map[string][]byte, string, error) {
+
+	var creds corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Name: store.Name + "-creds", Namespace: store.Namespace}, &creds); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ReasonCredentialsMissing, fmt.Errorf("credentials secret %s-creds does not exist", store.Name)
+		}
+		return nil, "", err
+	}
+	password, ok := creds.Data[data.PasswordKey]
+	if !ok {
+		return nil, ReasonCredentialsMissing, fmt.Errorf("credentials secret %s-creds has no %s", store.Name, data.PasswordKey)
+	}
+
+	secretData := map[string][]byte{jobSecretKeyDBPassword: password}
+
+	if backup == nil {
+		return secretData, "", nil
+	}
+	if s3 := backup.Spec.Target.S3; s3 != nil {
+		var s3Creds corev1.Secret
+		if err := c.Get(ctx, types.NamespacedName{Name: s3.CredentialsSecret, Namespace: backup.Namespace}, &s3Creds); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, ReasonCredentialsMissing, fmt.Errorf("S3 credentials secret %q does not exist", s3.CredentialsSecret)
+			}
+			return nil, "", err
+		}
+		for _, key := range []string{jobSecretKeyAWSAccessKey, jobSecretKeyAWSSecretKey} {
+			v, ok := s3Creds.Data[key]
+			if !ok {
+				return nil, ReasonCredentialsMissing, fmt.Errorf("S3 credentials secret %q has no %s", s3.CredentialsSecret, key)
+			}
+			secretData[key] = v
+		}
+	}
+
+	return secretData, "", nil
+}
+
+// deleteDataJob removes a data Job, its pods and its env Secret
+func deleteDataJob(ctx context.Context, c client.Client, namespace, name string) error {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if err := c.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return deleteJobSecret(ctx, c, namespace, name)
+}
+
+// deleteJobSecret removes a data Job's env Secret
+func deleteJobSecret(ctx context.Context, c client.Client, namespace, name string) error {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if err := c.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -1,8 +1,15 @@
 package controller
 
 import (
+	"context"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
 )
 
 var (
@@ -16,17 +23,141 @@ var (
 		Help: "Total number of stores deleted",
 	})
 
-	storeProvisioningSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+	storeProvisioningSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "store_provisioning_seconds",
 		Help:    "Time taken for a store to go from Provisioning to Ready",
 		Buckets: prometheus.ExponentialBuckets(5, 2, 8), // 5s, 10s, 20s, ... 640s
-	})
+	}, []string{"plan"})
+
+	storeReconcileErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "store_reconcile_errors_total",
+		Help: "Failures reported on stores, by status reason",
+	}, []string{"reason"})
+
+	storeHelmSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "store_helm_operation_seconds",
+		Help:    "Duration of Helm operations on store releases",
+		Buckets: prometheus.ExponentialBuckets(0.5, 2, 10), // 0.5s, 1s, 2s, ... 256s
+	}, []string{"operation", "result"})
+
+	storeResourceUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "store_resource_used",
+		Help: "ResourceQuota usage of a store's namespace (cores for CPU, bytes for memory)",
+	}, []string{"store", "namespace", "resource"})
+
+	storeResourceHard = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "store_resource_hard",
+		Help: "ResourceQuota granted to a store's namespace by its plan (cores for CPU, bytes for memory)",
+	}, []string{"store", "namespace", "resource"})
+
+	storeStorageBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "store_storage_requested_bytes",
+		Help: "Total requested size of a store's PVCs",
+	}, []string{"store", "namespace"})
 )
 
+// recordUsage exports a store's usage sample, replacing its previous series
+func recordUsage(store *infrav1alpha1.Store, usage *infrav1alpha1.StoreUsage) {
+	forgetUsage(store)
+	for name, q := range usage.Used {
+		storeResourceUsed.WithLabelValues(store.Name, store.Namespace, string(name)).Set(q.AsApproximateFloat64())
+	}
+	for name, q := range usage.Hard {
+		storeResourceHard.WithLabelValues(store.Name, store.Namespace, string(name)).Set(q.AsApproximateFloat64())
+	}
+	if usage.Storage != nil {
+		storeStorageBytes.WithLabelValues(store.Name, store.Namespace).Set(usage.Storage.AsApproximateFloat64())
+	}
+}
+
+// forgetUsage drops a store's usage series
+func forgetUsage(store *infrav1alpha1.Store) {
+	labels := prometheus.Labels{"store": store.Name, "namespace": store.Namespace}
+	storeResourceUsed.DeletePartialMatch(labels)
+	storeResourceHard.DeletePartialMatch(labels)
+	storeStorageBytes.DeletePartialMatch(labels)
+}
+
 func init() {
 	metrics.Registry.MustRegister(
 		storeCreatedTotal,
 		storeDeletionTotal,
 		storeProvisioningSeconds,
+		storeReconcileErrorsTotal,
+		storeHelmSeconds,
+		storeResourceUsed,
+		storeResourceHard,
+		storeStorageBytes,
 	)
 }
+
+// Helm operations and their outcomes, as recorded by store_helm_operation_seconds
+const (
+	helmOperationInstall   = "install"
+	helmOperationUpgrade   = "upgrade"
+	helmOperationRollback  = "rollback"
+	helmOperationUninstall = "uninstall"
+
+	helmResultSuccess = "success"
+	helmResultFailure = "failure"
+)
+
+// observeHelm records how long a Helm operation that started at start took
+func observeHelm(operation string, start time.Time, err error) {
+	result := helmResultSuccess
+	if err != nil {
+		result = helmResultFailure
+	}
+	storeHelmSeconds.WithLabelValues(operation, result).Observe(time.Since(start).Seconds())
+}
+
+// recordError counts a failure reported on a store. Callers record it where
+// the failure is first reported, not on every reconcile that still sees it.
+func recordError(reason string) {
+	storeReconcileErrorsTotal.WithLabelValues(reason).Inc()
+}
+
+// storeCollector reports the number of stores by phase, plan and engine. It
+// lists Stores from the cache at scrape time, so deleted stores drop out
+// without the reconciler having to track them.
+type storeCollector struct {
+	reader client.Reader
+	desc   *prometheus.Desc
+}
+
+func newStoreCollector(reader client.Reader) *storeCollector {
+	return &storeCollector{
+		reader: reader,
+		desc: prometheus.NewDesc("store_count", "Number of stores by phase, plan and engine",
+			[]string{"phase", "plan", "engine"}, nil),
+	}
+}
+
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var stores infrav1alpha1.StoreList
+	if err := c.reader.List(ctx, &stores); err != nil {
+		log.Log.WithName("metrics").Error(err, "unable to list Stores")
+		return
+	}
+
+	type key struct{ phase, plan, engine string }
+	counts := map[key]int{}
+	for _, store := range stores.Items {
+		phase := store.Status.Phase
+		if phase == "" {
+			// Not reconciled yet; matches the backend's Pending status
+			phase = "Pending"
+		}
+		counts[key{phase, store.Spec.Plan, store.Spec.Engine}]++
+	}
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), k.phase, k.plan, k.engine)
+	}
+}
@@ -6,30 +6,37 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
 )
 
+// ensureGuardrails applies the plan's quota and limits and the network policy
+func (r *StoreReconciler) ensureGuardrails(ctx context.Context, store *infrav1alpha1.Store, namespace string, planSpec PlanSpec,
+	provider engine.Provider) error {
+	if err := r.ensureQuota(ctx, namespace, planSpec); err != nil {
+		return err
+	}
+	if err := r.ensureLimitRange(ctx, namespace, planSpec); err != nil {
+		return err
+	}
+	return r.ensureNetworkPolicy(ctx, store, namespace, provider)
+}
+
 // ensureQuota creates or updates a ResourceQuota based on plan
-func (r *StoreReconciler) ensureQuota(ctx context.Context, namespace, plan string) error {
+func (r *StoreReconciler) ensureQuota(ctx context.Context, namespace string, planSpec PlanSpec) error {
 	logger := ctrl.LoggerFrom(ctx)
 
-	// Get resource specs for the plan
-	planSpec := GetPlanSpec(plan)
-
 	quota := &corev1.ResourceQuota{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      ResourceQuotaName,
 			Namespace: namespace,
 		},
 		Spec: corev1.ResourceQuotaSpec{
-			Hard: corev1.ResourceList{
-				"requests.cpu":    planSpec.RequestsCPU,
-				"requests.memory": planSpec.RequestsMemory,
-				"limits.cpu":      planSpec.LimitsCPU,
-				"limits.memory":   planSpec.LimitsMemory,
-				"pods":            planSpec.MaxPods,
-			},
+			Hard: quotaHard(planSpec),
 		},
 	}
 
@@ -50,13 +57,21 @@ func (r *StoreReconciler) ensureQuota(ctx context.Context, namespace, plan strin
 	return nil
 }
 
+// quotaHard is the ResourceQuota a plan grants a store namespace
+func quotaHard(planSpec PlanSpec) corev1.ResourceList {
+	return corev1.ResourceList{
+		"requests.cpu":    planSpec.RequestsCPU,
+		"requests.memory": planSpec.RequestsMemory,
+		"limits.cpu":      planSpec.LimitsCPU,
+		"limits.memory":   planSpec.LimitsMemory,
+		"pods":            planSpec.MaxPods,
+	}
+}
+
 // ensureLimitRange creates defaults for containers based on plan
-func (r *StoreReconciler) ensureLimitRange(ctx context.Context, namespace string, plan string) error {
+func (r *StoreReconciler) ensureLimitRange(ctx context.Context, namespace string, planSpec PlanSpec) error {
 	logger := ctrl.LoggerFrom(ctx)
 
-	// Get resource specs for the plan
-	planSpec := GetPlanSpec(plan)
-
 	limit := &corev1.LimitRange{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      LimitRangeName,
@@ -96,9 +111,35 @@ func (r *StoreReconciler) ensureLimitRange(ctx context.Context, namespace string
 	return nil
 }
 
-// ensureNetworkPolicy creates a default-deny policy
-func (r *StoreReconciler) ensureNetworkPolicy(ctx context.Context, namespace string) error {
-	logger := ctrl.LoggerFrom(ctx)
+// ensureNetworkPolicy denies everything the store doesn't need: ingress is
+// admitted from the ingress controller, the Gateway's proxies in Gateway mode
+// and the namespace itself, plus the operator's health probe on the engine's
+// health port; egress goes to cluster DNS, the namespace and whatever
+// spec.network allow-lists
+func (r *StoreReconciler) ensureNetworkPolicy(ctx context.Context, store *infrav1alpha1.Store, namespace string,
+	provider engine.Provider) error {
+	from := []netv1.NetworkPolicyPeer{
+		// Allow traffic from the Ingress Controller
+		namespacePeer(r.Config.IngressNamespace, r.Config.IngressPodLabels),
+		// Also allow traffic from within the same namespace
+		{PodSelector: &metav1.LabelSelector{}},
+	}
+	if routing := engine.ResolveRouting(store, r.Config); routing.Mode == engine.RoutingModeGateway {
+		proxyNamespace := r.Config.GatewayProxyNamespace
+		if proxyNamespace == "" {
+			proxyNamespace = routing.Gateway.Namespace
+		}
+		from = append(from, namespacePeer(proxyNamespace, r.Config.GatewayProxyPodLabels))
+	}
+	ingress := []netv1.NetworkPolicyIngressRule{{From: from}}
+	// The health probe runs in the operator's pod
+	if checker, ok := provider.(engine.HealthChecker); ok && r.HealthProbe != nil {
+		port := intstr.FromString(checker.HealthEndpoint(store.Name).PortName)
+		ingress = append(ingress, netv1.NetworkPolicyIngressRule{
+			From:  []netv1.NetworkPolicyPeer{namespacePeer(r.Config.OperatorNamespace, nil)},
+			Ports: []netv1.NetworkPolicyPort{{Port: &port}},
+		})
+	}
 
 	np := &netv1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -111,45 +152,98 @@ func (r *StoreReconciler) ensureNetworkPolicy(ctx context.Context, namespace str
 				netv1.PolicyTypeIngress,
 				netv1.PolicyTypeEgress,
 			},
-			Ingress: []netv1.NetworkPolicyIngressRule{
-				{
-					// Allow traffic from Ingress Controller
-					From: []netv1.NetworkPolicyPeer{
-						{
-							NamespaceSelector: &metav1.LabelSelector{
-								MatchLabels: map[string]string{
-									"kubernetes.io/metadata.name": "ingress-nginx",
-								},
-							},
-						},
-						{
-							// Also allow traffic from within the same namespace
-							PodSelector: &metav1.LabelSelector{},
-						},
-					},
-				},
-			},
-			Egress: []netv1.NetworkPolicyEgressRule{
-				{
-					// Allow all egress (for DNS, etc)
-					To: []netv1.NetworkPolicyPeer{},
-				},
+			Ingress: ingress,
+			Egress:  storeEgressRules(store, r.Config.DNSNamespace, r.Config.DNSPodLabels),
+		},
+	}
+	if err := r.applyNetworkPolicy(ctx, np); err != nil {
+		return err
+	}
+
+	// Backup and restore Jobs reach object storage outside the cluster
+	dataJobs := &netv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DataJobNetworkPolicyName,
+			Namespace: namespace,
+		},
+		Spec: netv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{LabelManagedBy: ManagedByValue}},
+			PolicyTypes: []netv1.PolicyType{netv1.PolicyTypeEgress},
+			Egress:      []netv1.NetworkPolicyEgressRule{{}},
+		},
+	}
+	return r.applyNetworkPolicy(ctx, dataJobs)
+}
+
+// storeEgressRules allows DNS lookups, traffic inside the namespace and the
+// destinations listed in spec.network
+func storeEgressRules(store *infrav1alpha1.Store, dnsNamespace string, dnsPodLabels map[string]string) []netv1.NetworkPolicyEgressRule {
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+	dnsPort := intstr.FromInt32(53)
+	rules := []netv1.NetworkPolicyEgressRule{
+		{
+			To: []netv1.NetworkPolicyPeer{namespacePeer(dnsNamespace, dnsPodLabels)},
+			Ports: []netv1.NetworkPolicyPort{
+				{Protocol: &udp, Port: &dnsPort},
+				{Protocol: &tcp, Port: &dnsPort},
 			},
 		},
+		{
+			To: []netv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+		},
+	}
+	if store.Spec.Network == nil {
+		return rules
 	}
 
+	for _, allowed := range store.Spec.Network.Egress {
+		var rule netv1.NetworkPolicyEgressRule
+		if allowed.CIDR != "" {
+			rule.To = []netv1.NetworkPolicyPeer{{IPBlock: &netv1.IPBlock{CIDR: allowed.CIDR, Except: allowed.Except}}}
+		}
+		for _, p := range allowed.Ports {
+			protocol := corev1.Protocol(p.Protocol)
+			if protocol == "" {
+				protocol = corev1.ProtocolTCP
+			}
+			port := intstr.FromInt32(p.Port)
+			rule.Ports = append(rule.Ports, netv1.NetworkPolicyPort{Protocol: &protocol, Port: &port})
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// namespacePeer selects pods carrying labels in the named namespace; no
+// labels selects every pod there
+func namespacePeer(namespace string, labels map[string]string) netv1.NetworkPolicyPeer {
+	peer := netv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{corev1.LabelMetadataName: namespace},
+		},
+	}
+	if len(labels) > 0 {
+		peer.PodSelector = &metav1.LabelSelector{MatchLabels: labels}
+	}
+	return peer
+}
+
+// applyNetworkPolicy creates the policy or replaces an existing one's spec
+func (r *StoreReconciler) applyNetworkPolicy(ctx context.Context, np *netv1.NetworkPolicy) error {
+	logger := ctrl.LoggerFrom(ctx)
+
 	var existing netv1.NetworkPolicy
-	if err := r.Get(ctx, clientObjectKey(namespace, NetworkPolicyName), &existing); err == nil {
+	if err := r.Get(ctx, clientObjectKey(np.Namespace, np.Name), &existing); err == nil {
 		existing.Spec = np.Spec
 		if err := r.Update(ctx, &existing); err != nil {
-			logger.Error(err, "updating NetworkPolicy")
+			logger.Error(err, "updating NetworkPolicy", "name", np.Name)
 			return err
 		}
 		return nil
 	}
 
 	if err := r.Create(ctx, np); err != nil {
-		logger.Error(err, "creating NetworkPolicy")
+		logger.Error(err, "creating NetworkPolicy", "name", np.Name)
 		return err
 	}
 	return nil
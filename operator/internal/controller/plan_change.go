@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/config"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+)
+
+// reconcilePlanChange decides which plan a store runs on. A store moving to
+// another plan only switches once its namespace's current usage fits the
+// new plan; until then it keeps the plan it is on and PlanChangeBlocked is
+// True. The bool reports a status change.
+func (r *StoreReconciler) reconcilePlanChange(ctx context.Context, store *infrav1alpha1.Store, nsName, releaseName string,
+	provider engine.Provider, target *infrav1alpha1.StorePlan) (*infrav1alpha1.StorePlan, bool, error) {
+	logger := log.FromContext(ctx)
+	changed := false
+
+	// Stores reconciled before appliedPlan existed run on their spec's plan
+	if store.Status.AppliedPlan == "" && store.Status.ObservedGeneration != 0 &&
+		store.Status.ObservedGeneration == store.Generation {
+		store.Status.AppliedPlan = store.Spec.Plan
+		changed = true
+	}
+
+	if store.Status.AppliedPlan == "" || store.Status.AppliedPlan == target.Name {
+		if meta.FindStatusCondition(store.Status.Conditions, ConditionPlanChangeBlocked) != nil {
+			changed = setCondition(store, ConditionPlanChangeBlocked, metav1.ConditionFalse,
+				ConditionReasonPlanApplied, fmt.Sprintf("Store runs on plan %s", target.Name)) || changed
+		}
+		return target, changed, nil
+	}
+
+	current, err := r.resolvePlan(ctx, store.Status.AppliedPlan)
+	if apierrors.IsNotFound(err) {
+		// Nothing to fall back to; the new plan applies as is
+		logger.Info("Previous StorePlan is gone, switching plans without a usage check",
+			"from", store.Status.AppliedPlan, "to", target.Name)
+		return target, changed, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	overages, err := r.planOverages(ctx, store, nsName, releaseName, provider, target)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(overages) == 0 {
+		logger.Info("Usage fits the new plan, switching", "from", current.Name, "to", target.Name)
+		changed = setCondition(store, ConditionPlanChangeBlocked, metav1.ConditionFalse, ConditionReasonPlanApplied,
+			fmt.Sprintf("Moved from plan %s to plan %s", current.Name, target.Name)) || changed
+		return target, changed, nil
+	}
+
+	msg := fmt.Sprintf("Staying on plan %s until usage fits plan %s: %s", current.Name, target.Name, strings.Join(overages, "; "))
+	if setCondition(store, ConditionPlanChangeBlocked, metav1.ConditionTrue, ConditionReasonUsageExceedsPlan, msg) {
+		changed = true
+		r.Recorder.Eventf(store, corev1.EventTypeWarning, EventReasonPlanChangeBlocked, "%s", msg)
+	}
+	return current, changed, nil
+}
+
+// planOverages lists what the store namespace currently uses beyond a plan:
+// ResourceQuota usage above the plan's quota, volumes larger than the plan's
+// persistence sizes (volumes can't shrink), and replicas the plan's content
+// volume can't be shared by
+func (r *StoreReconciler) planOverages(ctx context.Context, store *infrav1alpha1.Store, nsName, releaseName string,
+	provider engine.Provider, plan *infrav1alpha1.StorePlan) ([]string, error) {
+	var overages []string
+
+	if replicas, shared := sharesReadWriteOnce(store, provider, releaseName, &plan.Spec, r.Config); shared {
+		overages = append(overages, fmt.Sprintf("%d replicas can't share the plan's ReadWriteOnce content volume", replicas))
+	}
+
+	quota := &corev1.ResourceQuota{}
+	if err := r.Get(ctx, clientObjectKey(nsName, ResourceQuotaName), quota); err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	hard := quotaHard(PlanSpecFromStorePlan(plan))
+	resources := make([]string, 0, len(hard))
+	for name := range hard {
+		resources = append(resources, string(name))
+	}
+	sort.Strings(resources)
+	for _, name := range resources {
+		limit := hard[corev1.ResourceName(name)]
+		if used, ok := quota.Status.Used[corev1.ResourceName(name)]; ok && used.Cmp(limit) > 0 {
+			overages = append(overages, fmt.Sprintf("%s uses %s, plan allows %s", name, used.String(), limit.String()))
+		}
+	}
+
+	volumes, ok := provider.(engine.VolumeProvider)
+	if !ok {
+		return overages, nil
+	}
+	for _, v := range volumes.Volumes(releaseName) {
+		p := engine.VolumePersistence(&plan.Spec, v)
+		if p.Size == nil || !engine.PersistenceEnabled(p, r.Config) {
+			continue
+		}
+		pvc := &corev1.PersistentVolumeClaim{}
+		if err := r.Get(ctx, types.NamespacedName{Name: v.Claim, Namespace: nsName}, pvc); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.Cmp(*p.Size) > 0 {
+			overages = append(overages, fmt.Sprintf("PVC %s is %s, plan gives %s", pvc.Name, size.String(), p.Size.String()))
+		}
+	}
+	return overages, nil
+}
+
+// sharesReadWriteOnce reports whether more than one application replica would
+// mount a ReadWriteOnce content volume on plan, and how many replicas it runs
+func sharesReadWriteOnce(store *infrav1alpha1.Store, provider engine.Provider, releaseName string,
+	plan *infrav1alpha1.StorePlanSpec, cfg *config.OperatorConfig) (int32, bool) {
+	replicas := engine.MaxReplicas(store, plan)
+	return replicas, replicas > 1 && engine.ContentReadWriteOnce(provider, releaseName, plan, cfg)
+}
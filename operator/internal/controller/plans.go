@@ -1,11 +1,24 @@
 package controller
 
 import (
+	"context"
+
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
 )
 
+// storePlanIndexKey indexes Stores by spec.plan so plan changes can fan out
+const storePlanIndexKey = "spec.plan"
+
 // PlanSpec defines resource limits and defaults for a store plan
 type PlanSpec struct {
+	// Name of the StorePlan this spec was resolved from
+	Name string
+
 	// ResourceQuota limits
 	RequestsCPU    resource.Quantity
 	RequestsMemory resource.Quantity
@@ -20,57 +33,46 @@ type PlanSpec struct {
 	DefaultRequestMemory resource.Quantity
 }
 
-// SupportedPlans defines the resource specifications for each plan tier
-var SupportedPlans = map[string]PlanSpec{
-	"small": {
-		RequestsCPU:    resource.MustParse("500m"),
-		RequestsMemory: resource.MustParse("512Mi"),
-		LimitsCPU:      resource.MustParse("1"),
-		LimitsMemory:   resource.MustParse("1Gi"),
-		MaxPods:        resource.MustParse("10"),
-
-		DefaultCPU:           resource.MustParse("200m"),
-		DefaultMemory:        resource.MustParse("256Mi"),
-		DefaultRequestCPU:    resource.MustParse("50m"),
-		DefaultRequestMemory: resource.MustParse("128Mi"),
-	},
-	"medium": {
-		RequestsCPU:    resource.MustParse("1"),
-		RequestsMemory: resource.MustParse("1Gi"),
-		LimitsCPU:      resource.MustParse("2"),
-		LimitsMemory:   resource.MustParse("2Gi"),
-		MaxPods:        resource.MustParse("15"),
+// PlanSpecFromStorePlan flattens a StorePlan into the reconciler's PlanSpec
+func PlanSpecFromStorePlan(plan *infrav1alpha1.StorePlan) PlanSpec {
+	spec := plan.Spec
+	return PlanSpec{
+		Name: plan.Name,
 
-		DefaultCPU:           resource.MustParse("500m"),
-		DefaultMemory:        resource.MustParse("512Mi"),
-		DefaultRequestCPU:    resource.MustParse("100m"),
-		DefaultRequestMemory: resource.MustParse("256Mi"),
-	},
-	"large": {
-		RequestsCPU:    resource.MustParse("2"),
-		RequestsMemory: resource.MustParse("2Gi"),
-		LimitsCPU:      resource.MustParse("4"),
-		LimitsMemory:   resource.MustParse("4Gi"),
-		MaxPods:        resource.MustParse("20"),
+		RequestsCPU:    spec.Quota.RequestsCPU,
+		RequestsMemory: spec.Quota.RequestsMemory,
+		LimitsCPU:      spec.Quota.LimitsCPU,
+		LimitsMemory:   spec.Quota.LimitsMemory,
+		MaxPods:        *resource.NewQuantity(spec.Quota.MaxPods, resource.DecimalSI),
 
-		DefaultCPU:           resource.MustParse("1"),
-		DefaultMemory:        resource.MustParse("1Gi"),
-		DefaultRequestCPU:    resource.MustParse("200m"),
-		DefaultRequestMemory: resource.MustParse("512Mi"),
-	},
+		DefaultCPU:           spec.LimitRange.DefaultCPU,
+		DefaultMemory:        spec.LimitRange.DefaultMemory,
+		DefaultRequestCPU:    spec.LimitRange.DefaultRequestCPU,
+		DefaultRequestMemory: spec.LimitRange.DefaultRequestMemory,
+	}
 }
 
-// GetPlanSpec returns the resource spec for a plan, defaulting to "small" if invalid
-func GetPlanSpec(plan string) PlanSpec {
-	if spec, ok := SupportedPlans[plan]; ok {
-		return spec
+// resolvePlan looks up the StorePlan named by a Store's spec.plan
+func (r *StoreReconciler) resolvePlan(ctx context.Context, name string) (*infrav1alpha1.StorePlan, error) {
+	var plan infrav1alpha1.StorePlan
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, &plan); err != nil {
+		return nil, err
 	}
-	// Default to small plan if invalid plan specified
-	return SupportedPlans["small"]
+	return &plan, nil
 }
 
-// IsValidPlan checks if a plan name is supported
-func IsValidPlan(plan string) bool {
-	_, ok := SupportedPlans[plan]
-	return ok
+// storesForPlan maps a StorePlan event to every Store that references it
+func (r *StoreReconciler) storesForPlan(ctx context.Context, obj client.Object) []reconcile.Request {
+	var stores infrav1alpha1.StoreList
+	if err := r.List(ctx, &stores, client.MatchingFields{storePlanIndexKey: obj.GetName()}); err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(stores.Items))
+	for _, store := range stores.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: store.Name, Namespace: store.Namespace},
+		})
+	}
+	return requests
 }
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+)
+
+// backoffJitter is the largest fraction added on top of a retry delay, so
+// stores that failed together don't retry together
+const backoffJitter = 0.2
+
+// retryDelay is how long to wait after the given number of consecutive
+// failures: base, doubled for every further failure, capped at max
+func retryDelay(base, max time.Duration, failures int) time.Duration {
+	delay := base
+	for i := 1; i < failures && (max <= 0 || delay < max); i++ {
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// backoffRemaining returns how much of the current backoff is left before
+// the store's next Helm attempt; zero when it may retry now
+func (r *StoreReconciler) backoffRemaining(store *infrav1alpha1.Store, now time.Time) time.Duration {
+	if store.Status.FailureCount == 0 || store.Status.LastFailureTime == nil {
+		return 0
+	}
+	delay := retryDelay(r.Config.HelmFailureRetryInterval, r.Config.HelmRetryMaxInterval, store.Status.FailureCount)
+	if remaining := store.Status.LastFailureTime.Add(delay).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// retriesExhausted reports whether the store used up its failure budget
+func (r *StoreReconciler) retriesExhausted(store *infrav1alpha1.Store) bool {
+	return r.Config.MaxFailedAttempts > 0 && store.Status.FailureCount >= r.Config.MaxFailedAttempts
+}
+
+// recordFailure counts a failed Helm attempt on a store whose status already
+// describes the failure, and returns when to try again. Once the budget is
+// spent the store gets reason RetriesExhausted and no more Helm attempts. A
+// store that never became Ready moves to Failed and is not requeued; a
+// serving one keeps its phase and comes back for its next health check. The
+// caller persists the status.
+func (r *StoreReconciler) recordFailure(store *infrav1alpha1.Store) ctrl.Result {
+	now := metav1.Now()
+	store.Status.FailureCount++
+	store.Status.LastFailureTime = &now
+
+	if r.retriesExhausted(store) {
+		store.Status.Reason = ReasonRetriesExhausted
+		store.Status.Message = fmt.Sprintf("Gave up after %d failed attempts; set the %s annotation to retry: %s",
+			store.Status.FailureCount, AnnotationRetryNow, store.Status.Message)
+		if isServing(store.Status.Phase) {
+			return ctrl.Result{RequeueAfter: r.Config.HealthCheckInterval}
+		}
+		store.Status.Phase = PhaseFailed
+		return ctrl.Result{}
+	}
+
+	// The requeue only adds jitter on top of the delay, so it never lands
+	// before backoffRemaining lets the attempt through
+	delay := retryDelay(r.Config.HelmFailureRetryInterval, r.Config.HelmRetryMaxInterval, store.Status.FailureCount)
+	delay = wait.Jitter(delay, backoffJitter)
+	store.Status.Message = fmt.Sprintf("%s (attempt %d, retrying in %s)",
+		store.Status.Message, store.Status.FailureCount, delay.Round(time.Second))
+	return ctrl.Result{RequeueAfter: delay}
+}
+
+// recordGaveUp announces a store that just spent its failure budget
+func (r *StoreReconciler) recordGaveUp(store *infrav1alpha1.Store) {
+	if store.Status.Reason == ReasonRetriesExhausted {
+		r.Recorder.Eventf(store, corev1.EventTypeWarning, EventReasonGaveUp,
+			"Giving up after %d failed attempts; set the %s annotation to retry", store.Status.FailureCount, AnnotationRetryNow)
+		recordError(ReasonRetriesExhausted)
+	}
+}
+
+// reconcileRetryNow handles the retry-now annotation: it removes the
+// annotation and clears the failure count so the next attempt runs at once
+func (r *StoreReconciler) reconcileRetryNow(ctx context.Context, store *infrav1alpha1.Store) error {
+	if _, ok := store.Annotations[AnnotationRetryNow]; !ok {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+
+	delete(store.Annotations, AnnotationRetryNow)
+	if err := r.Update(ctx, store); err != nil {
+		return err
+	}
+	if store.Status.FailureCount == 0 {
+		return nil
+	}
+
+	logger.Info("Retry requested, resetting failure budget", "failureCount", store.Status.FailureCount)
+	store.Status.FailureCount = 0
+	if store.Status.Reason == ReasonRetriesExhausted {
+		// A serving store stays in its phase while the upgrade is retried
+		if !isServing(store.Status.Phase) {
+			store.Status.Phase = PhaseProvisioning
+			store.Status.Reason = ReasonProvisioning
+		} else {
+			store.Status.Reason = ""
+		}
+		store.Status.Message = "Retrying after the retry-now annotation was set"
+	}
+	if err := r.updateStatus(ctx, store); err != nil {
+		return err
+	}
+	r.Recorder.Event(store, corev1.EventTypeNormal, EventReasonRetrying, "Failure budget reset; retrying now")
+	return nil
+}
@@ -0,0 +1,274 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+)
+
+// reconcileClone drives spec.cloneFrom. It returns done once the source's data
+// has been copied; until then the caller returns the given result and error,
+// which keeps the store out of the Ready phase. The store keeps the
+// credentials it was provisioned with; only data is copied.
+func (r *StoreReconciler) reconcileClone(ctx context.Context, store *infrav1alpha1.Store, nsName string,
+	provider engine.Provider) (ctrl.Result, bool, error) {
+
+	logger := log.FromContext(ctx)
+	sourceName := store.Spec.CloneFrom.StoreName
+
+	// A new source starts a fresh clone
+	if store.Status.Clone == nil || store.Status.Clone.StoreName != sourceName {
+		store.Status.Clone = &infrav1alpha1.CloneStatus{StoreName: sourceName}
+	}
+	clone := store.Status.Clone
+
+	switch clone.Phase {
+	case ClonePhaseCompleted:
+		return ctrl.Result{}, true, nil
+	case ClonePhaseFailed:
+		// Terminal until cloneFrom points at another store
+		return ctrl.Result{}, false, nil
+	}
+
+	jobName := cloneJobName(store)
+	var job batchv1.Job
+	err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: nsName}, &job)
+	if apierrors.IsNotFound(err) {
+		result, err := r.startClone(ctx, store, nsName, provider)
+		return result, false, err
+	} else if err != nil {
+		return ctrl.Result{}, false, err
+	}
+
+	condition, message, finished := jobFinished(&job)
+	if !finished {
+		// The Job watch wakes us on completion; polling covers missed events
+		return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, false, nil
+	}
+
+	// The env Secret holds both stores' credentials and the Role opens the
+	// source to exec; don't leave either behind
+	if err := r.revokeCloneAccess(ctx, store, nsName); err != nil {
+		return ctrl.Result{}, false, err
+	}
+
+	if condition == batchv1.JobFailed {
+		return ctrl.Result{}, false, r.failClone(ctx, store, fmt.Sprintf("Clone Job failed: %s", message))
+	}
+
+	logger.Info("Clone completed", "source", sourceName)
+	now := metav1.Now()
+	clone.Phase = ClonePhaseCompleted
+	clone.CompletionTime = &now
+	r.Recorder.Eventf(store, corev1.EventTypeNormal, EventReasonCloned, "Cloned from store %s", sourceName)
+
+	// The caller persists the status together with the Ready phase
+	return ctrl.Result{}, true, nil
+}
+
+// startClone checks the source store and creates the env Secret, the access to
+// the source namespace and the clone Job
+func (r *StoreReconciler) startClone(ctx context.Context, store *infrav1alpha1.Store, nsName string,
+	provider engine.Provider) (ctrl.Result, error) {
+
+	logger := log.FromContext(ctx)
+	sourceName := store.Spec.CloneFrom.StoreName
+	if sourceName == store.Name {
+		return ctrl.Result{}, r.failClone(ctx, store, "A store can't be cloned from itself")
+	}
+
+	var source infrav1alpha1.Store
+	if err := r.Get(ctx, types.NamespacedName{Name: sourceName, Namespace: store.Namespace}, &source); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, r.failClone(ctx, store, fmt.Sprintf("Store %q does not exist", sourceName))
+		}
+		return ctrl.Result{}, err
+	}
+	if source.Spec.Engine != store.Spec.Engine {
+		return ctrl.Result{}, r.failClone(ctx, store, fmt.Sprintf(
+			"Store %q runs engine %q; a clone must use the same engine", sourceName, source.Spec.Engine))
+	}
+
+	dataProvider, isData := provider.(engine.DataProvider)
+	cloner, isCloner := provider.(engine.Cloner)
+	if !isData || !isCloner {
+		return ctrl.Result{}, r.failClone(ctx, store, fmt.Sprintf("Engine %q does not support clones", store.Spec.Engine))
+	}
+
+	// The source's pods must be running to be read from
+	if !isServing(source.Status.Phase) || source.Spec.Suspended {
+		if store.Status.Reason != ReasonWaitingSource {
+			store.Status.Phase = PhaseProvisioning
+			store.Status.Reason = ReasonWaitingSource
+			store.Status.Message = fmt.Sprintf("Waiting for store %q to be Ready", sourceName)
+			if err := r.updateStatus(ctx, store); err != nil {
+				logger.Error(err, "unable to update Store status")
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, nil
+	}
+
+	data := dataProvider.DataSpec(store.Name, r.Config)
+	secretData, reason, err := dataJobSecretData(ctx, r.Client, store, nil, data)
+	if err != nil {
+		if reason != "" {
+			return ctrl.Result{}, r.failClone(ctx, store, err.Error())
+		}
+		return ctrl.Result{}, err
+	}
+	sourceData, reason, err := dataJobSecretData(ctx, r.Client, &source, nil, dataProvider.DataSpec(source.Name, r.Config))
+	if err != nil {
+		if reason != "" {
+			return ctrl.Result{}, r.failClone(ctx, store, err.Error())
+		}
+		return ctrl.Result{}, err
+	}
+	secretData[jobSecretKeySourceDBPassword] = sourceData[jobSecretKeyDBPassword]
+
+	// The copied database gets the clone's own passwords
+	var creds corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: store.Name + "-creds", Namespace: store.Namespace}, &creds); err != nil {
+		return ctrl.Result{}, err
+	}
+	for _, key := range provider.CredentialKeys() {
+		password, ok := creds.Data[key.Name]
+		if !ok {
+			return ctrl.Result{}, r.failClone(ctx, store, fmt.Sprintf("credentials secret %s-creds has no %s", store.Name, key.Name))
+		}
+		secretData["NEW_"+engine.CredentialEnvName(key.Name)] = password
+	}
+
+	// Stores without persistence only get the source's database
+	var pvc corev1.PersistentVolumeClaim
+	hasContent := true
+	if err := r.Get(ctx, types.NamespacedName{Name: data.ContentClaim, Namespace: nsName}, &pvc); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		hasContent = false
+	}
+
+	jobName := cloneJobName(store)
+	sourceNamespace := StoreNamespacePrefix + sourceName
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: nsName,
+			Labels:    storeLabels(store),
+		},
+		Data: secretData,
+	}
+	if err := r.Create(ctx, secret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+	if err := r.grantCloneAccess(ctx, store, nsName, sourceNamespace); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	job := buildCloneJob(store, nsName, sourceNamespace,
+		fmt.Sprintf("%s.%s", sourceName, r.Config.BaseDomain), fmt.Sprintf("%s.%s", store.Name, r.Config.BaseDomain),
+		data, cloner.CloneSpec(&source, store), provider.CredentialKeys(), hasContent,
+		contentPodLabels(store, provider), r.Config.CloneKubectlImage, int32(r.Config.BackupJobBackoffLimit))
+	if err := r.Create(ctx, job); err != nil && !apierrors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Started clone Job", "job", jobName, "namespace", nsName, "source", sourceName, "content", hasContent)
+
+	now := metav1.Now()
+	store.Status.Clone.Phase = ClonePhaseRunning
+	store.Status.Clone.JobName = jobName
+	store.Status.Clone.StartTime = &now
+	store.Status.Phase = PhaseProvisioning
+	store.Status.Reason = ReasonCloning
+	store.Status.Message = fmt.Sprintf("Cloning store %q", sourceName)
+	if err := r.updateStatus(ctx, store); err != nil {
+		logger.Error(err, "unable to update Store status")
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Eventf(store, corev1.EventTypeNormal, EventReasonCloning, "Cloning store %s", sourceName)
+	return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, nil
+}
+
+// failClone marks the clone and the Store as Failed with ReasonCloneFailed
+func (r *StoreReconciler) failClone(ctx context.Context, store *infrav1alpha1.Store, message string) error {
+	now := metav1.Now()
+	store.Status.Clone.Phase = ClonePhaseFailed
+	store.Status.Clone.CompletionTime = &now
+	store.Status.Phase = PhaseFailed
+	store.Status.Reason = ReasonCloneFailed
+	store.Status.Message = message
+	if err := r.updateStatus(ctx, store); err != nil {
+		log.FromContext(ctx).Error(err, "unable to update Store status")
+		return err
+	}
+	r.Recorder.Event(store, corev1.EventTypeWarning, EventReasonFailed, message)
+	recordError(ReasonCloneFailed)
+	return nil
+}
+
+// grantCloneAccess lets the clone Job's ServiceAccount exec into the pods of
+// the source store's namespace
+func (r *StoreReconciler) grantCloneAccess(ctx context.Context, store *infrav1alpha1.Store, nsName, sourceNamespace string) error {
+	name := cloneJobName(store)
+	objects := []client.Object{
+		&corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsName, Labels: storeLabels(store)},
+		},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: sourceNamespace, Labels: storeLabels(store)},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+				{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}},
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments", "statefulsets"}, Verbs: []string{"get"}},
+			},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: sourceNamespace, Labels: storeLabels(store)},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: nsName}},
+		},
+	}
+	for _, obj := range objects {
+		if err := r.Create(ctx, obj); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeCloneAccess removes the clone Job's env Secret, ServiceAccount and
+// its access to the source namespace. Stores deleted mid-clone call it too,
+// since the Role outlives the store's own namespace.
+func (r *StoreReconciler) revokeCloneAccess(ctx context.Context, store *infrav1alpha1.Store, nsName string) error {
+	name := cloneJobName(store)
+	sourceNamespace := StoreNamespacePrefix + store.Spec.CloneFrom.StoreName
+	if err := deleteJobSecret(ctx, r.Client, nsName, name); err != nil {
+		return err
+	}
+	objects := []client.Object{
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsName}},
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: sourceNamespace}},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: sourceNamespace}},
+	}
+	for _, obj := range objects {
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+)
+
+// setCondition records a condition against the store's current generation.
+// It reports whether anything changed; the caller persists the status.
+func setCondition(store *infrav1alpha1.Store, conditionType string, status metav1.ConditionStatus, reason, message string) bool {
+	return meta.SetStatusCondition(&store.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: store.Generation,
+	})
+}
+
+// syncReadyCondition derives the overall Ready condition from the phase, so
+// it never disagrees with Phase/Reason/Message
+func syncReadyCondition(store *infrav1alpha1.Store) {
+	if store.Status.Phase == PhaseReady {
+		message := fmt.Sprintf("Store is ready at %s", store.Status.URL)
+		if store.Status.Message != "" {
+			message = store.Status.Message
+		}
+		setCondition(store, ConditionReady, metav1.ConditionTrue, ConditionReasonStoreReady, message)
+		return
+	}
+
+	reason := store.Status.Reason
+	if reason == "" {
+		reason = store.Status.Phase
+	}
+	if reason == "" {
+		reason = ReasonProvisioning
+	}
+	setCondition(store, ConditionReady, metav1.ConditionFalse, reason, store.Status.Message)
+}
+
+// updateStatus writes the store's status, Ready condition included
+func (r *StoreReconciler) updateStatus(ctx context.Context, store *infrav1alpha1.Store) error {
+	syncReadyCondition(store)
+	return r.Status().Update(ctx, store)
+}
@@ -10,17 +10,24 @@ import (
 	"time"
 
 	"github.com/Jovial-Kanwadia/store-operator/internal/config"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
 	"github.com/Jovial-Kanwadia/store-operator/internal/helm"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
 )
@@ -31,16 +38,33 @@ type StoreReconciler struct {
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
 	Config   *config.OperatorConfig
+
+	// Releases installs and removes the Helm release behind each store
+	Releases helm.ReleaseManager
+
+	// HealthProbe checks a serving store's in-cluster HTTP endpoint; nil
+	// skips the probe and leaves health to the workload checks
+	HealthProbe ProbeFunc
 }
 
 // +kubebuilder:rbac:groups=infra.store.io,resources=stores,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infra.store.io,resources=stores/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=infra.store.io,resources=stores/finalizers,verbs=update
-// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=infra.store.io,resources=storeplans,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infra.store.io,resources=storebackups,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;patch;delete
 // +kubebuilder:rbac:groups="",resources=pods;services;events;persistentvolumeclaims;secrets;configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=create;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=create;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=resourcequotas;limitranges,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotcontents,verbs=get;list;watch;update;patch
 
 func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -57,22 +81,51 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	if !store.DeletionTimestamp.IsZero() {
 		if containsString(store.Finalizers, storeFinalizer) {
 			logger.Info("Deleting Store resources...", "store", store.Name)
-			storeDeletionTotal.Inc()
+
+			// Protect or capture the store's data first, once, before teardown starts
+			var ns corev1.Namespace
+			if err := r.Get(ctx, types.NamespacedName{Name: nsName}, &ns); err == nil && ns.Status.Phase != corev1.NamespaceTerminating {
+				if result, done, err := r.prepareDeletion(ctx, &store, nsName); !done {
+					return result, err
+				}
+			} else if err != nil && !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
 
 			// A. Uninstall Helm Release
-			if err := helm.UninstallRelease(ctrl.GetConfigOrDie(), releaseName, nsName); err != nil {
+			uninstallStart := time.Now()
+			if err := r.Releases.Uninstall(ctx, releaseName, nsName); err != nil {
 				// Ignore "not found" errors to prevent getting stuck
 				if !strings.Contains(err.Error(), "not found") {
+					observeHelm(helmOperationUninstall, uninstallStart, err)
 					logger.Error(err, "Helm uninstall failed")
 					r.Recorder.Eventf(&store, corev1.EventTypeWarning, EventReasonDeleteFailed, "Helm uninstall failed: %v", err)
 					return ctrl.Result{RequeueAfter: r.Config.DeletionRequeueInterval}, nil
 				}
+			} else {
+				observeHelm(helmOperationUninstall, uninstallStart, nil)
 			}
 
-			// B. Delete PVCs (Clean up storage)
+			// B. Delete PVCs (Clean up storage); backup targets are kept
+			backupClaims, err := r.backupClaims(ctx, &store)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
 			var pvcList corev1.PersistentVolumeClaimList
 			if err := r.List(ctx, &pvcList, &client.ListOptions{Namespace: nsName}); err == nil {
+				var retained []corev1.PersistentVolumeClaim
+				for _, pvc := range pvcList.Items {
+					if backupClaims[pvc.Name] {
+						retained = append(retained, pvc)
+					}
+				}
+				if err := r.retainVolumes(ctx, &store, retained); err != nil {
+					return ctrl.Result{}, err
+				}
 				for _, pvc := range pvcList.Items {
+					if backupClaims[pvc.Name] {
+						continue
+					}
 					if err := r.Delete(ctx, &pvc); err != nil {
 						logger.Error(err, "Failed to delete PVC", "pvc", pvc.Name)
 						// Continue deleting other PVCs even if one fails
@@ -80,9 +133,15 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 				}
 			}
 
+			// A clone's access to its source namespace outlives this store's namespace
+			if store.Spec.CloneFrom != nil {
+				if err := r.revokeCloneAccess(ctx, &store, nsName); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+
 			// C. Delete Namespace
-			var ns corev1.Namespace
-			err := r.Get(ctx, types.NamespacedName{Name: nsName}, &ns)
+			err = r.Get(ctx, types.NamespacedName{Name: nsName}, &ns)
 			if err == nil {
 				// Namespace exists - DELETE IT
 				if ns.Status.Phase != corev1.NamespaceTerminating {
@@ -100,11 +159,21 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 				return ctrl.Result{}, err
 			}
 
+			// Retained volumes lose their deleted claims; point them at a future store
+			if deletionPolicy(&store) == DeletionPolicyRetain || len(backupClaims) > 0 {
+				if err := r.detachRetainedVolumes(ctx, &store); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+
 			// D. Remove Finalizer (Only reachable if Namespace is NotFound)
 			store.Finalizers = removeString(store.Finalizers, storeFinalizer)
 			if err := r.Update(ctx, &store); err != nil {
 				return ctrl.Result{}, err
 			}
+			// Counted once the finalizer is gone, not on every teardown pass
+			storeDeletionTotal.Inc()
+			forgetUsage(&store)
 		}
 		return ctrl.Result{}, nil
 	}
@@ -118,11 +187,23 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		}
 	}
 
-	// B. Ensure Namespace
+	// The retry-now annotation resets the failure budget; a store that spent
+	// it stays Failed without being requeued until then
+	if err := r.reconcileRetryNow(ctx, &store); err != nil {
+		return ctrl.Result{}, err
+	}
+	// A store that never became Ready has nothing left to do once its budget
+	// is spent; a serving one still gets its health, usage and drift checks
+	if r.retriesExhausted(&store) && !isServing(store.Status.Phase) {
+		logger.V(1).Info("Store has no retries left", "failureCount", store.Status.FailureCount)
+		return ctrl.Result{}, nil
+	}
+
+	// B. Ensure Namespace, labelled so its workloads map back to this Store
 	var ns corev1.Namespace
 	if err := r.Get(ctx, types.NamespacedName{Name: nsName}, &ns); err != nil {
 		if apierrors.IsNotFound(err) {
-			ns = corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}}
+			ns = corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName, Labels: storeNamespaceLabels(&store)}}
 			if err := r.Create(ctx, &ns); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -130,75 +211,184 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		}
 		return ctrl.Result{}, err
 	}
+	if ns.Labels[LabelStoreName] != store.Name || ns.Labels[LabelStoreNamespace] != store.Namespace {
+		// Namespaces created before the labels existed
+		patch := client.MergeFrom(ns.DeepCopy())
+		if ns.Labels == nil {
+			ns.Labels = map[string]string{}
+		}
+		for k, v := range storeNamespaceLabels(&store) {
+			ns.Labels[k] = v
+		}
+		if err := r.Patch(ctx, &ns, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	// Conditions are written with the next status update; a settled store
+	// with nothing else to report writes them at the end
+	conditionsChanged := setCondition(&store, ConditionNamespaceReady, metav1.ConditionTrue,
+		ConditionReasonCreated, fmt.Sprintf("Namespace %s exists", nsName))
+
+	// Resolve the engine provider before touching anything engine-specific
+	provider, err := engine.Resolve(store.Spec.Engine, r.Config)
+	if err != nil {
+		logger.Error(err, "Unsupported engine", "engine", store.Spec.Engine)
+		if store.Status.Reason != ReasonUnknownEngine {
+			store.Status.Phase = PhaseFailed
+			store.Status.Reason = ReasonUnknownEngine
+			store.Status.Message = fmt.Sprintf("%v: supported engines are %s", err, strings.Join(engine.Available(r.Config), ", "))
+			if err := r.updateStatus(ctx, &store); err != nil {
+				logger.Error(err, "unable to update Store status")
+				return ctrl.Result{}, err
+			}
+			r.Recorder.Eventf(&store, corev1.EventTypeWarning, EventReasonFailed, "Unsupported engine %q", store.Spec.Engine)
+			recordError(ReasonUnknownEngine)
+		}
+		// Nothing to retry until the spec changes
+		return ctrl.Result{}, nil
+	}
+
+	// Resolve the StorePlan; a plan created later re-triggers this Store via the watch
+	plan, err := r.resolvePlan(ctx, store.Spec.Plan)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		logger.Info("StorePlan not found", "plan", store.Spec.Plan)
+		if store.Status.Reason != ReasonPlanNotFound {
+			store.Status.Phase = PhaseFailed
+			store.Status.Reason = ReasonPlanNotFound
+			store.Status.Message = fmt.Sprintf("StorePlan %q does not exist", store.Spec.Plan)
+			if err := r.updateStatus(ctx, &store); err != nil {
+				logger.Error(err, "unable to update Store status")
+				return ctrl.Result{}, err
+			}
+			r.Recorder.Eventf(&store, corev1.EventTypeWarning, EventReasonFailed, "StorePlan %q does not exist", store.Spec.Plan)
+			recordError(ReasonPlanNotFound)
+		}
+		return ctrl.Result{}, nil
+	}
+	// The application's replicas plus the engine's database pods must fit the
+	// plan's pod quota, or pods would be rejected at admission
+	if maxPods := engine.MaxReplicas(&store, &plan.Spec) + provider.SupportingPods(); int64(maxPods) > plan.Spec.Quota.MaxPods {
+		logger.Info("Replicas exceed the plan's pod quota", "plan", plan.Name, "pods", maxPods, "maxPods", plan.Spec.Quota.MaxPods)
+		msg := fmt.Sprintf("Store needs up to %d pods but plan %q allows %d", maxPods, plan.Name, plan.Spec.Quota.MaxPods)
+		if store.Status.Reason != ReasonReplicasExceedPlan || store.Status.Message != msg {
+			store.Status.Phase = PhaseFailed
+			store.Status.Reason = ReasonReplicasExceedPlan
+			store.Status.Message = msg
+			if err := r.updateStatus(ctx, &store); err != nil {
+				logger.Error(err, "unable to update Store status")
+				return ctrl.Result{}, err
+			}
+			r.Recorder.Eventf(&store, corev1.EventTypeWarning, EventReasonFailed, "%s", msg)
+			recordError(ReasonReplicasExceedPlan)
+		}
+		// Nothing to retry until the store or plan changes
+		return ctrl.Result{}, nil
+	}
+	// A move to another plan waits until the namespace's usage fits it
+	plan, planChanged, err := r.reconcilePlanChange(ctx, &store, nsName, releaseName, provider, plan)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	conditionsChanged = planChanged || conditionsChanged
+	planSpec := PlanSpecFromStorePlan(plan)
+
+	// Every application replica mounts the content volume; spread over nodes
+	// they can't all attach a ReadWriteOnce one. A store never installed
+	// fails; an installed one keeps its release until the spec fits.
+	scaleBlocked := false
+	if replicas, shared := sharesReadWriteOnce(&store, provider, releaseName, &plan.Spec, r.Config); shared {
+		msg := fmt.Sprintf("Store runs up to %d replicas but plan %q's content volume isn't ReadWriteMany", replicas, plan.Name)
+		logger.Info("Replicas share a ReadWriteOnce volume", "plan", plan.Name, "replicas", replicas)
+		if store.Status.LastAppliedRevision == 0 {
+			if store.Status.Reason != ReasonReadWriteOnceVolume || store.Status.Message != msg {
+				store.Status.Phase = PhaseFailed
+				store.Status.Reason = ReasonReadWriteOnceVolume
+				store.Status.Message = msg
+				if err := r.updateStatus(ctx, &store); err != nil {
+					logger.Error(err, "unable to update Store status")
+					return ctrl.Result{}, err
+				}
+				r.Recorder.Eventf(&store, corev1.EventTypeWarning, EventReasonFailed, "%s", msg)
+				recordError(ReasonReadWriteOnceVolume)
+			}
+			// Nothing to retry until the store or plan changes
+			return ctrl.Result{}, nil
+		}
+		scaleBlocked = true
+		if setCondition(&store, ConditionScaleBlocked, metav1.ConditionTrue, ReasonReadWriteOnceVolume, msg) {
+			conditionsChanged = true
+			r.Recorder.Eventf(&store, corev1.EventTypeWarning, EventReasonScaleBlocked, "%s", msg)
+		}
+	} else if meta.FindStatusCondition(store.Status.Conditions, ConditionScaleBlocked) != nil {
+		conditionsChanged = setCondition(&store, ConditionScaleBlocked, metav1.ConditionFalse, ConditionReasonApplied,
+			"Replicas fit the content volume") || conditionsChanged
+	}
 
 	// NEW: Manage Credentials
-	creds, err := r.ReconcileCredentials(ctx, &store)
+	creds, err := r.ReconcileCredentials(ctx, &store, provider)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
+	conditionsChanged = setCondition(&store, ConditionCredentialsReady, metav1.ConditionTrue,
+		ConditionReasonGenerated, fmt.Sprintf("Credentials are stored in Secret %s-creds", store.Name)) || conditionsChanged
+
+	// Rotate credentials when asked; the upgrade below hands the new passwords to the chart
+	result, done, rotated, err := r.reconcileRotation(ctx, &store, nsName, provider, creds)
+	if !done {
+		return result, err
+	}
 
 	// C. Apply Guardrails (Quota, Limits, NetPol)
-	if err := r.ensureQuota(ctx, nsName, store.Spec.Plan); err != nil {
+	if err := r.ensureGuardrails(ctx, &store, nsName, planSpec, provider); err != nil {
+		if setCondition(&store, ConditionGuardrailsApplied, metav1.ConditionFalse, ConditionReasonApplyFailed, err.Error()) {
+			if err := r.updateStatus(ctx, &store); err != nil {
+				logger.Error(err, "unable to update Store status")
+			}
+		}
 		return ctrl.Result{}, err
 	}
-	if err := r.ensureLimitRange(ctx, nsName, store.Spec.Plan); err != nil {
+	conditionsChanged = setCondition(&store, ConditionGuardrailsApplied, metav1.ConditionTrue,
+		ConditionReasonApplied, fmt.Sprintf("ResourceQuota, LimitRange and NetworkPolicy match plan %s", plan.Name)) || conditionsChanged
+
+	// Sample the namespace's quota usage and volumes into status.usage
+	usageChanged, err := r.sampleUsage(ctx, &store, nsName)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
-	if err := r.ensureNetworkPolicy(ctx, nsName); err != nil {
+	conditionsChanged = usageChanged || conditionsChanged
+
+	// D. Determine Chart Source and Hostname from Config
+	chart := storeChart(&store, provider.Chart(r.Config))
+	hostname := fmt.Sprintf("%s.%s", store.Name, r.Config.BaseDomain)
+
+	// Grow the store's volumes with its plan; ones that can't grow are rendered at their current size
+	upgrading := store.Generation != store.Status.ObservedGeneration ||
+		plan.Name != store.Status.AppliedPlan || plan.Generation != store.Status.ObservedPlanGeneration
+	renderPlan, volumesChanged, err := r.reconcileVolumes(ctx, &store, nsName, releaseName, provider, &plan.Spec, upgrading)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
-
-	// D. Determine Chart Path and Base Domain from Config
-	chartPath := r.Config.WordPressChartPath
-	baseDomain := r.Config.BaseDomain
+	conditionsChanged = volumesChanged || conditionsChanged
 
 	// E. Prepare Values
-	values := map[string]interface{}{
-		"wordpressBlogName": store.Name,
-		"service":           map[string]interface{}{"type": "ClusterIP"},
-		"volumePermissions": map[string]interface{}{"enabled": false},
-
-		// Inject Credentials & Networking
-		"wordpressPassword": creds["wordpress-password"],
-		"ingress": map[string]interface{}{
-			"enabled":          true,
-			"ingressClassName": "nginx",
-			"hostname":         fmt.Sprintf("%s.%s", store.Name, baseDomain),
-		},
-		"mariadb": map[string]interface{}{
-			"auth": map[string]interface{}{
-				"rootPassword": creds["mariadb-root-password"],
-				"password":     creds["mariadb-user-password"],
-			},
-			"primary": map[string]interface{}{
-				"persistence": map[string]interface{}{
-					"enabled": false,
-				},
-			},
-		},
-
-		// 1. Configure Persistence from Config
-		"persistence": map[string]interface{}{
-			"enabled": r.Config.PersistenceEnabled,
-		},
-
-		// 2. Configure Probes from Config
-		"livenessProbe": map[string]interface{}{
-			"initialDelaySeconds": r.Config.LivenessProbeInitialDelay,
-			"periodSeconds":       r.Config.LivenessProbePeriod,
-		},
-		"readinessProbe": map[string]interface{}{
-			"initialDelaySeconds": r.Config.ReadinessProbeInitialDelay,
-			"periodSeconds":       r.Config.ReadinessProbePeriod,
-		},
-	}
+	values := provider.Values(engine.RenderInput{
+		Store:       &store,
+		Plan:        renderPlan,
+		Hostname:    hostname,
+		Credentials: creds,
+		Config:      r.Config,
+		Labels:      workloadLabels(&store),
+	})
 
 	// F. Install/Upgrade Helm
 	if store.Status.Phase == "" {
 		store.Status.Phase = PhaseProvisioning
 		store.Status.Message = "Started provisioning store"
 		store.Status.Reason = ReasonProvisioning
-		if err := r.Status().Update(ctx, &store); err != nil {
+		if err := r.updateStatus(ctx, &store); err != nil {
 			logger.Error(err, "unable to update Store status")
 			return ctrl.Result{}, err
 		}
@@ -209,67 +399,192 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 
 	provisionStart := store.CreationTimestamp.Time
 
-	// CHECK IDEMPOTENCY: Only run Helm if Spec changed or not ready
-	if store.Generation != store.Status.ObservedGeneration || store.Status.Phase != PhaseReady {
-		if err := helm.InstallOrUpgrade(ctx, ctrl.GetConfigOrDie(), releaseName, nsName, chartPath, values); err != nil {
-			logger.Error(err, "Helm install failed")
-			store.Status.Phase = PhaseFailed
-			store.Status.Message = fmt.Sprintf("Helm install failed: %v", err)
-			store.Status.Reason = ReasonHelmError
-			if err := r.Status().Update(ctx, &store); err != nil {
+	// CHECK IDEMPOTENCY: Only run Helm if Spec or Plan changed or not settled
+	settled := store.Generation == store.Status.ObservedGeneration &&
+		plan.Name == store.Status.AppliedPlan &&
+		plan.Generation == store.Status.ObservedPlanGeneration &&
+		!rotated &&
+		isServing(store.Status.Phase)
+
+	// A settled store is compared against its deployed release on every resync;
+	// anything changed behind the operator's back is repaired by an upgrade
+	var drift []string
+	if settled {
+		drift, err = r.detectDrift(ctx, releaseName, nsName, chart, values)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if len(drift) > 0 {
+			logger.Info("Helm release drifted", "release", releaseName, "drift", drift)
+			setDriftCondition(&store, metav1.ConditionTrue, ConditionReasonDriftDetected, drift)
+			if err := r.updateStatus(ctx, &store); err != nil {
 				logger.Error(err, "unable to update Store status")
 				return ctrl.Result{}, err
 			}
+			r.Recorder.Eventf(&store, corev1.EventTypeWarning, EventReasonDrifted, "Release drifted: %s", strings.Join(drift, "; "))
+		} else {
+			conditionsChanged = setDriftCondition(&store, metav1.ConditionFalse, ConditionReasonInSync, nil) || conditionsChanged
+		}
+		// Stores settled before conditions existed report their release once
+		if meta.FindStatusCondition(store.Status.Conditions, ConditionReleaseInstalled) == nil {
+			conditionsChanged = setCondition(&store, ConditionReleaseInstalled, metav1.ConditionTrue,
+				ConditionReasonInstalled, releaseInstalledMessage(&store)) || conditionsChanged
+		}
+	}
 
-			r.Recorder.Eventf(&store, corev1.EventTypeWarning, EventReasonFailed, "Installation failed: %v", err)
-			return ctrl.Result{RequeueAfter: r.Config.HelmFailureRetryInterval}, nil
+	// Workload events only re-run Helm for a release that was never installed
+	// or a Failed store; a store waiting for its pods keeps its revision
+	needsApply := store.Generation != store.Status.ObservedGeneration ||
+		plan.Name != store.Status.AppliedPlan ||
+		plan.Generation != store.Status.ObservedPlanGeneration ||
+		rotated || len(drift) > 0 ||
+		store.Status.LastAppliedRevision == 0 || store.Status.Phase == PhaseFailed
+	helmApplied := false
+	if needsApply && scaleBlocked {
+		logger.V(1).Info("Replicas don't fit the content volume, keeping the deployed release")
+	} else if needsApply && r.retriesExhausted(&store) {
+		// Serving stores keep their last good revision until retry-now is set
+		logger.V(1).Info("Store has no retries left, skipping Helm", "failureCount", store.Status.FailureCount)
+	} else if needsApply {
+		// Status updates and workload events re-trigger failed stores early;
+		// hold the next attempt until their backoff has passed
+		if wait := r.backoffRemaining(&store, time.Now()); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+		if result, done, err := r.applyRelease(ctx, &store, releaseName, nsName, chart, values); !done {
+			return result, err
 		}
 		// Update ObservedGeneration after successful Helm run
 		store.Status.ObservedGeneration = store.Generation
+		store.Status.ObservedPlanGeneration = plan.Generation
+		store.Status.AppliedPlan = plan.Name
+		helmApplied = true
+		if len(drift) > 0 {
+			setDriftCondition(&store, metav1.ConditionFalse, ConditionReasonRepaired, drift)
+			r.Recorder.Eventf(&store, corev1.EventTypeNormal, EventReasonRepaired, "Repaired release drift: %s", strings.Join(drift, "; "))
+		}
 		// We don't update status here yet, we wait until final success to save API calls
 	}
 
-	// G. Verify Readiness (Check if Pod is Ready)
-	// We use the Kubernetes API instead of HTTP probing because probing internal
-	// cluster IPs from a local operator (outside the cluster) is flaky/impossible.
-	if !r.isPodReady(ctx, nsName) {
+	// G. Suspended stores stop here with their data intact
+	if store.Spec.Suspended {
+		setCondition(&store, ConditionWorkloadReady, metav1.ConditionFalse, ReasonSuspended, "Workloads are scaled to zero")
+		return r.reconcileSuspended(ctx, &store, nsName, helmApplied || conditionsChanged)
+	}
+	if store.Status.Phase == PhaseSuspended {
+		logger.Info("Resuming Store", "namespace", nsName)
+		store.Status.Phase = PhaseProvisioning
+		store.Status.Reason = ReasonResuming
+		store.Status.Message = "Resuming store"
+		r.Recorder.Eventf(&store, corev1.EventTypeNormal, EventReasonResumed, "Resuming store %s", store.Name)
+	}
+
+	// H. Verify Readiness (Check if Pod is Ready)
+	// Provisioning only needs one ready pod; serving stores get the fuller
+	// health check below. Pod and Deployment watches bring us back as soon as
+	// readiness changes; the requeue is only a safety net for missed events.
+	serving := isServing(store.Status.Phase)
+	if !serving && !r.isPodReady(ctx, nsName, provider.ReadinessLabels()) {
 		logger.Info("Waiting for Pods to be Ready...", "namespace", nsName)
 		store.Status.Message = "Waiting for pods to become ready..."
 		store.Status.Reason = ReasonWaitingForPods
-		if err := r.Status().Update(ctx, &store); err != nil {
+		setCondition(&store, ConditionWorkloadReady, metav1.ConditionFalse, ReasonWaitingForPods, "No ready pod matches the engine's readiness labels")
+		if err := r.updateStatus(ctx, &store); err != nil {
 			logger.Error(err, "unable to update Store status")
 			return ctrl.Result{}, err
 		}
 
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: r.Config.PodReadinessCheckInterval}, nil
+	}
+
+	if serving {
+		// Ready and Degraded stores move between the two on every health check
+		healthChanged, err := r.reconcileHealth(ctx, &store, nsName, releaseName, provider)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		conditionsChanged = healthChanged || conditionsChanged
+	} else {
+		conditionsChanged = setCondition(&store, ConditionWorkloadReady, metav1.ConditionTrue,
+			ConditionReasonPodsReady, "The engine's pods are ready") || conditionsChanged
 	}
 
-	// H. Success!
-	if store.Status.Phase != PhaseReady {
+	// I. Restore from a backup before the store is declared Ready
+	if store.Spec.RestoreFrom != nil {
+		if result, done, err := r.reconcileRestore(ctx, &store, nsName, provider); !done {
+			return result, err
+		}
+	}
+	// Or copy another store's data
+	if store.Spec.CloneFrom != nil {
+		if result, done, err := r.reconcileClone(ctx, &store, nsName, provider); !done {
+			return result, err
+		}
+	}
+
+	// J. Success!
+	if !serving {
+		// Only the first Ready transition measures provisioning; resumes keep their URL
+		firstReady := store.Status.URL == ""
 		store.Status.Phase = PhaseReady
-		storeURL := fmt.Sprintf("http://%s.%s", store.Name, baseDomain)
+		store.Status.LastSuccessfulRevision = store.Status.LastAppliedRevision
+		storeURL := r.storeURL(ctx, &store, hostname)
 		store.Status.URL = storeURL
 		store.Status.Message = ""
 		store.Status.Reason = ""
-		if err := r.Status().Update(ctx, &store); err != nil {
+		if err := r.updateStatus(ctx, &store); err != nil {
 			logger.Error(err, "unable to update Store status")
 			return ctrl.Result{}, err
 		}
 
 		r.Recorder.Eventf(&store, corev1.EventTypeNormal, EventReasonReady, "Store is ready at URL %s", storeURL)
-		storeProvisioningSeconds.Observe(time.Since(provisionStart).Seconds())
+		if firstReady {
+			storeProvisioningSeconds.WithLabelValues(plan.Name).Observe(time.Since(provisionStart).Seconds())
+		}
+	} else if helmApplied || conditionsChanged {
+		if helmApplied {
+			// Already Ready: the upgrade waited for the new revision to become ready
+			store.Status.LastSuccessfulRevision = store.Status.LastAppliedRevision
+			if store.Status.Reason == ReasonRolledBack || store.Status.Reason == ReasonHelmError {
+				store.Status.Reason = ""
+				store.Status.Message = ""
+			}
+			// The upgrade may have switched routing modes or Gateways
+			store.Status.URL = r.storeURL(ctx, &store, hostname)
+		}
+		// Persist the generations the upgrade was applied for, and any new conditions
+		if err := r.updateStatus(ctx, &store); err != nil {
+			logger.Error(err, "unable to update Store status")
+			return ctrl.Result{}, err
+		}
 	}
 
-	return ctrl.Result{}, nil
+	// Come back for the next health check, usage sample or drift check, or
+	// sooner if a rotation is due or a blocked plan change needs its usage
+	// checked again
+	requeue := r.Config.DriftCheckInterval
+	if r.Config.HealthCheckInterval > 0 && (requeue == 0 || r.Config.HealthCheckInterval < requeue) {
+		requeue = r.Config.HealthCheckInterval
+	}
+	if wait, ok := rotationWait(&store, time.Now()); ok && wait > 0 && (requeue == 0 || wait < requeue) {
+		requeue = wait
+	}
+	if r.Config.UsageSampleInterval > 0 && (requeue == 0 || r.Config.UsageSampleInterval < requeue) {
+		requeue = r.Config.UsageSampleInterval
+	}
+	if meta.IsStatusConditionTrue(store.Status.Conditions, ConditionPlanChangeBlocked) &&
+		(requeue == 0 || r.Config.PlanChangeRecheckInterval < requeue) {
+		requeue = r.Config.PlanChangeRecheckInterval
+	}
+	return ctrl.Result{RequeueAfter: requeue}, nil
 }
 
-// isPodReady checks if there is at least one running and ready Pod for the WordPress app
-func (r *StoreReconciler) isPodReady(ctx context.Context, namespace string) bool {
+// isPodReady checks if there is at least one running and ready Pod matching the engine's labels
+func (r *StoreReconciler) isPodReady(ctx context.Context, namespace string, selector map[string]string) bool {
 	var podList corev1.PodList
-	// Bitnami WordPress charts use this label by default
 	opts := []client.ListOption{
 		client.InNamespace(namespace),
-		client.MatchingLabels{"app.kubernetes.io/name": "wordpress"},
+		client.MatchingLabels(selector),
 	}
 
 	if err := r.List(ctx, &podList, opts...); err != nil {
@@ -284,10 +599,8 @@ func (r *StoreReconciler) isPodReady(ctx context.Context, namespace string) bool
 		// Check if Pod is Running
 		if pod.Status.Phase == corev1.PodRunning {
 			// Check if it's Ready
-			for _, cond := range pod.Status.Conditions {
-				if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
-					return true
-				}
+			if isPodReadyCondition(&pod) {
+				return true
 			}
 		}
 	}
@@ -314,24 +627,55 @@ func removeString(slice []string, s string) []string {
 }
 
 func (r *StoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Index Stores by plan so a StorePlan change re-reconciles every Store on it
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &infrav1alpha1.Store{}, storePlanIndexKey,
+		func(obj client.Object) []string {
+			return []string{obj.(*infrav1alpha1.Store).Spec.Plan}
+		}); err != nil {
+		return err
+	}
+
+	// Count stores by phase, plan and engine from the cache at scrape time
+	if err := metrics.Registry.Register(newStoreCollector(mgr.GetClient())); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&infrav1alpha1.Store{}).
+		Watches(&infrav1alpha1.StorePlan{}, handler.EnqueueRequestsFromMapFunc(r.storesForPlan)).
+		Watches(&batchv1.Job{}, handler.EnqueueRequestsFromMapFunc(storeForJob)).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.storeForWorkload),
+			builder.WithPredicates(inStoreNamespace, podReadinessChanged)).
+		Watches(&appsv1.Deployment{}, handler.EnqueueRequestsFromMapFunc(r.storeForWorkload),
+			builder.WithPredicates(inStoreNamespace, deploymentAvailabilityChanged)).
 		Named("store").
 		Complete(r)
 }
 
 // Generate a random secure password
-func generatePassword(length int) string {
+func generatePassword(length int) (string, error) {
 	b := make([]byte, length)
-	_, err := rand.Read(b)
-	if err != nil {
-		return "default-secure-password-change-me" // Fallback (should rarely happen)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating password: %w", err)
+	}
+	return base64.URLEncoding.EncodeToString(b)[:length], nil
+}
+
+// generateCredentials returns a fresh password for every key the engine needs
+func generateCredentials(provider engine.Provider) (map[string]string, error) {
+	creds := make(map[string]string)
+	for _, key := range provider.CredentialKeys() {
+		password, err := generatePassword(key.Length)
+		if err != nil {
+			return nil, err
+		}
+		creds[key.Name] = password
 	}
-	return base64.URLEncoding.EncodeToString(b)[:length]
+	return creds, nil
 }
 
-// ReconcileCredentials ensures a secret exists with stable passwords
-func (r *StoreReconciler) ReconcileCredentials(ctx context.Context, store *infrav1alpha1.Store) (map[string]string, error) {
+// ReconcileCredentials ensures a secret exists with stable passwords for every key the engine needs
+func (r *StoreReconciler) ReconcileCredentials(ctx context.Context, store *infrav1alpha1.Store, provider engine.Provider) (map[string]string, error) {
 	secretName := store.Name + "-creds"
 	secret := &corev1.Secret{}
 
@@ -342,10 +686,9 @@ func (r *StoreReconciler) ReconcileCredentials(ctx context.Context, store *infra
 		// 2. Secret doesn't exist? Create it!
 		log.Log.Info("Generating new credentials for store", "store", store.Name)
 
-		creds := map[string]string{
-			"mariadb-root-password": generatePassword(MariaDBRootPasswordLength),
-			"mariadb-user-password": generatePassword(MariaDBUserPasswordLength),
-			"wordpress-password":    generatePassword(WordPressPasswordLength),
+		creds, err := generateCredentials(provider)
+		if err != nil {
+			return nil, err
 		}
 
 		secret = &corev1.Secret{
@@ -374,5 +717,27 @@ func (r *StoreReconciler) ReconcileCredentials(ctx context.Context, store *infra
 	for k, v := range secret.Data {
 		existingCreds[k] = string(v)
 	}
+
+	// 4. Backfill keys the engine needs but the secret predates
+	missing := false
+	for _, key := range provider.CredentialKeys() {
+		if _, ok := existingCreds[key.Name]; !ok {
+			if secret.Data == nil {
+				secret.Data = make(map[string][]byte)
+			}
+			password, err := generatePassword(key.Length)
+			if err != nil {
+				return nil, err
+			}
+			existingCreds[key.Name] = password
+			secret.Data[key.Name] = []byte(password)
+			missing = true
+		}
+	}
+	if missing {
+		if err := r.Update(ctx, secret); err != nil {
+			return nil, err
+		}
+	}
 	return existingCreds, nil
 }
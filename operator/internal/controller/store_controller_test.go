@@ -18,21 +18,58 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
 	"github.com/Jovial-Kanwadia/store-operator/internal/config"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+	"github.com/Jovial-Kanwadia/store-operator/internal/helm"
 )
 
 var _ = Describe("Store Controller", func() {
+	// newReconciler builds the StoreReconciler the tests drive; releases is
+	// nil for tests that never reach Helm
+	newReconciler := func(releases helm.ReleaseManager) *StoreReconciler {
+		return &StoreReconciler{
+			Client:   k8sClient,
+			Scheme:   k8sClient.Scheme(),
+			Recorder: record.NewFakeRecorder(100),
+			Config:   config.Load(),
+			Releases: releases,
+		}
+	}
+
+	// reconcileStore runs one reconcile of the Store at key and fails the test on error
+	reconcileStore := func(reconciler *StoreReconciler, key types.NamespacedName) reconcile.Result {
+		result, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
 	Context("When reconciling a resource", func() {
 		const resourceName = "test-resource"
 
@@ -45,15 +82,43 @@ var _ = Describe("Store Controller", func() {
 		store := &infrav1alpha1.Store{}
 
 		BeforeEach(func() {
+			By("creating the StorePlan the Store references")
+			plan := &infrav1alpha1.StorePlan{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "small"}, plan)
+			if err != nil && errors.IsNotFound(err) {
+				plan = &infrav1alpha1.StorePlan{
+					ObjectMeta: metav1.ObjectMeta{Name: "small"},
+					Spec: infrav1alpha1.StorePlanSpec{
+						Quota: infrav1alpha1.PlanQuota{
+							RequestsCPU:    resource.MustParse("500m"),
+							RequestsMemory: resource.MustParse("512Mi"),
+							LimitsCPU:      resource.MustParse("1"),
+							LimitsMemory:   resource.MustParse("1Gi"),
+							MaxPods:        10,
+						},
+						LimitRange: infrav1alpha1.PlanLimitRange{
+							DefaultCPU:           resource.MustParse("200m"),
+							DefaultMemory:        resource.MustParse("256Mi"),
+							DefaultRequestCPU:    resource.MustParse("50m"),
+							DefaultRequestMemory: resource.MustParse("128Mi"),
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, plan)).To(Succeed())
+			}
+
 			By("creating the custom resource for the Kind Store")
-			err := k8sClient.Get(ctx, typeNamespacedName, store)
+			err = k8sClient.Get(ctx, typeNamespacedName, store)
 			if err != nil && errors.IsNotFound(err) {
 				resource := &infrav1alpha1.Store{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      resourceName,
 						Namespace: "default",
 					},
-					// TODO(user): Specify other spec details if needed.
+					Spec: infrav1alpha1.StoreSpec{
+						Engine: "woo",
+						Plan:   "small",
+					},
 				}
 				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 			}
@@ -70,19 +135,2006 @@ var _ = Describe("Store Controller", func() {
 		})
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
-			controllerReconciler := &StoreReconciler{
-				Client:   k8sClient,
-				Scheme:   k8sClient.Scheme(),
-				Recorder: record.NewFakeRecorder(10),
-				Config:   config.Load(),
+			controllerReconciler := newReconciler(helm.NewFakeReleaseManager())
+
+			reconcileStore(controllerReconciler, typeNamespacedName)
+			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
+			// Example: If you expect a certain status condition after reconciliation, verify it here.
+		})
+
+		It("should fail a Store whose StorePlan does not exist", func() {
+			missingPlanName := types.NamespacedName{Name: "test-missing-plan", Namespace: "default"}
+
+			By("creating a Store on an unknown plan")
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      missingPlanName.Name,
+					Namespace: missingPlanName.Namespace,
+				},
+				Spec: infrav1alpha1.StoreSpec{
+					Engine: "woo",
+					Plan:   "xlarge",
+				},
+			})).To(Succeed())
+
+			controllerReconciler := newReconciler(helm.NewFakeReleaseManager())
+
+			By("reconciling past namespace creation")
+			for i := 0; i < 2; i++ {
+				reconcileStore(controllerReconciler, missingPlanName)
+			}
+
+			resource := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, missingPlanName, resource)).To(Succeed())
+			Expect(resource.Status.Phase).To(Equal(PhaseFailed))
+			Expect(resource.Status.Reason).To(Equal(ReasonPlanNotFound))
+
+			By("removing the finalizer and deleting the Store")
+			resource.Finalizers = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+		It("should fail a medusa Store and leave medusa unadvertised until MEDUSA_IMAGE is set", func() {
+			key := types.NamespacedName{Name: "test-medusa-unconfigured", Namespace: "default"}
+			cfg := config.Load()
+			cfg.MedusaImage = ""
+			Expect(engine.Available(cfg)).NotTo(ContainElement(engine.EngineMedusa))
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineMedusa, Plan: "small"},
+			})).To(Succeed())
+			controllerReconciler := newReconciler(helm.NewFakeReleaseManager())
+			controllerReconciler.Config = cfg
+			for i := 0; i < 2; i++ {
+				reconcileStore(controllerReconciler, key)
+			}
+
+			resource := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, resource)).To(Succeed())
+			Expect(resource.Status.Phase).To(Equal(PhaseFailed))
+			Expect(resource.Status.Reason).To(Equal(ReasonUnknownEngine))
+			Expect(resource.Status.Message).To(ContainSubstring("MEDUSA_IMAGE"))
+
+			cfg.MedusaImage = "registry.example.com/medusa-store:2.0"
+			Expect(engine.Available(cfg)).To(ContainElement(engine.EngineMedusa))
+
+			resource.Finalizers = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+	})
+
+	Context("When restoring from a backup", func() {
+		ctx := context.Background()
+
+		// restoreTarget creates a store with credentials that restores from backupName
+		restoreTarget := func(name, backupName string) *infrav1alpha1.Store {
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: StoreNamespacePrefix + name},
+			})).To(Succeed())
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: name + "-creds", Namespace: "default"},
+				StringData: map[string]string{engine.SecretKeyMariaDBRoot: "new-root-pw"},
+			})).To(Succeed())
+			store := &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: infrav1alpha1.StoreSpec{
+					Engine:      "woo",
+					Plan:        "small",
+					RestoreFrom: &infrav1alpha1.RestoreSource{BackupName: backupName},
+				},
+			}
+			Expect(k8sClient.Create(ctx, store)).To(Succeed())
+			return store
+		}
+
+		It("should fail with RestoreFailed when the backup does not exist", func() {
+			store := restoreTarget("restore-missing", "does-not-exist")
+			provider, err := engine.Get(store.Spec.Engine)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, done, err := newReconciler(nil).reconcileRestore(ctx, store, StoreNamespacePrefix+store.Name, provider)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeFalse())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: store.Name, Namespace: "default"}, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(PhaseFailed))
+			Expect(store.Status.Reason).To(Equal(ReasonRestoreFailed))
+			Expect(store.Status.Restore.Phase).To(Equal(RestorePhaseFailed))
+		})
+
+		It("should hold the store until the restore Job completes", func() {
+			store := restoreTarget("restore-dst", "snap")
+			nsName := StoreNamespacePrefix + store.Name
+			provider, err := engine.Get(store.Spec.Engine)
+			Expect(err).NotTo(HaveOccurred())
+			reconciler := newReconciler(nil)
+
+			By("creating an S3 backup of another store that is still running")
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "restore-s3", Namespace: "default"},
+				StringData: map[string]string{
+					jobSecretKeyAWSAccessKey: "access",
+					jobSecretKeyAWSSecretKey: "secret",
+				},
+			})).To(Succeed())
+			backup := &infrav1alpha1.StoreBackup{
+				ObjectMeta: metav1.ObjectMeta{Name: "snap", Namespace: "default"},
+				Spec: infrav1alpha1.StoreBackupSpec{
+					StoreName: "restore-src",
+					Target: infrav1alpha1.BackupTarget{S3: &infrav1alpha1.S3BackupTarget{
+						Endpoint:          "http://minio:9000",
+						Bucket:            "backups",
+						CredentialsSecret: "restore-s3",
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+			backup.Status.Phase = BackupPhaseRunning
+			Expect(k8sClient.Status().Update(ctx, backup)).To(Succeed())
+
+			_, done, err := reconciler.reconcileRestore(ctx, store, nsName, provider)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeFalse())
+			Expect(store.Status.Reason).To(Equal(ReasonWaitingBackup))
+
+			By("completing the backup")
+			backup.Status.Phase = BackupPhaseCompleted
+			backup.Status.Checksum = "sha256:deadbeef"
+			Expect(k8sClient.Status().Update(ctx, backup)).To(Succeed())
+
+			_, done, err = reconciler.reconcileRestore(ctx, store, nsName, provider)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeFalse())
+			Expect(store.Status.Phase).To(Equal(PhaseProvisioning))
+			Expect(store.Status.Reason).To(Equal(ReasonRestoring))
+			Expect(store.Status.Restore.Phase).To(Equal(RestorePhaseRunning))
+
+			By("checking the restore Job and its credentials")
+			job := &batchv1.Job{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "restore-snap", Namespace: nsName}, job)).To(Succeed())
+			var steps []string
+			for _, c := range job.Spec.Template.Spec.InitContainers {
+				steps = append(steps, c.Name)
+			}
+			Expect(steps).To(Equal([]string{RestoreStepFetch, RestoreStepUnpack, RestoreStepRestoreDatabase}))
+			Expect(job.Spec.Template.Spec.Containers[0].Name).To(Equal(RestoreStepRestoreContent))
+			Expect(job.Spec.Template.Spec.InitContainers[0].Command[2]).To(ContainSubstring("sha256sum -c"))
+			Expect(job.Labels[LabelStoreName]).To(Equal(store.Name))
+
+			jobSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "restore-snap", Namespace: nsName}, jobSecret)).To(Succeed())
+			Expect(string(jobSecret.Data[jobSecretKeyDBPassword])).To(Equal("new-root-pw"))
+			Expect(string(jobSecret.Data[jobSecretKeyAWSAccessKey])).To(Equal("access"))
+
+			By("completing the restore Job")
+			now := metav1.Now()
+			job.Status.StartTime = &now
+			job.Status.CompletionTime = &now
+			job.Status.Succeeded = 1
+			job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+			Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+
+			_, done, err = reconciler.reconcileRestore(ctx, store, nsName, provider)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeTrue())
+			Expect(store.Status.Restore.Phase).To(Equal(RestorePhaseCompleted))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "restore-snap", Namespace: nsName}, jobSecret)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("When cloning a store", func() {
+		ctx := context.Background()
+
+		It("should hold the clone until the clone Job completes", func() {
+			reconciler := newReconciler(nil)
+			provider, err := engine.Get("woo")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating a source store that is still provisioning and its clone")
+			for _, name := range []string{"clone-src", "clone-dst"} {
+				Expect(k8sClient.Create(ctx, &corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{Name: StoreNamespacePrefix + name},
+				})).To(Succeed())
+				Expect(k8sClient.Create(ctx, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: name + "-creds", Namespace: "default"},
+					StringData: map[string]string{
+						engine.SecretKeyMariaDBRoot: name + "-root-pw",
+						engine.SecretKeyMariaDBUser: name + "-user-pw",
+						engine.SecretKeyWordPress:   name + "-admin-pw",
+					},
+				})).To(Succeed())
+			}
+			source := &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: "clone-src", Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: "woo", Plan: "small"},
+			}
+			Expect(k8sClient.Create(ctx, source)).To(Succeed())
+			store := &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: "clone-dst", Namespace: "default"},
+				Spec: infrav1alpha1.StoreSpec{
+					Engine:    "woo",
+					Plan:      "small",
+					CloneFrom: &infrav1alpha1.CloneSource{StoreName: source.Name},
+					Site:      &infrav1alpha1.SiteSpec{AdminUsername: "staging"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, store)).To(Succeed())
+			nsName := StoreNamespacePrefix + store.Name
+			sourceNamespace := StoreNamespacePrefix + source.Name
+
+			_, done, err := reconciler.reconcileClone(ctx, store, nsName, provider)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeFalse())
+			Expect(store.Status.Reason).To(Equal(ReasonWaitingSource))
+
+			By("marking the source Ready")
+			source.Status.Phase = PhaseReady
+			Expect(k8sClient.Status().Update(ctx, source)).To(Succeed())
+
+			_, done, err = reconciler.reconcileClone(ctx, store, nsName, provider)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeFalse())
+			Expect(store.Status.Phase).To(Equal(PhaseProvisioning))
+			Expect(store.Status.Reason).To(Equal(ReasonCloning))
+			Expect(store.Status.Clone.Phase).To(Equal(ClonePhaseRunning))
+
+			By("checking the clone Job, its credentials and its access to the source")
+			job := &batchv1.Job{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "clone-clone-dst", Namespace: nsName}, job)).To(Succeed())
+			var steps []string
+			for _, c := range job.Spec.Template.Spec.InitContainers {
+				steps = append(steps, c.Name)
+			}
+			// The store has no content volume, so only the database is copied
+			Expect(steps).To(Equal([]string{CloneStepCopyDatabase, RestoreStepRestoreDatabase}))
+			Expect(job.Spec.Template.Spec.InitContainers[0].Command[2]).To(ContainSubstring(sourceNamespace))
+			Expect(job.Spec.Template.Spec.ServiceAccountName).To(Equal("clone-clone-dst"))
+			rewrite := job.Spec.Template.Spec.Containers[0]
+			Expect(rewrite.Name).To(Equal(CloneStepRewriteURLs))
+			Expect(rewrite.Env).To(ContainElements(
+				corev1.EnvVar{Name: "SOURCE_HOST", Value: "clone-src." + reconciler.Config.BaseDomain},
+				corev1.EnvVar{Name: "TARGET_HOST", Value: "clone-dst." + reconciler.Config.BaseDomain},
+				secretEnv("NEW_WORDPRESS_PASSWORD", "clone-clone-dst", "NEW_WORDPRESS_PASSWORD"),
+			))
+			// The copied admin row still has the source's login and password
+			Expect(rewrite.Command[2]).To(ContainSubstring(
+				"UPDATE wp_users SET user_pass = MD5('$NEW_WORDPRESS_PASSWORD') WHERE user_login IN ('" +
+					engine.WordPressUsername + "', 'staging');"))
+
+			jobSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "clone-clone-dst", Namespace: nsName}, jobSecret)).To(Succeed())
+			Expect(string(jobSecret.Data[jobSecretKeyDBPassword])).To(Equal("clone-dst-root-pw"))
+			Expect(string(jobSecret.Data[jobSecretKeySourceDBPassword])).To(Equal("clone-src-root-pw"))
+			Expect(string(jobSecret.Data["NEW_WORDPRESS_PASSWORD"])).To(Equal("clone-dst-admin-pw"))
+
+			binding := &rbacv1.RoleBinding{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "clone-clone-dst", Namespace: sourceNamespace}, binding)).To(Succeed())
+			Expect(binding.Subjects).To(ConsistOf(rbacv1.Subject{
+				Kind: rbacv1.ServiceAccountKind, Name: "clone-clone-dst", Namespace: nsName,
+			}))
+
+			By("completing the clone Job")
+			now := metav1.Now()
+			job.Status.StartTime = &now
+			job.Status.CompletionTime = &now
+			job.Status.Succeeded = 1
+			job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+			Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+
+			_, done, err = reconciler.reconcileClone(ctx, store, nsName, provider)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeTrue())
+			Expect(store.Status.Clone.Phase).To(Equal(ClonePhaseCompleted))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "clone-clone-dst", Namespace: nsName}, jobSecret)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "clone-clone-dst", Namespace: sourceNamespace}, &rbacv1.Role{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("When suspending a store", func() {
+		ctx := context.Background()
+
+		It("should scale every workload to zero and report Suspended", func() {
+			const storeName = "suspend-me"
+			nsName := StoreNamespacePrefix + storeName
+			one := int32(1)
+			labels := map[string]string{"app": storeName}
+			podTemplate := corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "busybox"}}},
+			}
+
+			By("creating a store with a Deployment and a StatefulSet")
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}})).To(Succeed())
+			Expect(k8sClient.Create(ctx, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "wordpress", Namespace: nsName},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: &one,
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: podTemplate,
+				},
+			})).To(Succeed())
+			Expect(k8sClient.Create(ctx, &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "mariadb", Namespace: nsName},
+				Spec: appsv1.StatefulSetSpec{
+					Replicas: &one,
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: podTemplate,
+				},
+			})).To(Succeed())
+			store := &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: "woo", Plan: "small", Suspended: true},
+			}
+			Expect(k8sClient.Create(ctx, store)).To(Succeed())
+
+			reconciler := newReconciler(nil)
+			_, err := reconciler.reconcileSuspended(ctx, store, nsName, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: storeName, Namespace: "default"}, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(PhaseSuspended))
+			Expect(store.Status.Reason).To(Equal(ReasonSuspended))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "wordpress", Namespace: nsName}, deployment)).To(Succeed())
+			Expect(*deployment.Spec.Replicas).To(BeZero())
+			statefulSet := &appsv1.StatefulSet{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "mariadb", Namespace: nsName}, statefulSet)).To(Succeed())
+			Expect(*statefulSet.Spec.Replicas).To(BeZero())
+		})
+	})
+
+	Context("When rotating credentials", func() {
+		ctx := context.Background()
+
+		It("should only replace the stored passwords after the rotation Job succeeds", func() {
+			const storeName = "rotate-me"
+			nsName := StoreNamespacePrefix + storeName
+			storeKey := types.NamespacedName{Name: storeName, Namespace: "default"}
+			credsKey := types.NamespacedName{Name: storeName + "-creds", Namespace: "default"}
+
+			By("creating a Ready store that asks for rotation")
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}})).To(Succeed())
+			store := &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        storeName,
+					Namespace:   "default",
+					Annotations: map[string]string{AnnotationRotateCredentials: "true"},
+				},
+				Spec: infrav1alpha1.StoreSpec{Engine: "woo", Plan: "small"},
+			}
+			Expect(k8sClient.Create(ctx, store)).To(Succeed())
+			store.Status.Phase = PhaseReady
+			Expect(k8sClient.Status().Update(ctx, store)).To(Succeed())
+
+			provider, err := engine.Get(store.Spec.Engine)
+			Expect(err).NotTo(HaveOccurred())
+			reconciler := newReconciler(nil)
+			creds, err := reconciler.ReconcileCredentials(ctx, store, provider)
+			Expect(err).NotTo(HaveOccurred())
+			oldRoot := creds[engine.SecretKeyMariaDBRoot]
+
+			_, done, rotated, err := reconciler.reconcileRotation(ctx, store, nsName, provider, creds)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeFalse())
+			Expect(rotated).To(BeFalse())
+			Expect(store.Status.Reason).To(Equal(ReasonRotating))
+
+			By("checking the Job gets old and new passwords while -creds is untouched")
+			jobSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "rotate-" + storeName, Namespace: nsName}, jobSecret)).To(Succeed())
+			Expect(string(jobSecret.Data["OLD_MARIADB_ROOT_PASSWORD"])).To(Equal(oldRoot))
+			newRoot := string(jobSecret.Data["NEW_MARIADB_ROOT_PASSWORD"])
+			Expect(newRoot).NotTo(BeEmpty())
+			Expect(newRoot).NotTo(Equal(oldRoot))
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, credsKey, secret)).To(Succeed())
+			Expect(string(secret.Data[engine.SecretKeyMariaDBRoot])).To(Equal(oldRoot))
+
+			By("completing the rotation Job")
+			job := &batchv1.Job{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "rotate-" + storeName, Namespace: nsName}, job)).To(Succeed())
+			now := metav1.Now()
+			job.Status.StartTime = &now
+			job.Status.CompletionTime = &now
+			job.Status.Succeeded = 1
+			job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+			Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+
+			_, done, rotated, err = reconciler.reconcileRotation(ctx, store, nsName, provider, creds)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeTrue())
+			Expect(rotated).To(BeTrue())
+			Expect(creds[engine.SecretKeyMariaDBRoot]).To(Equal(newRoot))
+
+			Expect(k8sClient.Get(ctx, credsKey, secret)).To(Succeed())
+			Expect(string(secret.Data[engine.SecretKeyMariaDBRoot])).To(Equal(newRoot))
+
+			Expect(k8sClient.Get(ctx, storeKey, store)).To(Succeed())
+			Expect(store.Status.CredentialsRotatedAt).NotTo(BeNil())
+			Expect(store.Annotations).NotTo(HaveKey(AnnotationRotateCredentials))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "rotate-" + storeName, Namespace: nsName}, jobSecret)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should stop re-running a failing rotation Job once the failure budget is spent", func() {
+			const storeName = "rotate-fails"
+			nsName := StoreNamespacePrefix + storeName
+			storeKey := types.NamespacedName{Name: storeName, Namespace: "default"}
+			jobKey := types.NamespacedName{Name: "rotate-" + storeName, Namespace: nsName}
+
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}})).To(Succeed())
+			store := &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        storeName,
+					Namespace:   "default",
+					Annotations: map[string]string{AnnotationRotateCredentials: "true"},
+				},
+				Spec: infrav1alpha1.StoreSpec{Engine: "woo", Plan: "small"},
+			}
+			Expect(k8sClient.Create(ctx, store)).To(Succeed())
+			store.Status.Phase = PhaseReady
+			Expect(k8sClient.Status().Update(ctx, store)).To(Succeed())
+
+			provider, err := engine.Get(store.Spec.Engine)
+			Expect(err).NotTo(HaveOccurred())
+			cfg := config.Load()
+			cfg.MaxFailedAttempts = 2
+			cfg.HelmFailureRetryInterval = 0
+			reconciler := newReconciler(nil)
+			reconciler.Config = cfg
+			creds, err := reconciler.ReconcileCredentials(ctx, store, provider)
+			Expect(err).NotTo(HaveOccurred())
+			failJob := func() {
+				job := &batchv1.Job{}
+				Expect(k8sClient.Get(ctx, jobKey, job)).To(Succeed())
+				now := metav1.Now()
+				job.Status.StartTime = &now
+				job.Status.Failed = 1
+				job.Status.Conditions = []batchv1.JobCondition{
+					{Type: batchv1.JobFailureTarget, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"},
+					{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "access denied"},
+				}
+				Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+			}
+			rotate := func() bool {
+				_, done, rotated, err := reconciler.reconcileRotation(ctx, store, nsName, provider, creds)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rotated).To(BeFalse())
+				return done
+			}
+
+			By("counting each failed Job against the store's budget")
+			for attempt := 1; attempt <= 2; attempt++ {
+				Expect(rotate()).To(BeFalse())
+				Expect(k8sClient.Get(ctx, jobKey, &batchv1.Job{})).To(Succeed())
+				failJob()
+				Expect(rotate()).To(BeFalse())
+				Expect(store.Status.FailureCount).To(Equal(attempt))
+				Expect(store.Status.Phase).To(Equal(PhaseReady))
 			}
+			Expect(store.Status.Reason).To(Equal(ReasonRetriesExhausted))
 
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: typeNamespacedName,
+			By("starting no further Job once the budget is spent")
+			Expect(rotate()).To(BeTrue())
+			err = k8sClient.Get(ctx, jobKey, &batchv1.Job{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			By("running the rotation again after retry-now")
+			Expect(k8sClient.Get(ctx, storeKey, store)).To(Succeed())
+			store.Annotations[AnnotationRetryNow] = "true"
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			Expect(reconciler.reconcileRetryNow(ctx, store)).To(Succeed())
+			Expect(rotate()).To(BeFalse())
+			Expect(k8sClient.Get(ctx, jobKey, &batchv1.Job{})).To(Succeed())
+		})
+	})
+
+	Context("When a store goes through its lifecycle", func() {
+		ctx := context.Background()
+
+		// markPodReady creates a running, ready pod carrying the engine's readiness labels
+		markPodReady := func(namespace string, labels map[string]string) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "app-0", Namespace: namespace, Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "busybox"}}},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			pod.Status.Phase = corev1.PodRunning
+			pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+			Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+		}
+
+		// expireBackoff moves a store's last failure far enough into the past
+		// that its next Helm attempt is due
+		expireBackoff := func(key types.NamespacedName) {
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			store.Status.LastFailureTime = &metav1.Time{Time: time.Now().Add(-24 * time.Hour)}
+			Expect(k8sClient.Status().Update(ctx, store)).To(Succeed())
+		}
+
+		// finalizeNamespace stands in for the namespace controller, which envtest doesn't run
+		finalizeNamespace := func(name string) {
+			clientset, err := kubernetes.NewForConfig(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, ns)).To(Succeed())
+			ns.Spec.Finalizers = nil
+			_, err = clientset.CoreV1().Namespaces().Finalize(ctx, ns, metav1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		DescribeTable("create, provision, become ready and delete",
+			func(storeName, engineName string, chartPath func(*config.OperatorConfig) string) {
+				key := types.NamespacedName{Name: storeName, Namespace: "default"}
+				nsName := StoreNamespacePrefix + storeName
+				releases := helm.NewFakeReleaseManager()
+				reconciler := newReconciler(releases)
+				reconciler.Config.MedusaImage = "registry.example.com/medusa-store:2.0"
+				provider, err := engine.Get(engineName)
+				Expect(err).NotTo(HaveOccurred())
+
+				By("creating the Store")
+				Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+					ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+					Spec:       infrav1alpha1.StoreSpec{Engine: engineName, Plan: "small"},
+				})).To(Succeed())
+
+				By("adding the finalizer and the store namespace")
+				Expect(reconcileStore(reconciler, key).RequeueAfter).To(BeNumerically(">", 0))
+				store := &infrav1alpha1.Store{}
+				Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+				Expect(store.Finalizers).To(ContainElement(storeFinalizer))
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: nsName}, &corev1.Namespace{})).To(Succeed())
+
+				By("installing the release and waiting for pods")
+				reconcileStore(reconciler, key)
+				Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+				Expect(store.Status.Phase).To(Equal(PhaseProvisioning))
+				Expect(store.Status.Reason).To(Equal(ReasonWaitingForPods))
+				Expect(meta.IsStatusConditionTrue(store.Status.Conditions, ConditionReleaseInstalled)).To(BeTrue())
+				Expect(meta.IsStatusConditionFalse(store.Status.Conditions, ConditionWorkloadReady)).To(BeTrue())
+				ready := meta.FindStatusCondition(store.Status.Conditions, ConditionReady)
+				Expect(ready).NotTo(BeNil())
+				Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+				Expect(ready.Reason).To(Equal(ReasonWaitingForPods))
+				release, ok := releases.Release(storeName, nsName)
+				Expect(ok).To(BeTrue())
+				Expect(release.Chart.URL).To(Equal(chartPath(reconciler.Config)))
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: storeName + "-creds", Namespace: "default"}, &corev1.Secret{})).To(Succeed())
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ResourceQuotaName, Namespace: nsName}, &corev1.ResourceQuota{})).To(Succeed())
+				Expect(release.Values).To(HaveKeyWithValue("commonLabels", HaveKeyWithValue(LabelStoreName, storeName)))
+
+				By("keeping the release revision while workload events re-trigger a provisioning store")
+				installed := release.Revision
+				reconcileStore(reconciler, key)
+				release, _ = releases.Release(storeName, nsName)
+				Expect(release.Revision).To(Equal(installed))
+
+				By("becoming Ready once the engine's pods are")
+				provisioned := metricValue(storeProvisioningSeconds, prometheus.Labels{"plan": "small"})
+				markPodReady(nsName, provider.ReadinessLabels())
+				reconcileStore(reconciler, key)
+				Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+				Expect(store.Status.Phase).To(Equal(PhaseReady))
+				Expect(store.Status.URL).NotTo(BeEmpty())
+				Expect(metricValue(storeProvisioningSeconds, prometheus.Labels{"plan": "small"})).To(Equal(provisioned + 1))
+				Expect(metricValue(newStoreCollector(k8sClient),
+					prometheus.Labels{"phase": PhaseReady, "plan": "small", "engine": engineName})).To(BeNumerically(">=", 1))
+				Expect(store.Status.ObservedGeneration).To(Equal(store.Generation))
+				for _, conditionType := range []string{ConditionNamespaceReady, ConditionCredentialsReady,
+					ConditionGuardrailsApplied, ConditionReleaseInstalled, ConditionWorkloadReady, ConditionReady} {
+					condition := meta.FindStatusCondition(store.Status.Conditions, conditionType)
+					Expect(condition).NotTo(BeNil(), conditionType)
+					Expect(condition.Status).To(Equal(metav1.ConditionTrue), conditionType)
+					Expect(condition.ObservedGeneration).To(Equal(store.Generation), conditionType)
+				}
+
+				By("not upgrading a settled store")
+				release, _ = releases.Release(storeName, nsName)
+				revision := release.Revision
+				reconcileStore(reconciler, key)
+				release, _ = releases.Release(storeName, nsName)
+				Expect(release.Revision).To(Equal(revision))
+
+				By("uninstalling and deleting the namespace on delete")
+				deleted := metricValue(storeDeletionTotal, prometheus.Labels{})
+				uninstalled := prometheus.Labels{"operation": helmOperationUninstall, "result": helmResultSuccess}
+				uninstalls := metricValue(storeHelmSeconds, uninstalled)
+				Expect(k8sClient.Delete(ctx, store)).To(Succeed())
+				reconcileStore(reconciler, key)
+				reconcileStore(reconciler, key)
+				Expect(metricValue(storeHelmSeconds, uninstalled)).To(BeNumerically(">", uninstalls))
+				_, ok = releases.Release(storeName, nsName)
+				Expect(ok).To(BeFalse())
+				ns := &corev1.Namespace{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: nsName}, ns)).To(Succeed())
+				Expect(ns.Status.Phase).To(Equal(corev1.NamespaceTerminating))
+
+				By("removing the finalizer once the namespace is gone")
+				finalizeNamespace(nsName)
+				reconcileStore(reconciler, key)
+				err = k8sClient.Get(ctx, key, store)
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+				Expect(metricValue(storeDeletionTotal, prometheus.Labels{})).To(Equal(deleted + 1))
+			},
+			Entry("woo", "lifecycle-woo", engine.EngineWoo,
+				func(c *config.OperatorConfig) string { return c.WordPressChartPath }),
+			Entry("medusa", "lifecycle-medusa", engine.EngineMedusa,
+				func(c *config.OperatorConfig) string { return c.MedusaChartPath }),
+		)
+
+		It("should repair a release that drifted from the desired state", func() {
+			const storeName = "lifecycle-drift"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			releases := helm.NewFakeReleaseManager()
+			reconciler := newReconciler(releases)
+			driftCondition := func() *metav1.Condition {
+				store := &infrav1alpha1.Store{}
+				Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+				return meta.FindStatusCondition(store.Status.Conditions, ConditionDrifted)
+			}
+			provider, err := engine.Get(engine.EngineWoo)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: "small"},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+			reconcileStore(reconciler, key)
+			markPodReady(nsName, provider.ReadinessLabels())
+			reconcileStore(reconciler, key)
+
+			By("resyncing a Ready store for its next health and drift check")
+			Expect(reconcileStore(reconciler, key).RequeueAfter).To(Equal(reconciler.Config.HealthCheckInterval))
+			Expect(driftCondition()).NotTo(BeNil())
+			Expect(driftCondition().Status).To(Equal(metav1.ConditionFalse))
+			Expect(driftCondition().Reason).To(Equal(ConditionReasonInSync))
+
+			By("upgrading a release whose values and chart version were changed by hand")
+			releases.Modify(storeName, nsName, func(rel *helm.FakeRelease) {
+				rel.ChartVersion = "0.0.1"
+				rel.Values = map[string]interface{}{"wordpressUsername": "intruder"}
 			})
+			release, _ := releases.Release(storeName, nsName)
+			revision := release.Revision
+			reconcileStore(reconciler, key)
+			release, _ = releases.Release(storeName, nsName)
+			Expect(release.Revision).To(Equal(revision + 1))
+			Expect(release.ChartVersion).To(Equal(helm.FakeChartVersion))
+			Expect(release.Values).To(HaveKey("ingress"))
+			Expect(driftCondition().Status).To(Equal(metav1.ConditionFalse))
+			Expect(driftCondition().Reason).To(Equal(ConditionReasonRepaired))
+			Expect(driftCondition().Message).To(ContainSubstring("chart version is 0.0.1"))
+
+			By("reinstalling objects deleted from the store namespace")
+			releases.Modify(storeName, nsName, func(rel *helm.FakeRelease) {
+				rel.Manifest = "---\n# Source: engine-woo/templates/config.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: wp-config\n"
+			})
+			reconcileStore(reconciler, key)
+			Expect(driftCondition().Reason).To(Equal(ConditionReasonRepaired))
+			Expect(driftCondition().Message).To(ContainSubstring("ConfigMap/wp-config is missing"))
+
+			// The real upgrade recreates the object; do the same for the fake
+			Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "wp-config", Namespace: nsName},
+			})).To(Succeed())
+			release, _ = releases.Release(storeName, nsName)
+			revision = release.Revision
+			reconcileStore(reconciler, key)
+			release, _ = releases.Release(storeName, nsName)
+			Expect(release.Revision).To(Equal(revision))
+			Expect(driftCondition().Reason).To(Equal(ConditionReasonInSync))
+		})
+
+		It("should move a Ready store to Degraded and back as its health changes", func() {
+			const storeName = "lifecycle-health"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			var probeErr error
+			var probed string
+			reconciler := newReconciler(helm.NewFakeReleaseManager())
+			reconciler.HealthProbe = func(_ context.Context, url string) error {
+				probed = url
+				return probeErr
+			}
+			reconcileAndGet := func() *infrav1alpha1.Store {
+				reconcileStore(reconciler, key)
+				store := &infrav1alpha1.Store{}
+				Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+				return store
+			}
+			provider, err := engine.Get(engine.EngineWoo)
 			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: "small"},
+			})).To(Succeed())
+			reconcileAndGet()
+			reconcileAndGet()
+			markPodReady(nsName, provider.ReadinessLabels())
+			Expect(reconcileAndGet().Status.Phase).To(Equal(PhaseReady))
+			Expect(reconcileAndGet().Status.Phase).To(Equal(PhaseReady))
+			Expect(probed).To(Equal("http://" + storeName + "-wordpress." + nsName + ".svc:80/"))
+
+			By("degrading a store whose homepage returns server errors")
+			probeErr = fmt.Errorf("returned 500 Internal Server Error")
+			store := reconcileAndGet()
+			Expect(store.Status.Phase).To(Equal(PhaseDegraded))
+			Expect(store.Status.Reason).To(Equal(ReasonProbeFailed))
+			Expect(meta.IsStatusConditionFalse(store.Status.Conditions, ConditionReady)).To(BeTrue())
+
+			By("reporting a crash-looping container before the probe")
+			pod := &corev1.Pod{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "app-0", Namespace: nsName}, pod)).To(Succeed())
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:         "app",
+				RestartCount: 5,
+				State: corev1.ContainerState{
+					Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+				},
+			}}
+			Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+			store = reconcileAndGet()
+			Expect(store.Status.Phase).To(Equal(PhaseDegraded))
+			Expect(store.Status.Reason).To(Equal(ReasonCrashLooping))
+			Expect(meta.IsStatusConditionFalse(store.Status.Conditions, ConditionWorkloadReady)).To(BeTrue())
+
+			By("returning to Ready once every check passes")
+			pod.Status.ContainerStatuses = nil
+			Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+			probeErr = nil
+			store = reconcileAndGet()
+			Expect(store.Status.Phase).To(Equal(PhaseReady))
+			Expect(store.Status.Reason).To(BeEmpty())
+			Expect(meta.IsStatusConditionTrue(store.Status.Conditions, ConditionReady)).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(store.Status.Conditions, ConditionWorkloadReady)).To(BeTrue())
+		})
+
+		It("should roll a failed upgrade back to the last successful revision", func() {
+			const storeName = "lifecycle-rollback"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			releases := helm.NewFakeReleaseManager()
+			reconciler := newReconciler(releases)
+			provider, err := engine.Get(engine.EngineWoo)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: "small"},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+			reconcileStore(reconciler, key)
+			Expect(releases.LastOptions.Wait).To(BeFalse())
+			markPodReady(nsName, provider.ReadinessLabels())
+			reconcileStore(reconciler, key)
+
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(PhaseReady))
+			good := store.Status.LastSuccessfulRevision
+			Expect(good).To(BeNumerically(">", 0))
+			Expect(good).To(Equal(store.Status.LastAppliedRevision))
+
+			By("rolling back an upgrade that doesn't become ready in time")
+			releases.UpgradeErr = fmt.Errorf("timed out waiting for the condition")
+			store.Spec.Credentials = &infrav1alpha1.CredentialsSpec{RotateAfter: &metav1.Duration{Duration: 720 * time.Hour}}
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			Expect(reconcileStore(reconciler, key).RequeueAfter).To(BeNumerically(">=", reconciler.Config.HelmFailureRetryInterval))
+			Expect(releases.LastOptions.Wait).To(BeTrue())
+			Expect(releases.LastOptions.Timeout).To(Equal(reconciler.Config.HelmTimeout))
+
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(PhaseReady))
+			Expect(store.Status.Reason).To(Equal(ReasonRolledBack))
+			Expect(store.Status.LastAppliedRevision).To(Equal(good + 1))
+			Expect(store.Status.LastSuccessfulRevision).To(Equal(good + 2))
+			Expect(store.Status.ObservedGeneration).NotTo(Equal(store.Generation))
+			Expect(store.Status.FailureCount).To(Equal(1))
+			release, _ := releases.Release(storeName, nsName)
+			Expect(release.Revision).To(Equal(good + 2))
+			Expect(release.Status).To(Equal(helm.StatusDeployed))
+
+			By("staying Ready when the upgrade fails before Helm records a revision")
+			releases.UpgradeErr = nil
+			releases.InstallErr = fmt.Errorf("failed to pull chart: registry unavailable")
+			expireBackoff(key)
+			reconcileStore(reconciler, key)
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(PhaseReady))
+			Expect(store.Status.Reason).To(Equal(ReasonHelmError))
+			Expect(store.Status.LastSuccessfulRevision).To(Equal(good + 2))
+			Expect(store.Status.FailureCount).To(Equal(2))
+			Expect(meta.IsStatusConditionFalse(store.Status.Conditions, ConditionReleaseInstalled)).To(BeTrue())
+
+			By("recording the new revision once an upgrade succeeds")
+			releases.InstallErr = nil
+			expireBackoff(key)
+			reconcileStore(reconciler, key)
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Reason).To(BeEmpty())
+			Expect(store.Status.LastAppliedRevision).To(Equal(good + 3))
+			Expect(store.Status.LastSuccessfulRevision).To(Equal(good + 3))
+			Expect(store.Status.ObservedGeneration).To(Equal(store.Generation))
+			Expect(store.Status.FailureCount).To(BeZero())
+		})
+
+		It("should retain a store's volumes for a later store of the same name", func() {
+			const storeName = "lifecycle-retain"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			reconciler := newReconciler(helm.NewFakeReleaseManager())
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec: infrav1alpha1.StoreSpec{
+					Engine:         engine.EngineWoo,
+					Plan:           "small",
+					DeletionPolicy: DeletionPolicyRetain,
+				},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+
+			By("binding a volume to a claim in the store namespace")
+			storage := corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}
+			pv := &corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName + "-data"},
+				Spec: corev1.PersistentVolumeSpec{
+					Capacity:                      storage,
+					AccessModes:                   []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+					PersistentVolumeSource: corev1.PersistentVolumeSource{
+						HostPath: &corev1.HostPathVolumeSource{Path: "/tmp/" + storeName},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pv)).To(Succeed())
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: nsName},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources:   corev1.VolumeResourceRequirements{Requests: storage},
+					VolumeName:  pv.Name,
+				},
+			}
+			Expect(k8sClient.Create(ctx, pvc)).To(Succeed())
+			pv.Spec.ClaimRef = &corev1.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: nsName, Name: pvc.Name, UID: pvc.UID}
+			Expect(k8sClient.Update(ctx, pv)).To(Succeed())
+
+			By("switching the volume to Retain before teardown")
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, store)).To(Succeed())
+			reconcileStore(reconciler, key)
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: pv.Name}, pv)).To(Succeed())
+			Expect(pv.Spec.PersistentVolumeReclaimPolicy).To(Equal(corev1.PersistentVolumeReclaimRetain))
+			Expect(pv.Labels).To(HaveKeyWithValue(LabelStoreName, storeName))
+			Expect(pv.Labels).To(HaveKeyWithValue(LabelRetainedClaim, "data"))
+
+			By("detaching the volume from its claim once the namespace is gone")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(pvc), pvc)).To(Succeed())
+			pvc.Finalizers = nil
+			Expect(k8sClient.Update(ctx, pvc)).To(Succeed())
+			finalizeNamespace(nsName)
+			reconcileStore(reconciler, key)
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, key, store))).To(BeTrue())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: pv.Name}, pv)).To(Succeed())
+			Expect(pv.Spec.ClaimRef).NotTo(BeNil())
+			Expect(pv.Spec.ClaimRef.UID).To(BeEmpty())
+			Expect(pv.Spec.ClaimRef.Namespace).To(Equal(nsName))
+			Expect(pv.Spec.ClaimRef.Name).To(Equal("data"))
+			Expect(k8sClient.Delete(ctx, pv)).To(Succeed())
+		})
+
+		It("should keep the volume of a PVC backup target when the store is deleted", func() {
+			const storeName = "lifecycle-backup-pvc"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			reconciler := newReconciler(helm.NewFakeReleaseManager())
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: "small"},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+
+			By("writing a backup to a PVC next to the store's data")
+			storage := corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}
+			pv := &corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName + "-backups"},
+				Spec: corev1.PersistentVolumeSpec{
+					Capacity:                      storage,
+					AccessModes:                   []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+					PersistentVolumeSource: corev1.PersistentVolumeSource{
+						HostPath: &corev1.HostPathVolumeSource{Path: "/tmp/" + storeName},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pv)).To(Succeed())
+			claims := map[string]*corev1.PersistentVolumeClaim{}
+			for _, name := range []string{"backups", "data"} {
+				pvc := &corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsName},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						Resources:   corev1.VolumeResourceRequirements{Requests: storage},
+					},
+				}
+				if name == "backups" {
+					pvc.Spec.VolumeName = pv.Name
+				}
+				Expect(k8sClient.Create(ctx, pvc)).To(Succeed())
+				claims[name] = pvc
+			}
+			pv.Spec.ClaimRef = &corev1.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: nsName,
+				Name: "backups", UID: claims["backups"].UID}
+			Expect(k8sClient.Update(ctx, pv)).To(Succeed())
+			backup := &infrav1alpha1.StoreBackup{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec: infrav1alpha1.StoreBackupSpec{
+					StoreName: storeName,
+					Target:    infrav1alpha1.BackupTarget{PVC: &infrav1alpha1.PVCBackupTarget{ClaimName: "backups"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+			DeferCleanup(func() { Expect(k8sClient.Delete(ctx, backup)).To(Succeed()) })
+
+			By("deleting the store's data but retaining the backup volume")
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, store)).To(Succeed())
+			reconcileStore(reconciler, key)
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(claims["data"]), claims["data"])
+			Expect(errors.IsNotFound(err) || claims["data"].DeletionTimestamp != nil).To(BeTrue())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(claims["backups"]), claims["backups"])).To(Succeed())
+			Expect(claims["backups"].DeletionTimestamp).To(BeNil())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: pv.Name}, pv)).To(Succeed())
+			Expect(pv.Spec.PersistentVolumeReclaimPolicy).To(Equal(corev1.PersistentVolumeReclaimRetain))
+			Expect(pv.Labels).To(HaveKeyWithValue(LabelRetainedClaim, "backups"))
+
+			By("detaching it for a new store of the same name once the namespace is gone")
+			for _, pvc := range claims {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(pvc), pvc); err == nil {
+					pvc.Finalizers = nil
+					Expect(k8sClient.Update(ctx, pvc)).To(Succeed())
+				}
+			}
+			finalizeNamespace(nsName)
+			reconcileStore(reconciler, key)
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, key, store))).To(BeTrue())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: pv.Name}, pv)).To(Succeed())
+			Expect(pv.Spec.ClaimRef.UID).To(BeEmpty())
+			Expect(pv.Spec.ClaimRef.Name).To(Equal("backups"))
+			Expect(k8sClient.Delete(ctx, pv)).To(Succeed())
+		})
+
+		It("should take a final backup before tearing down a Snapshot store", func() {
+			const storeName = "lifecycle-snapshot"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			releases := helm.NewFakeReleaseManager()
+			reconciler := newReconciler(releases)
+			provider, err := engine.Get(engine.EngineWoo)
+			Expect(err).NotTo(HaveOccurred())
+
+			target := &infrav1alpha1.S3BackupTarget{Endpoint: "https://s3.example.com", Bucket: "stores", CredentialsSecret: "s3-creds"}
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec: infrav1alpha1.StoreSpec{
+					Engine:         engine.EngineWoo,
+					Plan:           "small",
+					DeletionPolicy: DeletionPolicySnapshot,
+					SnapshotTarget: target,
+				},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+			reconcileStore(reconciler, key)
+			markPodReady(nsName, provider.ReadinessLabels())
+			reconcileStore(reconciler, key)
+
+			By("holding teardown until the final backup completes")
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(PhaseReady))
+			Expect(k8sClient.Delete(ctx, store)).To(Succeed())
+			Expect(reconcileStore(reconciler, key).RequeueAfter).To(Equal(reconciler.Config.BackupPollInterval))
+			backup := &infrav1alpha1.StoreBackup{}
+			backupKey := types.NamespacedName{Name: storeName + "-deletion", Namespace: "default"}
+			Expect(k8sClient.Get(ctx, backupKey, backup)).To(Succeed())
+			Expect(backup.Spec.StoreName).To(Equal(storeName))
+			Expect(backup.Spec.Target.S3).To(Equal(target))
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Reason).To(Equal(ReasonSnapshotting))
+			_, installed := releases.Release(storeName, nsName)
+			Expect(installed).To(BeTrue())
+
+			By("tearing down once it has")
+			backup.Status.Phase = BackupPhaseCompleted
+			backup.Status.Location = "s3://stores/" + storeName + ".tar.gz"
+			Expect(k8sClient.Status().Update(ctx, backup)).To(Succeed())
+			reconcileStore(reconciler, key)
+			_, installed = releases.Release(storeName, nsName)
+			Expect(installed).To(BeFalse())
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: nsName}, ns)).To(Succeed())
+			Expect(ns.Status.Phase).To(Equal(corev1.NamespaceTerminating))
+
+			finalizeNamespace(nsName)
+			reconcileStore(reconciler, key)
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, key, store))).To(BeTrue())
+			Expect(k8sClient.Get(ctx, backupKey, backup)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, backup)).To(Succeed())
+		})
+
+		DescribeTable("should take the final backup of a Snapshot store that isn't Ready",
+			func(storeName string, leaveReady func(reconcileNow func() reconcile.Result, store *infrav1alpha1.Store)) {
+				key := types.NamespacedName{Name: storeName, Namespace: "default"}
+				nsName := StoreNamespacePrefix + storeName
+				reconciler := newReconciler(helm.NewFakeReleaseManager())
+				provider, err := engine.Get(engine.EngineWoo)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Create(ctx, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: storeName + "-s3", Namespace: "default"},
+					StringData: map[string]string{jobSecretKeyAWSAccessKey: "access", jobSecretKeyAWSSecretKey: "secret"},
+				})).To(Succeed())
+				Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+					ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+					Spec: infrav1alpha1.StoreSpec{
+						Engine:         engine.EngineWoo,
+						Plan:           "small",
+						DeletionPolicy: DeletionPolicySnapshot,
+						SnapshotTarget: &infrav1alpha1.S3BackupTarget{
+							Endpoint: "https://s3.example.com", Bucket: "stores", CredentialsSecret: storeName + "-s3",
+						},
+					},
+				})).To(Succeed())
+				reconcileStore(reconciler, key)
+				reconcileStore(reconciler, key)
+				markPodReady(nsName, provider.ReadinessLabels())
+				reconcileStore(reconciler, key)
+				one := int32(1)
+				Expect(k8sClient.Create(ctx, &appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: "wordpress", Namespace: nsName},
+					Spec: appsv1.DeploymentSpec{
+						Replicas: &one,
+						Selector: &metav1.LabelSelector{MatchLabels: provider.ReadinessLabels()},
+						Template: corev1.PodTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{Labels: provider.ReadinessLabels()},
+							Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "busybox"}}},
+						},
+					},
+				})).To(Succeed())
+
+				store := &infrav1alpha1.Store{}
+				Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+				Expect(store.Status.Phase).To(Equal(PhaseReady))
+				leaveReady(func() reconcile.Result { return reconcileStore(reconciler, key) }, store)
+
+				By("deleting the store")
+				Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, store)).To(Succeed())
+				Expect(reconcileStore(reconciler, key).RequeueAfter).To(Equal(reconciler.Config.BackupPollInterval))
+				deployment := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "wordpress", Namespace: nsName}, deployment)).To(Succeed())
+				Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+
+				By("starting the final backup although the store isn't Ready")
+				backupKey := types.NamespacedName{Name: storeName + "-deletion", Namespace: "default"}
+				backupReconciler := &StoreBackupReconciler{
+					Client:   k8sClient,
+					Scheme:   k8sClient.Scheme(),
+					Recorder: record.NewFakeRecorder(10),
+					Config:   config.Load(),
+				}
+				_, err = backupReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: backupKey})
+				Expect(err).NotTo(HaveOccurred())
+				backup := &infrav1alpha1.StoreBackup{}
+				Expect(k8sClient.Get(ctx, backupKey, backup)).To(Succeed())
+				Expect(backup.Status.Phase).To(Equal(BackupPhaseRunning))
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "backup-" + backupKey.Name, Namespace: nsName}, &batchv1.Job{})).To(Succeed())
+
+				By("tearing down once it completes")
+				backup.Status.Phase = BackupPhaseCompleted
+				backup.Status.Location = "s3://stores/" + storeName + ".tar"
+				Expect(k8sClient.Status().Update(ctx, backup)).To(Succeed())
+				reconcileStore(reconciler, key)
+				finalizeNamespace(nsName)
+				reconcileStore(reconciler, key)
+				Expect(errors.IsNotFound(k8sClient.Get(ctx, key, store))).To(BeTrue())
+				Expect(k8sClient.Delete(ctx, backup)).To(Succeed())
+			},
+			Entry("suspended", "lifecycle-snapshot-suspended",
+				func(reconcileNow func() reconcile.Result, store *infrav1alpha1.Store) {
+					store.Spec.Suspended = true
+					Expect(k8sClient.Update(ctx, store)).To(Succeed())
+					reconcileNow()
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(store), store)).To(Succeed())
+					Expect(store.Status.Phase).To(Equal(PhaseSuspended))
+				}),
+			Entry("degraded", "lifecycle-snapshot-degraded",
+				func(_ func() reconcile.Result, store *infrav1alpha1.Store) {
+					store.Status.Phase = PhaseDegraded
+					Expect(k8sClient.Status().Update(ctx, store)).To(Succeed())
+				}),
+		)
+
+		It("should restrict egress to DNS, the namespace and spec.network", func() {
+			const storeName = "lifecycle-network"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			reconciler := newReconciler(helm.NewFakeReleaseManager())
+			reconciler.HealthProbe = func(context.Context, string) error {
+				return nil
+			}
+			reconciler.Config.IngressNamespace = "gateway-system"
+			reconciler.Config.IngressPodLabels = map[string]string{"app": "envoy"}
+			reconciler.Config.OperatorNamespace = "store-operator-system"
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec: infrav1alpha1.StoreSpec{
+					Engine: engine.EngineWoo,
+					Plan:   "small",
+					Network: &infrav1alpha1.NetworkSpec{Egress: []infrav1alpha1.EgressRule{
+						{CIDR: "203.0.113.0/24", Ports: []infrav1alpha1.EgressPort{{Port: 443}}},
+						{Ports: []infrav1alpha1.EgressPort{{Port: 587, Protocol: "TCP"}}},
+					}},
+				},
+			})).To(Succeed())
+			for i := 0; i < 2; i++ {
+				reconcileStore(reconciler, key)
+			}
+
+			np := &netv1.NetworkPolicy{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: NetworkPolicyName, Namespace: nsName}, np)).To(Succeed())
+			Expect(np.Spec.PolicyTypes).To(ConsistOf(netv1.PolicyTypeIngress, netv1.PolicyTypeEgress))
+
+			By("admitting ingress from the configured controller pods")
+			from := np.Spec.Ingress[0].From[0]
+			Expect(from.NamespaceSelector.MatchLabels).To(HaveKeyWithValue(corev1.LabelMetadataName, "gateway-system"))
+			Expect(from.PodSelector.MatchLabels).To(Equal(map[string]string{"app": "envoy"}))
+
+			By("admitting the operator's health probe on the engine's health port only")
+			Expect(np.Spec.Ingress).To(HaveLen(2))
+			probe := np.Spec.Ingress[1]
+			Expect(probe.From).To(HaveLen(1))
+			Expect(probe.From[0].NamespaceSelector.MatchLabels).To(HaveKeyWithValue(corev1.LabelMetadataName, "store-operator-system"))
+			Expect(probe.From[0].PodSelector).To(BeNil())
+			Expect(probe.Ports).To(HaveLen(1))
+			Expect(probe.Ports[0].Port.String()).To(Equal("http"))
+
+			By("allowing DNS, the namespace and each allow-listed destination")
+			egress := np.Spec.Egress
+			Expect(egress).To(HaveLen(4))
+			Expect(egress[0].To[0].NamespaceSelector.MatchLabels).To(HaveKeyWithValue(corev1.LabelMetadataName, "kube-system"))
+			Expect(egress[0].Ports).To(HaveLen(2))
+			Expect(egress[0].Ports[0].Port.IntValue()).To(Equal(53))
+			Expect(egress[1].To[0].PodSelector).NotTo(BeNil())
+			Expect(egress[2].To[0].IPBlock.CIDR).To(Equal("203.0.113.0/24"))
+			Expect(egress[2].Ports[0].Port.IntValue()).To(Equal(443))
+			Expect(*egress[2].Ports[0].Protocol).To(Equal(corev1.ProtocolTCP))
+			Expect(egress[3].To).To(BeEmpty())
+			Expect(egress[3].Ports[0].Port.IntValue()).To(Equal(587))
+
+			By("opening egress for the operator's data Jobs only")
+			jobs := &netv1.NetworkPolicy{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: DataJobNetworkPolicyName, Namespace: nsName}, jobs)).To(Succeed())
+			Expect(jobs.Spec.PodSelector.MatchLabels).To(Equal(map[string]string{LabelManagedBy: ManagedByValue}))
+			Expect(jobs.Spec.Egress).To(HaveLen(1))
+		})
+
+		It("should expose a store through an HTTPRoute in Gateway mode", func() {
+			const storeName = "lifecycle-gateway"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			releases := helm.NewFakeReleaseManager()
+			reconciler := newReconciler(releases)
+			reconciler.Config.IngressAnnotations = map[string]string{"example.com/rate-limit": "100"}
+			reconciler.Config.GatewayProxyPodLabels = map[string]string{"gateway.networking.k8s.io/gateway-name": "shared"}
+			provider, err := engine.Get(engine.EngineWoo)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec: infrav1alpha1.StoreSpec{
+					Engine: engine.EngineWoo,
+					Plan:   "small",
+					Routing: &infrav1alpha1.RoutingSpec{
+						Mode:        engine.RoutingModeGateway,
+						Annotations: map[string]string{"example.com/body-size": "64m"},
+						Gateway:     &infrav1alpha1.GatewayRef{Name: "shared", SectionName: "web"},
+					},
+				},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+			reconcileStore(reconciler, key)
+
+			release, ok := releases.Release(storeName, nsName)
+			Expect(ok).To(BeTrue())
+			ingress := release.Values[engine.HelmKeyIngress].(map[string]interface{})
+			Expect(ingress["enabled"]).To(BeFalse())
+			route := release.Values[engine.HelmKeyHTTPRoute].(map[string]interface{})
+			Expect(route["enabled"]).To(BeTrue())
+			Expect(route["hostnames"]).To(ConsistOf(storeName + "." + reconciler.Config.BaseDomain))
+			Expect(route["parentRefs"]).To(ConsistOf(map[string]interface{}{
+				"name": "shared", "namespace": reconciler.Config.GatewayNamespace, "sectionName": "web",
+			}))
+			Expect(route["annotations"]).To(Equal(map[string]interface{}{
+				"example.com/rate-limit": "100", "example.com/body-size": "64m",
+			}))
+
+			By("admitting the Gateway's proxy pods alongside the ingress controller")
+			np := &netv1.NetworkPolicy{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: NetworkPolicyName, Namespace: nsName}, np)).To(Succeed())
+			from := np.Spec.Ingress[0].From
+			Expect(from).To(HaveLen(3))
+			Expect(from[0].NamespaceSelector.MatchLabels).To(HaveKeyWithValue(corev1.LabelMetadataName, reconciler.Config.IngressNamespace))
+			Expect(from[2].NamespaceSelector.MatchLabels).To(HaveKeyWithValue(corev1.LabelMetadataName, reconciler.Config.GatewayNamespace))
+			Expect(from[2].PodSelector.MatchLabels).To(Equal(reconciler.Config.GatewayProxyPodLabels))
+
+			By("falling back to plain HTTP while the Gateway can't be read")
+			markPodReady(nsName, provider.ReadinessLabels())
+			reconcileStore(reconciler, key)
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.URL).To(Equal("http://" + storeName + "." + reconciler.Config.BaseDomain))
+
+			By("deriving the scheme and port from the Gateway listener")
+			listeners := []interface{}{
+				map[string]interface{}{"name": "web", "protocol": "HTTP", "port": int64(80)},
+				map[string]interface{}{"name": "websecure", "protocol": "HTTPS", "port": int64(8443)},
+			}
+			Expect(gatewayURL(listeners, "web", "shop.example.com")).To(Equal("http://shop.example.com"))
+			Expect(gatewayURL(listeners, "", "shop.example.com")).To(Equal("https://shop.example.com:8443"))
+		})
+
+		It("should map plan scaling onto the chart and reject replicas beyond maxPods", func() {
+			const storeName = "lifecycle-scaling"
+			const planName = "scaling"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			releases := helm.NewFakeReleaseManager()
+			reconciler := newReconciler(releases)
+
+			targetCPU := int32(70)
+			minAvailable := intstr.FromInt32(1)
+			plan := &infrav1alpha1.StorePlan{
+				ObjectMeta: metav1.ObjectMeta{Name: planName},
+				Spec: infrav1alpha1.StorePlanSpec{
+					Quota: infrav1alpha1.PlanQuota{
+						RequestsCPU:    resource.MustParse("1"),
+						RequestsMemory: resource.MustParse("1Gi"),
+						LimitsCPU:      resource.MustParse("2"),
+						LimitsMemory:   resource.MustParse("2Gi"),
+						MaxPods:        5,
+					},
+					LimitRange: infrav1alpha1.PlanLimitRange{
+						DefaultCPU:           resource.MustParse("200m"),
+						DefaultMemory:        resource.MustParse("256Mi"),
+						DefaultRequestCPU:    resource.MustParse("50m"),
+						DefaultRequestMemory: resource.MustParse("128Mi"),
+					},
+					Autoscaling:      &infrav1alpha1.PlanAutoscaling{MinReplicas: 2, MaxReplicas: 4, TargetCPU: &targetCPU},
+					DisruptionBudget: &infrav1alpha1.PlanDisruptionBudget{MinAvailable: &minAvailable},
+				},
+			}
+			Expect(k8sClient.Create(ctx, plan)).To(Succeed())
+			DeferCleanup(func() { Expect(k8sClient.Delete(ctx, plan)).To(Succeed()) })
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: planName},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+			reconcileStore(reconciler, key)
+
+			By("rejecting replicas that would share a ReadWriteOnce content volume")
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(PhaseFailed))
+			Expect(store.Status.Reason).To(Equal(ReasonReadWriteOnceVolume))
+			_, ok := releases.Release(storeName, nsName)
+			Expect(ok).To(BeFalse())
+
+			By("rendering the plan's ReadWriteMany access mode")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: planName}, plan)).To(Succeed())
+			plan.Spec.Persistence.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+			Expect(k8sClient.Update(ctx, plan)).To(Succeed())
+			reconcileStore(reconciler, key)
+
+			release, ok := releases.Release(storeName, nsName)
+			Expect(ok).To(BeTrue())
+			Expect(release.Values[engine.HelmKeyPersistence]).To(HaveKeyWithValue("accessModes", []interface{}{"ReadWriteMany"}))
+			Expect(release.Values[engine.HelmKeyAutoscaling]).To(Equal(map[string]interface{}{
+				"enabled": true, "minReplicas": int32(2), "maxReplicas": int32(4), "targetCPU": int32(70),
+			}))
+			Expect(release.Values[engine.HelmKeyPDB]).To(Equal(map[string]interface{}{
+				"create": true, "minAvailable": "1",
+			}))
+
+			By("rejecting spec.replicas that don't fit the plan's pods next to MariaDB")
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			replicas := int32(5)
+			store.Spec.Replicas = &replicas
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			reconcileStore(reconciler, key)
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(PhaseFailed))
+			Expect(store.Status.Reason).To(Equal(ReasonReplicasExceedPlan))
+
+			By("pinning the replica count and turning autoscaling off")
+			replicas = 3
+			store.Spec.Replicas = &replicas
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			reconcileStore(reconciler, key)
+			release, _ = releases.Release(storeName, nsName)
+			Expect(release.Values[engine.HelmKeyReplicaCount]).To(Equal(int32(3)))
+			Expect(release.Values[engine.HelmKeyAutoscaling]).To(Equal(map[string]interface{}{"enabled": false}))
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Reason).NotTo(Equal(ReasonReplicasExceedPlan))
+
+			By("blocking a move to a plan whose content volume is ReadWriteOnce")
+			phase := store.Status.Phase
+			rwoPlan := &infrav1alpha1.StorePlan{
+				ObjectMeta: metav1.ObjectMeta{Name: planName + "-rwo"},
+				Spec:       *plan.Spec.DeepCopy(),
+			}
+			rwoPlan.Spec.Persistence.AccessModes = nil
+			Expect(k8sClient.Create(ctx, rwoPlan)).To(Succeed())
+			DeferCleanup(func() { Expect(k8sClient.Delete(ctx, rwoPlan)).To(Succeed()) })
+			store.Spec.Plan = rwoPlan.Name
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			reconcileStore(reconciler, key)
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(phase))
+			Expect(store.Status.AppliedPlan).To(Equal(planName))
+			blocked := meta.FindStatusCondition(store.Status.Conditions, ConditionPlanChangeBlocked)
+			Expect(blocked).NotTo(BeNil())
+			Expect(blocked.Status).To(Equal(metav1.ConditionTrue))
+			Expect(blocked.Message).To(ContainSubstring("ReadWriteOnce"))
+
+			By("keeping the installed release when its own plan's volume turns ReadWriteOnce")
+			store.Spec.Plan = planName
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: planName}, plan)).To(Succeed())
+			plan.Spec.Persistence.AccessModes = nil
+			Expect(k8sClient.Update(ctx, plan)).To(Succeed())
+			release, _ = releases.Release(storeName, nsName)
+			revision := release.Revision
+			reconcileStore(reconciler, key)
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(phase))
+			Expect(meta.IsStatusConditionTrue(store.Status.Conditions, ConditionScaleBlocked)).To(BeTrue())
+			release, _ = releases.Release(storeName, nsName)
+			Expect(release.Revision).To(Equal(revision))
+
+			By("applying the spec again once a single replica is pinned")
+			replicas = 1
+			store.Spec.Replicas = &replicas
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			reconcileStore(reconciler, key)
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(store.Status.Conditions, ConditionScaleBlocked)).To(BeFalse())
+			release, _ = releases.Release(storeName, nsName)
+			Expect(release.Revision).To(BeNumerically(">", revision))
+			Expect(release.Values[engine.HelmKeyReplicaCount]).To(Equal(int32(1)))
+		})
+
+		It("should expand volumes with the plan and report the ones that can't grow", func() {
+			const storeName = "lifecycle-volumes"
+			const planName = "volumes"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			releases := helm.NewFakeReleaseManager()
+			reconciler := newReconciler(releases)
+			provider, err := engine.Get(engine.EngineWoo)
+			Expect(err).NotTo(HaveOccurred())
+			volumes := provider.(engine.VolumeProvider).Volumes(storeName)
+			Expect(volumes).To(HaveLen(2))
+			content, database := volumes[0], volumes[1]
+
+			expands, fixed := true, false
+			for name, allow := range map[string]*bool{"expandable": &expands, "fixed": &fixed} {
+				class := &storagev1.StorageClass{
+					ObjectMeta:           metav1.ObjectMeta{Name: name},
+					Provisioner:          "example.com/csi",
+					AllowVolumeExpansion: allow,
+				}
+				Expect(k8sClient.Create(ctx, class)).To(Succeed())
+				DeferCleanup(func() { Expect(k8sClient.Delete(ctx, class)).To(Succeed()) })
+			}
+
+			size := func(q string) *resource.Quantity {
+				quantity := resource.MustParse(q)
+				return &quantity
+			}
+			plan := &infrav1alpha1.StorePlan{
+				ObjectMeta: metav1.ObjectMeta{Name: planName},
+				Spec: infrav1alpha1.StorePlanSpec{
+					Quota: infrav1alpha1.PlanQuota{
+						RequestsCPU:    resource.MustParse("1"),
+						RequestsMemory: resource.MustParse("1Gi"),
+						LimitsCPU:      resource.MustParse("2"),
+						LimitsMemory:   resource.MustParse("2Gi"),
+						MaxPods:        10,
+					},
+					LimitRange: infrav1alpha1.PlanLimitRange{
+						DefaultCPU:           resource.MustParse("200m"),
+						DefaultMemory:        resource.MustParse("256Mi"),
+						DefaultRequestCPU:    resource.MustParse("50m"),
+						DefaultRequestMemory: resource.MustParse("128Mi"),
+					},
+					Persistence:         infrav1alpha1.PlanPersistence{Size: size("1Gi"), StorageClassName: "expandable"},
+					DatabasePersistence: infrav1alpha1.PlanPersistence{Size: size("1Gi"), StorageClassName: "fixed"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, plan)).To(Succeed())
+			DeferCleanup(func() { Expect(k8sClient.Delete(ctx, plan)).To(Succeed()) })
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: planName},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+			reconcileStore(reconciler, key)
+
+			release, ok := releases.Release(storeName, nsName)
+			Expect(ok).To(BeTrue())
+			Expect(release.Values[engine.HelmKeyPersistence]).To(Equal(map[string]interface{}{
+				"enabled": true, "size": "1Gi", "storageClass": "expandable",
+			}))
+			mariadb := release.Values[engine.HelmKeyMariaDB].(map[string]interface{})
+			Expect(mariadb["primary"].(map[string]interface{})["persistence"]).To(Equal(map[string]interface{}{
+				"enabled": true, "size": "1Gi", "storageClass": "fixed",
+			}))
+
+			By("standing in for the chart's volumes")
+			for _, v := range []struct {
+				volume engine.Volume
+				class  string
+			}{{content, "expandable"}, {database, "fixed"}} {
+				class := v.class
+				pvc := &corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: v.volume.Claim, Namespace: nsName},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						StorageClassName: &class,
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, pvc)).To(Succeed())
+				pvc.Status.Phase = corev1.ClaimBound
+				Expect(k8sClient.Status().Update(ctx, pvc)).To(Succeed())
+			}
+			labels := map[string]string{"app.kubernetes.io/name": "mariadb"}
+			statefulSet := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: database.StatefulSet, Namespace: nsName},
+				Spec: appsv1.StatefulSetSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "mariadb", Image: "mariadb"}}},
+					},
+					VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+						ObjectMeta: metav1.ObjectMeta{Name: "data"},
+						Spec: corev1.PersistentVolumeClaimSpec{
+							AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+							Resources: corev1.VolumeResourceRequirements{
+								Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+							},
+						},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, statefulSet)).To(Succeed())
+			reconcileStore(reconciler, key)
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(store.Status.Conditions, ConditionVolumesResized)).To(BeTrue())
+
+			By("growing both volumes on the plan")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: planName}, plan)).To(Succeed())
+			plan.Spec.Persistence.Size = size("2Gi")
+			plan.Spec.DatabasePersistence.Size = size("2Gi")
+			Expect(k8sClient.Update(ctx, plan)).To(Succeed())
+			reconcileStore(reconciler, key)
+
+			pvc := &corev1.PersistentVolumeClaim{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: content.Claim, Namespace: nsName}, pvc)).To(Succeed())
+			Expect(pvc.Spec.Resources.Requests.Storage().String()).To(Equal("2Gi"))
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: database.Claim, Namespace: nsName}, pvc)).To(Succeed())
+			Expect(pvc.Spec.Resources.Requests.Storage().String()).To(Equal("1Gi"))
+
+			release, _ = releases.Release(storeName, nsName)
+			Expect(release.Values[engine.HelmKeyPersistence].(map[string]interface{})["size"]).To(Equal("2Gi"))
+			mariadb = release.Values[engine.HelmKeyMariaDB].(map[string]interface{})
+			Expect(mariadb["primary"].(map[string]interface{})["persistence"].(map[string]interface{})["size"]).To(Equal("1Gi"))
+
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			condition := meta.FindStatusCondition(store.Status.Conditions, ConditionVolumesResized)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(ConditionReasonExpansionBlocked))
+			Expect(condition.Message).To(ContainSubstring(database.Claim))
+
+			By("keeping the StatefulSet whose claim template still matches the rendered size")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(statefulSet), statefulSet)).To(Succeed())
+			Expect(statefulSet.DeletionTimestamp).To(BeNil())
+		})
+
+		It("should hold a downgrade until the namespace's usage fits the smaller plan", func() {
+			const storeName = "lifecycle-downgrade"
+			const planName = "roomy"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			releases := helm.NewFakeReleaseManager()
+			reconciler := newReconciler(releases)
+			provider, err := engine.Get(engine.EngineWoo)
+			Expect(err).NotTo(HaveOccurred())
+			quotaMemory := func() string {
+				quota := &corev1.ResourceQuota{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ResourceQuotaName, Namespace: nsName}, quota)).To(Succeed())
+				return quota.Spec.Hard.Name("requests.memory", resource.BinarySI).String()
+			}
+			setUsedMemory := func(used string) {
+				quota := &corev1.ResourceQuota{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ResourceQuotaName, Namespace: nsName}, quota)).To(Succeed())
+				quota.Status.Hard = quota.Spec.Hard
+				quota.Status.Used = corev1.ResourceList{"requests.memory": resource.MustParse(used)}
+				Expect(k8sClient.Status().Update(ctx, quota)).To(Succeed())
+			}
+
+			plan := &infrav1alpha1.StorePlan{
+				ObjectMeta: metav1.ObjectMeta{Name: planName},
+				Spec: infrav1alpha1.StorePlanSpec{
+					Quota: infrav1alpha1.PlanQuota{
+						RequestsCPU:    resource.MustParse("2"),
+						RequestsMemory: resource.MustParse("2Gi"),
+						LimitsCPU:      resource.MustParse("4"),
+						LimitsMemory:   resource.MustParse("4Gi"),
+						MaxPods:        20,
+					},
+					LimitRange: infrav1alpha1.PlanLimitRange{
+						DefaultCPU:           resource.MustParse("200m"),
+						DefaultMemory:        resource.MustParse("256Mi"),
+						DefaultRequestCPU:    resource.MustParse("50m"),
+						DefaultRequestMemory: resource.MustParse("128Mi"),
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, plan)).To(Succeed())
+			DeferCleanup(func() { Expect(k8sClient.Delete(ctx, plan)).To(Succeed()) })
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: planName},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+			reconcileStore(reconciler, key)
+			markPodReady(nsName, provider.ReadinessLabels())
+			reconcileStore(reconciler, key)
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(PhaseReady))
+			Expect(store.Status.AppliedPlan).To(Equal(planName))
+
+			By("keeping the old plan while usage exceeds the new one")
+			setUsedMemory("1Gi")
+			store.Spec.Plan = "small"
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			result := reconcileStore(reconciler, key)
+			Expect(result.RequeueAfter).To(Equal(reconciler.Config.PlanChangeRecheckInterval))
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.AppliedPlan).To(Equal(planName))
+			Expect(quotaMemory()).To(Equal("2Gi"))
+			condition := meta.FindStatusCondition(store.Status.Conditions, ConditionPlanChangeBlocked)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(ConditionReasonUsageExceedsPlan))
+			Expect(condition.Message).To(ContainSubstring("requests.memory uses 1Gi, plan allows 512Mi"))
+
+			By("switching once usage drops")
+			setUsedMemory("256Mi")
+			reconcileStore(reconciler, key)
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.AppliedPlan).To(Equal("small"))
+			Expect(quotaMemory()).To(Equal("512Mi"))
+			Expect(meta.IsStatusConditionFalse(store.Status.Conditions, ConditionPlanChangeBlocked)).To(BeTrue())
+		})
+
+		It("should sample quota usage and volume sizes into status.usage", func() {
+			const storeName = "lifecycle-usage"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			reconciler := newReconciler(helm.NewFakeReleaseManager())
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: "small"},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+			reconcileStore(reconciler, key)
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Usage).NotTo(BeNil())
+			Expect(store.Status.Usage.Hard.Name("requests.memory", resource.BinarySI).String()).To(Equal("512Mi"))
+			Expect(store.Status.Usage.Storage.IsZero()).To(BeTrue())
+
+			By("keeping the sample until it is due")
+			quota := &corev1.ResourceQuota{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ResourceQuotaName, Namespace: nsName}, quota)).To(Succeed())
+			quota.Status.Hard = quota.Spec.Hard
+			quota.Status.Used = corev1.ResourceList{"requests.memory": resource.MustParse("300Mi")}
+			Expect(k8sClient.Status().Update(ctx, quota)).To(Succeed())
+			Expect(k8sClient.Create(ctx, &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "uploads", Namespace: nsName},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("3Gi")},
+					},
+				},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Usage.Used).To(BeEmpty())
+
+			By("resampling once the interval has passed")
+			reconciler.Config.UsageSampleInterval = 0
+			reconcileStore(reconciler, key)
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Usage.Used.Name("requests.memory", resource.BinarySI).String()).To(Equal("300Mi"))
+			Expect(store.Status.Usage.Storage.String()).To(Equal("3Gi"))
+			Expect(store.Status.Usage.Volumes).To(ConsistOf(infrav1alpha1.VolumeUsage{Name: "uploads", Size: resource.MustParse("3Gi")}))
+		})
+
+		It("should install the chart version pinned by spec.chart", func() {
+			const storeName = "lifecycle-chart"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			releases := helm.NewFakeReleaseManager()
+			reconciler := newReconciler(releases)
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec: infrav1alpha1.StoreSpec{
+					Engine: engine.EngineWoo,
+					Plan:   "small",
+					Chart:  &infrav1alpha1.ChartSource{Version: "2.1.0"},
+				},
+			})).To(Succeed())
+			for i := 0; i < 2; i++ {
+				reconcileStore(reconciler, key)
+			}
+
+			release, ok := releases.Release(storeName, nsName)
+			Expect(ok).To(BeTrue())
+			Expect(release.Chart.URL).To(Equal(reconciler.Config.WordPressChartPath))
+			Expect(release.ChartVersion).To(Equal("2.1.0"))
+
+			By("switching the store to another chart source")
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			store.Spec.Chart = &infrav1alpha1.ChartSource{URL: "oci://registry.example.com/charts/engine-woo", Version: "2.2.0"}
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			reconcileStore(reconciler, key)
+			release, _ = releases.Release(storeName, nsName)
+			Expect(release.Chart).To(Equal(helm.ChartRef{URL: "oci://registry.example.com/charts/engine-woo", Version: "2.2.0"}))
+		})
+
+		It("should seed spec.site through the chart's values and keep it immutable", func() {
+			const storeName = "lifecycle-site"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			releases := helm.NewFakeReleaseManager()
+			reconciler := newReconciler(releases)
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec: infrav1alpha1.StoreSpec{
+					Engine: engine.EngineWoo,
+					Plan:   "small",
+					Site: &infrav1alpha1.SiteSpec{
+						Title:         "Jo's Books",
+						AdminEmail:    "owner@example.com",
+						AdminUsername: "owner",
+						Locale:        "de_DE",
+						Currency:      "EUR",
+						Address: &infrav1alpha1.StoreAddress{
+							Line1:    "Hauptstraße 1",
+							City:     "Berlin",
+							Postcode: "10115",
+							Country:  "DE",
+							State:    "BE",
+						},
+					},
+				},
+			})).To(Succeed())
+			for i := 0; i < 2; i++ {
+				reconcileStore(reconciler, key)
+			}
+
+			release, ok := releases.Release(storeName, nsName)
+			Expect(ok).To(BeTrue())
+			Expect(release.Values[engine.HelmKeyWordPressBlogName]).To(Equal("Jo's Books"))
+			Expect(release.Values[engine.HelmKeyWordPressEmail]).To(Equal("owner@example.com"))
+			Expect(release.Values[engine.HelmKeyWordPressUsername]).To(Equal("owner"))
+			scripts, ok := release.Values[engine.HelmKeyPostInitScripts].(map[string]interface{})
+			Expect(ok).To(BeTrue())
+			script, _ := scripts[engine.WordPressSiteScript].(string)
+			Expect(script).To(ContainSubstring("wp language core install 'de_DE' --activate"))
+			Expect(script).To(ContainSubstring("wp option update woocommerce_currency 'EUR'"))
+			Expect(script).To(ContainSubstring("wp option update woocommerce_default_country 'DE:BE'"))
+			Expect(script).NotTo(ContainSubstring("woocommerce_store_address_2"))
+
+			By("rotating the configured admin user's password")
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			provider, err := engine.Get(store.Spec.Engine)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(provider.(engine.CredentialRotator).RotationScript(store)).To(ContainSubstring("WHERE user_login = 'owner'"))
+
+			By("rejecting changes to spec.site")
+			store.Spec.Site.Currency = "USD"
+			Expect(k8sClient.Update(ctx, store)).NotTo(Succeed())
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			store.Spec.Site = nil
+			Expect(k8sClient.Update(ctx, store)).NotTo(Succeed())
+		})
+
+		It("should map workload events in the store namespace back to the Store", func() {
+			const storeName = "lifecycle-watch"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			reconciler := newReconciler(helm.NewFakeReleaseManager())
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: "small"},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: nsName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue(LabelStoreName, storeName))
+			Expect(ns.Labels).To(HaveKeyWithValue(LabelStoreNamespace, "default"))
+
+			By("waiting for pods with the slow safety-net requeue")
+			result := reconcileStore(reconciler, key)
+			Expect(result.RequeueAfter).To(Equal(reconciler.Config.PodReadinessCheckInterval))
+
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "wordpress-0", Namespace: nsName}}
+			Expect(reconciler.storeForWorkload(ctx, pod)).To(ConsistOf(reconcile.Request{NamespacedName: key}))
+			deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "wordpress", Namespace: nsName}}
+			Expect(reconciler.storeForWorkload(ctx, deployment)).To(ConsistOf(reconcile.Request{NamespacedName: key}))
+			Expect(reconciler.storeForWorkload(ctx, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			})).To(BeEmpty())
+
+			By("only passing pod updates that change readiness")
+			notReady := &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+			ready := notReady.DeepCopy()
+			ready.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+			relabelled := notReady.DeepCopy()
+			relabelled.Labels = map[string]string{"extra": "label"}
+			Expect(podReadinessChanged.Update(event.UpdateEvent{ObjectOld: notReady, ObjectNew: ready})).To(BeTrue())
+			Expect(podReadinessChanged.Update(event.UpdateEvent{ObjectOld: notReady, ObjectNew: relabelled})).To(BeFalse())
+		})
+
+		It("should report a Helm failure and recover once the release installs", func() {
+			const storeName = "lifecycle-helm-error"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			releases := helm.NewFakeReleaseManager()
+			releases.InstallErr = fmt.Errorf("chart not found")
+			reconciler := newReconciler(releases)
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: "small"},
+			})).To(Succeed())
+
+			for i := 0; i < 2; i++ {
+				reconcileStore(reconciler, key)
+			}
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(PhaseFailed))
+			Expect(store.Status.Reason).To(Equal(ReasonHelmError))
+			Expect(store.Status.LastAppliedRevision).To(BeZero())
+			Expect(store.Status.FailureCount).To(Equal(1))
+			Expect(store.Status.LastFailureTime).NotTo(BeNil())
+
+			releases.InstallErr = nil
+			expireBackoff(key)
+			result := reconcileStore(reconciler, key)
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Reason).To(Equal(ReasonWaitingForPods))
+			Expect(store.Status.FailureCount).To(BeZero())
+			_, ok := releases.Release(storeName, StoreNamespacePrefix+storeName)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("should back off failed installs and stop once the retry budget is spent", func() {
+			const storeName = "lifecycle-retry-budget"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			releases := helm.NewFakeReleaseManager()
+			releases.InstallErr = fmt.Errorf("chart not found")
+			reconciler := newReconciler(releases)
+			reconciler.Config.MaxFailedAttempts = 3
+			base := reconciler.Config.HelmFailureRetryInterval
+			failureCount := func() int {
+				store := &infrav1alpha1.Store{}
+				Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+				return store.Status.FailureCount
+			}
+
+			helmErrors := metricValue(storeReconcileErrorsTotal, prometheus.Labels{"reason": ReasonHelmError})
+			gaveUp := metricValue(storeReconcileErrorsTotal, prometheus.Labels{"reason": ReasonRetriesExhausted})
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: "small"},
+			})).To(Succeed())
+			reconcileStore(reconciler, key)
+			result := reconcileStore(reconciler, key)
+			Expect(failureCount()).To(Equal(1))
+			Expect(result.RequeueAfter).To(BeNumerically(">=", base))
+			Expect(result.RequeueAfter).To(BeNumerically("<=", base+base/5))
+
+			By("holding the next attempt until the backoff has passed")
+			result = reconcileStore(reconciler, key)
+			Expect(failureCount()).To(Equal(1))
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(result.RequeueAfter).To(BeNumerically("<=", base))
+
+			By("doubling the delay after each failure")
+			expireBackoff(key)
+			result = reconcileStore(reconciler, key)
+			Expect(failureCount()).To(Equal(2))
+			Expect(result.RequeueAfter).To(BeNumerically(">=", 2*base))
+
+			By("giving up once the budget is spent")
+			expireBackoff(key)
+			Expect(reconcileStore(reconciler, key)).To(Equal(reconcile.Result{}))
+			store := &infrav1alpha1.Store{}
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Status.Phase).To(Equal(PhaseFailed))
+			Expect(store.Status.Reason).To(Equal(ReasonRetriesExhausted))
+			Expect(store.Status.FailureCount).To(Equal(3))
+
+			expireBackoff(key)
+			Expect(reconcileStore(reconciler, key)).To(Equal(reconcile.Result{}))
+			Expect(failureCount()).To(Equal(3))
+			Expect(metricValue(storeReconcileErrorsTotal, prometheus.Labels{"reason": ReasonHelmError})).To(Equal(helmErrors + 3))
+			Expect(metricValue(storeReconcileErrorsTotal, prometheus.Labels{"reason": ReasonRetriesExhausted})).To(Equal(gaveUp + 1))
+
+			By("retrying immediately once the retry-now annotation is set")
+			releases.InstallErr = nil
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			store.Annotations = map[string]string{AnnotationRetryNow: "true"}
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			reconcileStore(reconciler, key)
+			Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+			Expect(store.Annotations).NotTo(HaveKey(AnnotationRetryNow))
+			Expect(store.Status.FailureCount).To(BeZero())
+			Expect(store.Status.Reason).To(Equal(ReasonWaitingForPods))
+			_, ok := releases.Release(storeName, StoreNamespacePrefix+storeName)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("should keep health-checking a serving store whose upgrade retries are spent", func() {
+			const storeName = "lifecycle-retry-serving"
+			key := types.NamespacedName{Name: storeName, Namespace: "default"}
+			nsName := StoreNamespacePrefix + storeName
+			releases := helm.NewFakeReleaseManager()
+			var probeErr error
+			reconciler := newReconciler(releases)
+			reconciler.HealthProbe = func(context.Context, string) error {
+				return probeErr
+			}
+			reconciler.Config.MaxFailedAttempts = 1
+			reconcileAndGet := func() (reconcile.Result, *infrav1alpha1.Store) {
+				result := reconcileStore(reconciler, key)
+				store := &infrav1alpha1.Store{}
+				Expect(k8sClient.Get(ctx, key, store)).To(Succeed())
+				return result, store
+			}
+			provider, err := engine.Get(engine.EngineWoo)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Create(ctx, &infrav1alpha1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+				Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: "small"},
+			})).To(Succeed())
+			reconcileAndGet()
+			reconcileAndGet()
+			markPodReady(nsName, provider.ReadinessLabels())
+			_, store := reconcileAndGet()
+			Expect(store.Status.Phase).To(Equal(PhaseReady))
+			release, _ := releases.Release(storeName, nsName)
+			revision := release.Revision
+
+			By("staying Ready when the only upgrade attempt fails")
+			releases.InstallErr = fmt.Errorf("chart not found")
+			store.Spec.Chart = &infrav1alpha1.ChartSource{Version: "99.0.0"}
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			result, store := reconcileAndGet()
+			Expect(store.Status.Phase).To(Equal(PhaseReady))
+			Expect(store.Status.Reason).To(Equal(ReasonRetriesExhausted))
+			Expect(store.Status.FailureCount).To(Equal(1))
+			Expect(result.RequeueAfter).To(Equal(reconciler.Config.HealthCheckInterval))
+
+			By("running health checks without further Helm attempts")
+			releases.InstallErr = nil
+			expireBackoff(key)
+			probeErr = fmt.Errorf("returned 500 Internal Server Error")
+			result, store = reconcileAndGet()
+			Expect(store.Status.Phase).To(Equal(PhaseDegraded))
+			Expect(store.Status.Reason).To(Equal(ReasonProbeFailed))
+			Expect(store.Status.FailureCount).To(Equal(1))
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			release, _ = releases.Release(storeName, nsName)
+			Expect(release.Revision).To(Equal(revision))
+
+			By("retrying the upgrade in place once the retry-now annotation is set")
+			probeErr = nil
+			store.Annotations = map[string]string{AnnotationRetryNow: "true"}
+			Expect(k8sClient.Update(ctx, store)).To(Succeed())
+			_, store = reconcileAndGet()
+			Expect(store.Status.Phase).To(Equal(PhaseReady))
+			Expect(store.Status.FailureCount).To(BeZero())
+			Expect(store.Status.ObservedGeneration).To(Equal(store.Generation))
+			release, _ = releases.Release(storeName, nsName)
+			Expect(release.Revision).To(BeNumerically(">", revision))
 		})
 	})
 })
+
+// metricValue reads the sample of a collector carrying labels: a counter or
+// gauge's value, or a histogram's sample count. It is zero until the series
+// exists.
+func metricValue(collector prometheus.Collector, labels prometheus.Labels) float64 {
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+
+	for m := range ch {
+		var sample dto.Metric
+		Expect(m.Write(&sample)).To(Succeed())
+		got := prometheus.Labels{}
+		for _, pair := range sample.GetLabel() {
+			got[pair.GetName()] = pair.GetValue()
+		}
+		if fmt.Sprint(got) != fmt.Sprint(labels) {
+			continue
+		}
+		switch {
+		case sample.Counter != nil:
+			return sample.GetCounter().GetValue()
+		case sample.Gauge != nil:
+			return sample.GetGauge().GetValue()
+		case sample.Histogram != nil:
+			return float64(sample.GetHistogram().GetSampleCount())
+		}
+	}
+	return 0
+}
@@ -0,0 +1,341 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+)
+
+// deletionSnapshotSuffix names the VolumeSnapshots and StoreBackup taken by
+// the Snapshot deletion policy
+const deletionSnapshotSuffix = "-deletion"
+
+var (
+	volumeSnapshotGVK        = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+	volumeSnapshotContentGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshotContent"}
+)
+
+// deletionPolicy returns spec.deletionPolicy, defaulting to Delete
+func deletionPolicy(store *infrav1alpha1.Store) string {
+	if store.Spec.DeletionPolicy == "" {
+		return DeletionPolicyDelete
+	}
+	return store.Spec.DeletionPolicy
+}
+
+// prepareDeletion runs the deletion policy's steps that must happen before
+// the release and namespace are torn down. done is false when the caller
+// should return the given result.
+func (r *StoreReconciler) prepareDeletion(ctx context.Context, store *infrav1alpha1.Store, nsName string) (ctrl.Result, bool, error) {
+	policy := deletionPolicy(store)
+	if policy == DeletionPolicyDelete {
+		return ctrl.Result{}, true, nil
+	}
+
+	var pvcList corev1.PersistentVolumeClaimList
+	if err := r.List(ctx, &pvcList, client.InNamespace(nsName)); err != nil {
+		return ctrl.Result{}, false, err
+	}
+
+	if policy == DeletionPolicyRetain {
+		if err := r.retainVolumes(ctx, store, pvcList.Items); err != nil {
+			return ctrl.Result{}, false, err
+		}
+		return ctrl.Result{}, true, nil
+	}
+
+	if r.volumeSnapshotsSupported() {
+		return r.snapshotVolumes(ctx, store, nsName, pvcList.Items)
+	}
+	return r.snapshotToBackup(ctx, store, nsName)
+}
+
+// retainVolumes switches the PersistentVolumes behind the store's PVCs to the
+// Retain reclaim policy and labels them with the store and claim they served,
+// so tearing down the namespace leaves the data in place
+func (r *StoreReconciler) retainVolumes(ctx context.Context, store *infrav1alpha1.Store, pvcs []corev1.PersistentVolumeClaim) error {
+	logger := log.FromContext(ctx)
+
+	for _, pvc := range pvcs {
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+		var pv corev1.PersistentVolume
+		if err := r.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, &pv); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if pv.Spec.PersistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimRetain &&
+			pv.Labels[LabelRetainedClaim] == pvc.Name {
+			continue
+		}
+
+		patch := client.MergeFrom(pv.DeepCopy())
+		pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+		if pv.Labels == nil {
+			pv.Labels = map[string]string{}
+		}
+		pv.Labels[LabelManagedBy] = ManagedByValue
+		pv.Labels[LabelStoreName] = store.Name
+		pv.Labels[LabelStoreNamespace] = store.Namespace
+		pv.Labels[LabelRetainedClaim] = pvc.Name
+		if err := r.Patch(ctx, &pv, patch); err != nil {
+			return err
+		}
+		logger.Info("Retaining volume", "pv", pv.Name, "pvc", pvc.Name)
+	}
+	return nil
+}
+
+// backupClaims returns the PVCs the store's StoreBackups were written to.
+// Their volumes are retained whatever the deletion policy, so the archives
+// outlive the store and can be restored into a new store of the same name.
+func (r *StoreReconciler) backupClaims(ctx context.Context, store *infrav1alpha1.Store) (map[string]bool, error) {
+	var backups infrav1alpha1.StoreBackupList
+	if err := r.List(ctx, &backups, client.InNamespace(store.Namespace)); err != nil {
+		return nil, err
+	}
+	claims := map[string]bool{}
+	for _, backup := range backups.Items {
+		if backup.Spec.StoreName == store.Name && backup.Spec.Target.PVC != nil {
+			claims[backup.Spec.Target.PVC.ClaimName] = true
+		}
+	}
+	return claims, nil
+}
+
+// detachRetainedVolumes releases the store's retained volumes from their
+// deleted claims. Each keeps the claim's namespace and name, so a new store of
+// the same name binds its PVCs to the old data.
+func (r *StoreReconciler) detachRetainedVolumes(ctx context.Context, store *infrav1alpha1.Store) error {
+	var pvList corev1.PersistentVolumeList
+	if err := r.List(ctx, &pvList, client.MatchingLabels{
+		LabelStoreName:      store.Name,
+		LabelStoreNamespace: store.Namespace,
+	}); err != nil {
+		return err
+	}
+
+	detached := 0
+	for i := range pvList.Items {
+		pv := &pvList.Items[i]
+		if _, ok := pv.Labels[LabelRetainedClaim]; !ok || pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.UID == "" {
+			continue
+		}
+		patch := client.MergeFrom(pv.DeepCopy())
+		pv.Spec.ClaimRef.UID = ""
+		pv.Spec.ClaimRef.ResourceVersion = ""
+		if err := r.Patch(ctx, pv, patch); err != nil {
+			return err
+		}
+		detached++
+	}
+	if detached > 0 {
+		r.Recorder.Eventf(store, corev1.EventTypeNormal, EventReasonRetained,
+			"Retained %d volume(s) for adoption by a new store named %s", detached, store.Name)
+	}
+	return nil
+}
+
+// volumeSnapshotsSupported reports whether the cluster serves the
+// snapshot.storage.k8s.io VolumeSnapshot API
+func (r *StoreReconciler) volumeSnapshotsSupported() bool {
+	_, err := r.RESTMapper().RESTMapping(volumeSnapshotGVK.GroupKind(), volumeSnapshotGVK.Version)
+	return err == nil
+}
+
+// snapshotVolumes takes a VolumeSnapshot of every PVC in the store namespace
+// and waits until each is ready. The snapshots' contents are switched to the
+// Retain deletion policy so they outlive the namespace.
+func (r *StoreReconciler) snapshotVolumes(ctx context.Context, store *infrav1alpha1.Store, nsName string,
+	pvcs []corev1.PersistentVolumeClaim) (ctrl.Result, bool, error) {
+
+	logger := log.FromContext(ctx)
+
+	pending := 0
+	for _, pvc := range pvcs {
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+		key := types.NamespacedName{Name: pvc.Name + deletionSnapshotSuffix, Namespace: nsName}
+		if err := r.Get(ctx, key, snapshot); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, false, err
+			}
+			snapshot = r.buildVolumeSnapshot(store, key, pvc.Name)
+			if err := r.Create(ctx, snapshot); err != nil {
+				return ctrl.Result{}, false, err
+			}
+			logger.Info("Created VolumeSnapshot", "snapshot", key.Name, "pvc", pvc.Name)
+			pending++
+			continue
+		}
+
+		if message, _, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); message != "" {
+			return r.snapshotFailed(ctx, store, fmt.Sprintf("VolumeSnapshot %s failed: %s", key.Name, message))
+		}
+		ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+		content, _, _ := unstructured.NestedString(snapshot.Object, "status", "boundVolumeSnapshotContentName")
+		if !ready || content == "" {
+			pending++
+			continue
+		}
+		if err := r.retainSnapshotContent(ctx, store, content); err != nil {
+			return ctrl.Result{}, false, err
+		}
+	}
+
+	if pending > 0 {
+		return r.snapshotPending(ctx, store, fmt.Sprintf("Waiting for %d VolumeSnapshot(s) before teardown", pending))
+	}
+	if len(pvcs) > 0 {
+		r.Recorder.Eventf(store, corev1.EventTypeNormal, EventReasonSnapshotted,
+			"Took %d VolumeSnapshot(s) before teardown", len(pvcs))
+	}
+	return ctrl.Result{}, true, nil
+}
+
+func (r *StoreReconciler) buildVolumeSnapshot(store *infrav1alpha1.Store, key types.NamespacedName, claimName string) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetName(key.Name)
+	snapshot.SetNamespace(key.Namespace)
+	snapshot.SetLabels(storeNamespaceLabels(store))
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": claimName},
+	}
+	if r.Config.VolumeSnapshotClass != "" {
+		spec["volumeSnapshotClassName"] = r.Config.VolumeSnapshotClass
+	}
+	snapshot.Object["spec"] = spec
+	return snapshot
+}
+
+// retainSnapshotContent keeps a snapshot's content once its VolumeSnapshot is
+// deleted with the namespace, and labels it with the store it came from
+func (r *StoreReconciler) retainSnapshotContent(ctx context.Context, store *infrav1alpha1.Store, name string) error {
+	content := &unstructured.Unstructured{}
+	content.SetGroupVersionKind(volumeSnapshotContentGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, content); err != nil {
+		return err
+	}
+	policy, _, _ := unstructured.NestedString(content.Object, "spec", "deletionPolicy")
+	if policy == DeletionPolicyRetain && content.GetLabels()[LabelStoreName] == store.Name {
+		return nil
+	}
+
+	patch := client.MergeFrom(content.DeepCopy())
+	if err := unstructured.SetNestedField(content.Object, DeletionPolicyRetain, "spec", "deletionPolicy"); err != nil {
+		return err
+	}
+	labels := content.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[LabelManagedBy] = ManagedByValue
+	labels[LabelStoreName] = store.Name
+	labels[LabelStoreNamespace] = store.Namespace
+	content.SetLabels(labels)
+	return r.Patch(ctx, content, patch)
+}
+
+// isDeletionBackup reports whether backup is the final backup the Snapshot
+// deletion policy takes of store while it is being deleted. It runs whatever
+// the store's phase, since the store will never be Ready again.
+func isDeletionBackup(store *infrav1alpha1.Store, backup *infrav1alpha1.StoreBackup) bool {
+	return !store.DeletionTimestamp.IsZero() && backup.Name == store.Name+deletionSnapshotSuffix
+}
+
+// snapshotToBackup takes a final StoreBackup to spec.snapshotTarget, for
+// clusters without VolumeSnapshot support, and waits for it to complete. A
+// suspended store's workloads are scaled back up for the backup to read.
+func (r *StoreReconciler) snapshotToBackup(ctx context.Context, store *infrav1alpha1.Store, nsName string) (ctrl.Result, bool, error) {
+	// A store that never came up has no data worth keeping
+	if store.Status.URL == "" {
+		return ctrl.Result{}, true, nil
+	}
+	if store.Spec.SnapshotTarget == nil {
+		return r.snapshotFailed(ctx, store,
+			"VolumeSnapshots are not available; set spec.snapshotTarget to take a backup, or change spec.deletionPolicy")
+	}
+
+	var backup infrav1alpha1.StoreBackup
+	key := types.NamespacedName{Name: store.Name + deletionSnapshotSuffix, Namespace: store.Namespace}
+	if err := r.Get(ctx, key, &backup); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, false, err
+		}
+		backup = infrav1alpha1.StoreBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    map[string]string{LabelManagedBy: ManagedByValue, LabelStoreName: store.Name},
+			},
+			Spec: infrav1alpha1.StoreBackupSpec{
+				StoreName: store.Name,
+				Target:    infrav1alpha1.BackupTarget{S3: store.Spec.SnapshotTarget.DeepCopy()},
+			},
+		}
+		if store.Spec.Suspended || store.Status.Phase == PhaseSuspended {
+			if err := r.scaleWorkloadsUp(ctx, nsName); err != nil {
+				return ctrl.Result{}, false, err
+			}
+			log.FromContext(ctx).Info("Scaled suspended workloads up for the final backup", "namespace", nsName)
+		}
+		if err := r.Create(ctx, &backup); err != nil {
+			return ctrl.Result{}, false, err
+		}
+		log.FromContext(ctx).Info("Created final StoreBackup", "backup", key.Name)
+	}
+
+	switch backup.Status.Phase {
+	case BackupPhaseCompleted:
+		r.Recorder.Eventf(store, corev1.EventTypeNormal, EventReasonSnapshotted,
+			"Final backup %s written to %s", backup.Name, backup.Status.Location)
+		return ctrl.Result{}, true, nil
+	case BackupPhaseFailed:
+		return r.snapshotFailed(ctx, store, fmt.Sprintf("Final backup %s failed: %s; delete it to try again", backup.Name, backup.Status.Message))
+	}
+	return r.snapshotPending(ctx, store, fmt.Sprintf("Waiting for final backup %s before teardown", backup.Name))
+}
+
+// snapshotPending reports a snapshot in progress and polls for it
+func (r *StoreReconciler) snapshotPending(ctx context.Context, store *infrav1alpha1.Store, message string) (ctrl.Result, bool, error) {
+	if store.Status.Reason != ReasonSnapshotting || store.Status.Message != message {
+		store.Status.Reason = ReasonSnapshotting
+		store.Status.Message = message
+		if err := r.updateStatus(ctx, store); err != nil {
+			log.FromContext(ctx).Error(err, "unable to update Store status")
+			return ctrl.Result{}, false, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, false, nil
+}
+
+// snapshotFailed holds the deletion: the store's data stays in place until
+// the snapshot succeeds or the deletion policy changes
+func (r *StoreReconciler) snapshotFailed(ctx context.Context, store *infrav1alpha1.Store, message string) (ctrl.Result, bool, error) {
+	if store.Status.Reason != ReasonSnapshotFailed || store.Status.Message != message {
+		store.Status.Reason = ReasonSnapshotFailed
+		store.Status.Message = message
+		if err := r.updateStatus(ctx, store); err != nil {
+			log.FromContext(ctx).Error(err, "unable to update Store status")
+			return ctrl.Result{}, false, err
+		}
+		r.Recorder.Event(store, corev1.EventTypeWarning, EventReasonDeleteFailed, message)
+		recordError(ReasonSnapshotFailed)
+	}
+	return ctrl.Result{RequeueAfter: r.Config.DeletionRequeueInterval}, false, nil
+}
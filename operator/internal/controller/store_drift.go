@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/helm"
+)
+
+// detectDrift compares the deployed release with the desired state and
+// returns a description of every difference. An empty result means the
+// release is in sync.
+func (r *StoreReconciler) detectDrift(ctx context.Context, releaseName, nsName string, chart helm.ChartRef,
+	values map[string]interface{}) ([]string, error) {
+
+	release, err := r.Releases.Get(ctx, releaseName, nsName)
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		return []string{"release is not installed"}, nil
+	}
+
+	var drift []string
+	if release.Status != helm.StatusDeployed {
+		drift = append(drift, fmt.Sprintf("release status is %s", release.Status))
+	}
+
+	chartVersion, err := r.Releases.ChartVersion(ctx, chart)
+	if err != nil {
+		return nil, err
+	}
+	if release.ChartVersion != chartVersion {
+		drift = append(drift, fmt.Sprintf("chart version is %s, want %s", release.ChartVersion, chartVersion))
+	}
+
+	keys, err := valuesDrift(release.Values, values)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		drift = append(drift, fmt.Sprintf("values %q changed", key))
+	}
+
+	missing, err := r.missingObjects(ctx, release.Manifest, nsName)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range missing {
+		drift = append(drift, fmt.Sprintf("%s/%s is missing", obj.GetKind(), obj.GetName()))
+	}
+	return drift, nil
+}
+
+// valuesDrift returns the sorted top-level keys whose deployed value differs
+// from the desired one. Both sides go through JSON first so that typed values
+// (int32, maps of strings) compare equal to what Helm stored.
+func valuesDrift(deployed, desired map[string]interface{}) ([]string, error) {
+	a, err := normalizeValues(deployed)
+	if err != nil {
+		return nil, err
+	}
+	b, err := normalizeValues(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for key := range b {
+		if !reflect.DeepEqual(a[key], b[key]) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range a {
+		if _, ok := b[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func normalizeValues(values map[string]interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	normalized := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// missingObjects returns the manifest objects that no longer exist in the
+// cluster. Objects the operator can't read are skipped rather than reported.
+func (r *StoreReconciler) missingObjects(ctx context.Context, manifest, nsName string) ([]*unstructured.Unstructured, error) {
+	logger := log.FromContext(ctx)
+
+	objects, err := helm.ManifestObjects(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []*unstructured.Unstructured
+	for _, obj := range objects {
+		key := types.NamespacedName{Name: obj.GetName()}
+		namespaced, err := r.IsObjectNamespaced(obj)
+		if err != nil {
+			logger.V(1).Info("Skipping drift check for unknown kind", "kind", obj.GetKind(), "name", obj.GetName())
+			continue
+		}
+		if namespaced {
+			key.Namespace = obj.GetNamespace()
+			if key.Namespace == "" {
+				key.Namespace = nsName
+			}
+		}
+
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := r.Get(ctx, key, live); err != nil {
+			if apierrors.IsNotFound(err) {
+				missing = append(missing, obj)
+				continue
+			}
+			logger.V(1).Info("Skipping drift check", "kind", obj.GetKind(), "name", obj.GetName(), "error", err.Error())
+		}
+	}
+	return missing, nil
+}
+
+// setDriftCondition records the outcome of a drift check on the store
+func setDriftCondition(store *infrav1alpha1.Store, status metav1.ConditionStatus, reason string, drift []string) bool {
+	message := "Release matches the desired state"
+	switch reason {
+	case ConditionReasonDriftDetected:
+		message = "Drift detected: " + strings.Join(drift, "; ")
+	case ConditionReasonRepaired:
+		message = "Repaired drift: " + strings.Join(drift, "; ")
+	}
+	return meta.SetStatusCondition(&store.Status.Conditions, metav1.Condition{
+		Type:               ConditionDrifted,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: store.Generation,
+	})
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+)
+
+// ProbeFunc checks an HTTP endpoint and returns an error when it doesn't
+// answer or answers with a server error
+type ProbeFunc func(ctx context.Context, url string) error
+
+// HTTPProbe returns a ProbeFunc that GETs the URL within timeout. Redirects
+// count as healthy and are not followed, since they usually point at the
+// store's public hostname.
+func HTTPProbe(timeout time.Duration) ProbeFunc {
+	httpClient := &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return func(ctx context.Context, url string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("%s returned %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// isServing reports whether a store has been declared Ready and is being
+// health-checked rather than provisioned
+func isServing(phase string) bool {
+	return phase == PhaseReady || phase == PhaseDegraded
+}
+
+// reconcileHealth checks a serving store's workloads and probes its
+// Service. A failing check moves the store to Degraded with a reason; a
+// passing one brings it back to Ready. It reports a status change; the
+// caller persists the status.
+func (r *StoreReconciler) reconcileHealth(ctx context.Context, store *infrav1alpha1.Store, nsName, releaseName string,
+	provider engine.Provider) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	reason, message, err := r.workloadProblem(ctx, nsName)
+	if err != nil {
+		return false, err
+	}
+	changed := false
+	if reason != "" {
+		changed = setCondition(store, ConditionWorkloadReady, metav1.ConditionFalse, reason, message)
+	} else {
+		changed = setCondition(store, ConditionWorkloadReady, metav1.ConditionTrue,
+			ConditionReasonPodsReady, "The engine's pods are ready")
+		reason, message = r.probeProblem(ctx, nsName, releaseName, provider)
+	}
+
+	if reason == "" {
+		if store.Status.Phase == PhaseDegraded {
+			logger.Info("Store recovered", "previousReason", store.Status.Reason)
+			store.Status.Phase = PhaseReady
+			store.Status.Reason = ""
+			store.Status.Message = ""
+			r.Recorder.Event(store, corev1.EventTypeNormal, EventReasonRecovered, "Store is healthy again")
+			changed = true
+		}
+		return changed, nil
+	}
+
+	if store.Status.Phase == PhaseDegraded && store.Status.Reason == reason && store.Status.Message == message {
+		return changed, nil
+	}
+	logger.Info("Store is degraded", "reason", reason, "message", message)
+	if store.Status.Phase != PhaseDegraded || store.Status.Reason != reason {
+		r.Recorder.Eventf(store, corev1.EventTypeWarning, EventReasonDegraded, "Store is degraded: %s", message)
+		recordError(reason)
+	}
+	store.Status.Phase = PhaseDegraded
+	store.Status.Reason = reason
+	store.Status.Message = message
+	return true, nil
+}
+
+// workloadProblem looks for crash-looping containers and for Deployments and
+// StatefulSets short of their desired replicas in the store namespace
+func (r *StoreReconciler) workloadProblem(ctx context.Context, nsName string) (string, string, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(nsName)); err != nil {
+		return "", "", err
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+				return ReasonCrashLooping, fmt.Sprintf("Container %s in pod %s is crash-looping after %d restarts",
+					status.Name, pod.Name, status.RestartCount), nil
+			}
+		}
+	}
+
+	var deployments appsv1.DeploymentList
+	if err := r.List(ctx, &deployments, client.InNamespace(nsName)); err != nil {
+		return "", "", err
+	}
+	for _, d := range deployments.Items {
+		if want := desiredReplicas(d.Spec.Replicas); d.Status.AvailableReplicas < want {
+			return ReasonWorkloadUnavailable, fmt.Sprintf("Deployment %s has %d of %d replicas available",
+				d.Name, d.Status.AvailableReplicas, want), nil
+		}
+	}
+
+	var statefulSets appsv1.StatefulSetList
+	if err := r.List(ctx, &statefulSets, client.InNamespace(nsName)); err != nil {
+		return "", "", err
+	}
+	for _, s := range statefulSets.Items {
+		if want := desiredReplicas(s.Spec.Replicas); s.Status.ReadyReplicas < want {
+			return ReasonWorkloadUnavailable, fmt.Sprintf("StatefulSet %s has %d of %d replicas ready",
+				s.Name, s.Status.ReadyReplicas, want), nil
+		}
+	}
+	return "", "", nil
+}
+
+// probeProblem probes the engine's in-cluster health endpoint
+func (r *StoreReconciler) probeProblem(ctx context.Context, nsName, releaseName string, provider engine.Provider) (string, string) {
+	checker, ok := provider.(engine.HealthChecker)
+	if !ok || r.HealthProbe == nil {
+		return "", ""
+	}
+	endpoint := checker.HealthEndpoint(releaseName)
+	url := fmt.Sprintf("http://%s.%s.svc:%d%s", endpoint.Service, nsName, endpoint.Port, endpoint.Path)
+	if err := r.HealthProbe(ctx, url); err != nil {
+		return ReasonProbeFailed, fmt.Sprintf("HTTP probe failed: %v", err)
+	}
+	return "", ""
+}
+
+// desiredReplicas defaults an unset replica count to one, as the API does
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/helm"
+)
+
+// applyRelease installs or upgrades the store's release and records the
+// revision in status.lastAppliedRevision. Once the store has been Ready, an
+// upgrade waits up to HelmTimeout for its workloads and a failed one is rolled
+// back to status.lastSuccessfulRevision, leaving the store on the good
+// revision with reason UpgradeRolledBack. An upgrade that fails before Helm
+// records a revision leaves the store serving its current one, in its current
+// phase, with reason HelmError. A failed install, or a failed rollback, moves
+// the store to Failed with reason HelmError. Every failure counts against the
+// store's retry budget and is retried with backoff. done is false when the
+// caller should return the given result.
+func (r *StoreReconciler) applyRelease(ctx context.Context, store *infrav1alpha1.Store, releaseName, nsName string,
+	chart helm.ChartRef, values map[string]interface{}) (ctrl.Result, bool, error) {
+
+	logger := log.FromContext(ctx)
+	lastGood := store.Status.LastSuccessfulRevision
+
+	opts := helm.InstallOptions{
+		Wait:    lastGood > 0 && !store.Spec.Suspended,
+		Timeout: r.Config.HelmTimeout,
+	}
+	operation := helmOperationUpgrade
+	if store.Status.LastAppliedRevision == 0 {
+		operation = helmOperationInstall
+	}
+	start := time.Now()
+	revision, err := r.Releases.InstallOrUpgrade(ctx, releaseName, nsName, chart, values, opts)
+	observeHelm(operation, start, err)
+	if err == nil {
+		store.Status.LastAppliedRevision = revision
+		store.Status.FailureCount = 0
+		setCondition(store, ConditionReleaseInstalled, metav1.ConditionTrue, ConditionReasonInstalled, releaseInstalledMessage(store))
+		return ctrl.Result{}, true, nil
+	}
+
+	// Nothing to roll back when the store never went Ready, or when Helm
+	// failed before recording a revision (e.g. the chart couldn't be pulled)
+	if lastGood == 0 || revision <= store.Status.LastAppliedRevision {
+		action := "install"
+		if lastGood > 0 {
+			action = "upgrade"
+		}
+		logger.Error(err, "Helm "+action+" failed")
+		// A store that went Ready still runs its last good revision
+		if lastGood == 0 {
+			store.Status.Phase = PhaseFailed
+		}
+		store.Status.Message = fmt.Sprintf("Helm %s failed: %v", action, err)
+		store.Status.Reason = ReasonHelmError
+		result := r.recordFailure(store)
+		setCondition(store, ConditionReleaseInstalled, metav1.ConditionFalse, ReasonHelmError, store.Status.Message)
+		if err := r.updateStatus(ctx, store); err != nil {
+			logger.Error(err, "unable to update Store status")
+			return ctrl.Result{}, false, err
+		}
+
+		r.Recorder.Eventf(store, corev1.EventTypeWarning, EventReasonFailed, "Helm %s failed: %v", action, err)
+		recordError(ReasonHelmError)
+		r.recordGaveUp(store)
+		return result, false, nil
+	}
+
+	// The upgrade left a failed revision behind; go back to the last good one
+	logger.Error(err, "Helm upgrade failed, rolling back", "revision", revision, "rollbackTo", lastGood)
+	store.Status.LastAppliedRevision = revision
+	start = time.Now()
+	rolledBack, rollbackErr := r.Releases.Rollback(ctx, releaseName, nsName, lastGood)
+	observeHelm(helmOperationRollback, start, rollbackErr)
+	if rollbackErr != nil {
+		logger.Error(rollbackErr, "Helm rollback failed")
+		store.Status.Phase = PhaseFailed
+		store.Status.Reason = ReasonHelmError
+		store.Status.Message = fmt.Sprintf("Helm upgrade failed: %v; rollback to revision %d failed: %v", err, lastGood, rollbackErr)
+		result := r.recordFailure(store)
+		setCondition(store, ConditionReleaseInstalled, metav1.ConditionFalse, ReasonHelmError, store.Status.Message)
+		if err := r.updateStatus(ctx, store); err != nil {
+			logger.Error(err, "unable to update Store status")
+			return ctrl.Result{}, false, err
+		}
+
+		r.Recorder.Eventf(store, corev1.EventTypeWarning, EventReasonFailed,
+			"Helm upgrade failed: %v; rollback to revision %d failed: %v", err, lastGood, rollbackErr)
+		recordError(ReasonHelmError)
+		r.recordGaveUp(store)
+		return result, false, nil
+	}
+
+	// The rollback re-applies the good revision's manifests under a new number;
+	// the failed attempt stays in lastAppliedRevision until an upgrade succeeds
+	store.Status.LastSuccessfulRevision = rolledBack
+	store.Status.Reason = ReasonRolledBack
+	store.Status.Message = fmt.Sprintf("Upgrade to revision %d failed and was rolled back to revision %d: %v", revision, lastGood, err)
+	result := r.recordFailure(store)
+	setCondition(store, ConditionReleaseInstalled, metav1.ConditionFalse, ReasonRolledBack, store.Status.Message)
+	if err := r.updateStatus(ctx, store); err != nil {
+		logger.Error(err, "unable to update Store status")
+		return ctrl.Result{}, false, err
+	}
+
+	r.Recorder.Eventf(store, corev1.EventTypeWarning, EventReasonRolledBack,
+		"Upgrade failed and was rolled back to revision %d: %v", lastGood, err)
+	recordError(ReasonRolledBack)
+	r.recordGaveUp(store)
+	return result, false, nil
+}
+
+// storeChart applies spec.chart to the engine's default chart. A URL replaces
+// the whole source; a version on its own pins the engine's chart.
+func storeChart(store *infrav1alpha1.Store, chart helm.ChartRef) helm.ChartRef {
+	override := store.Spec.Chart
+	if override == nil {
+		return chart
+	}
+	if override.URL != "" {
+		chart = helm.ChartRef{URL: override.URL, Name: override.Name}
+	}
+	if override.Version != "" {
+		chart.Version = override.Version
+	}
+	return chart
+}
+
+// releaseInstalledMessage describes the revision the store runs
+func releaseInstalledMessage(store *infrav1alpha1.Store) string {
+	if store.Status.LastAppliedRevision == 0 {
+		return "Release is deployed"
+	}
+	return fmt.Sprintf("Revision %d is deployed", store.Status.LastAppliedRevision)
+}
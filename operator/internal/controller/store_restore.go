@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+)
+
+// reconcileRestore drives spec.restoreFrom. It returns done once the backup has
+// been loaded; until then the caller returns the given result and error, which
+// keeps the store out of the Ready phase.
+func (r *StoreReconciler) reconcileRestore(ctx context.Context, store *infrav1alpha1.Store, nsName string,
+	provider engine.Provider) (ctrl.Result, bool, error) {
+
+	logger := log.FromContext(ctx)
+	backupName := store.Spec.RestoreFrom.BackupName
+
+	// A new backup name starts a fresh restore
+	if store.Status.Restore == nil || store.Status.Restore.BackupName != backupName {
+		store.Status.Restore = &infrav1alpha1.RestoreStatus{BackupName: backupName}
+	}
+	restore := store.Status.Restore
+
+	switch restore.Phase {
+	case RestorePhaseCompleted:
+		return ctrl.Result{}, true, nil
+	case RestorePhaseFailed:
+		// Terminal until restoreFrom points at another backup
+		return ctrl.Result{}, false, nil
+	}
+
+	jobName := restoreJobName(backupName)
+	var job batchv1.Job
+	err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: nsName}, &job)
+	if apierrors.IsNotFound(err) {
+		result, err := r.startRestore(ctx, store, nsName, provider)
+		return result, false, err
+	} else if err != nil {
+		return ctrl.Result{}, false, err
+	}
+
+	condition, message, finished := jobFinished(&job)
+	if !finished {
+		// The Job watch wakes us on completion; polling covers missed events
+		return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, false, nil
+	}
+
+	// The env Secret holds credentials; don't leave it behind
+	if err := deleteJobSecret(ctx, r.Client, nsName, jobName); err != nil {
+		return ctrl.Result{}, false, err
+	}
+
+	if condition == batchv1.JobFailed {
+		return ctrl.Result{}, false, r.failRestore(ctx, store, fmt.Sprintf("Restore Job failed: %s", message))
+	}
+
+	logger.Info("Restore completed", "backup", backupName)
+	now := metav1.Now()
+	restore.Phase = RestorePhaseCompleted
+	restore.CompletionTime = &now
+	r.Recorder.Eventf(store, corev1.EventTypeNormal, EventReasonRestored, "Restored from StoreBackup %s", backupName)
+
+	// The caller persists the status together with the Ready phase
+	return ctrl.Result{}, true, nil
+}
+
+// startRestore checks the backup and creates the env Secret and restore Job
+func (r *StoreReconciler) startRestore(ctx context.Context, store *infrav1alpha1.Store, nsName string,
+	provider engine.Provider) (ctrl.Result, error) {
+
+	logger := log.FromContext(ctx)
+	backupName := store.Spec.RestoreFrom.BackupName
+
+	var backup infrav1alpha1.StoreBackup
+	if err := r.Get(ctx, types.NamespacedName{Name: backupName, Namespace: store.Namespace}, &backup); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, r.failRestore(ctx, store, fmt.Sprintf("StoreBackup %q does not exist", backupName))
+		}
+		return ctrl.Result{}, err
+	}
+
+	switch backup.Status.Phase {
+	case BackupPhaseCompleted:
+	case BackupPhaseFailed:
+		return ctrl.Result{}, r.failRestore(ctx, store, fmt.Sprintf("StoreBackup %q failed: %s", backupName, backup.Status.Message))
+	default:
+		if store.Status.Reason != ReasonWaitingBackup {
+			store.Status.Phase = PhaseProvisioning
+			store.Status.Reason = ReasonWaitingBackup
+			store.Status.Message = fmt.Sprintf("Waiting for StoreBackup %q to complete", backupName)
+			if err := r.updateStatus(ctx, store); err != nil {
+				logger.Error(err, "unable to update Store status")
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, nil
+	}
+
+	dataProvider, ok := provider.(engine.DataProvider)
+	if !ok {
+		return ctrl.Result{}, r.failRestore(ctx, store, fmt.Sprintf("Engine %q does not support restores", store.Spec.Engine))
+	}
+	data := dataProvider.DataSpec(store.Name, r.Config)
+
+	// A PVC archive lives in the source store's namespace and can't be mounted elsewhere
+	if backup.Spec.Target.PVC != nil && backup.Spec.StoreName != store.Name {
+		return ctrl.Result{}, r.failRestore(ctx, store, fmt.Sprintf(
+			"StoreBackup %q was written to a PVC in store %q; only S3 backups can be restored into another store",
+			backupName, backup.Spec.StoreName))
+	}
+
+	secretData, reason, err := dataJobSecretData(ctx, r.Client, store, &backup, data)
+	if err != nil {
+		if reason != "" {
+			return ctrl.Result{}, r.failRestore(ctx, store, err.Error())
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Stores without persistence only get their database back
+	var pvc corev1.PersistentVolumeClaim
+	hasContent := true
+	if err := r.Get(ctx, types.NamespacedName{Name: data.ContentClaim, Namespace: nsName}, &pvc); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		hasContent = false
+	}
+
+	jobName := restoreJobName(backupName)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: nsName,
+			Labels:    storeLabels(store),
+		},
+		Data: secretData,
+	}
+	if err := r.Create(ctx, secret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+
+	job := buildRestoreJob(store, &backup, nsName, data, hasContent, contentPodLabels(store, provider), r.Config.BackupUploaderImage, int32(r.Config.BackupJobBackoffLimit))
+	if err := r.Create(ctx, job); err != nil && !apierrors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Started restore Job", "job", jobName, "namespace", nsName, "content", hasContent)
+
+	now := metav1.Now()
+	store.Status.Restore.Phase = RestorePhaseRunning
+	store.Status.Restore.JobName = jobName
+	store.Status.Restore.StartTime = &now
+	store.Status.Phase = PhaseProvisioning
+	store.Status.Reason = ReasonRestoring
+	store.Status.Message = fmt.Sprintf("Restoring from StoreBackup %q", backupName)
+	if err := r.updateStatus(ctx, store); err != nil {
+		logger.Error(err, "unable to update Store status")
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Eventf(store, corev1.EventTypeNormal, EventReasonRestoring, "Restoring from StoreBackup %s", backupName)
+	return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, nil
+}
+
+// failRestore marks the restore and the Store as Failed with ReasonRestoreFailed
+func (r *StoreReconciler) failRestore(ctx context.Context, store *infrav1alpha1.Store, message string) error {
+	now := metav1.Now()
+	store.Status.Restore.Phase = RestorePhaseFailed
+	store.Status.Restore.CompletionTime = &now
+	store.Status.Phase = PhaseFailed
+	store.Status.Reason = ReasonRestoreFailed
+	store.Status.Message = message
+	if err := r.updateStatus(ctx, store); err != nil {
+		log.FromContext(ctx).Error(err, "unable to update Store status")
+		return err
+	}
+	r.Recorder.Event(store, corev1.EventTypeWarning, EventReasonFailed, message)
+	recordError(ReasonRestoreFailed)
+	return nil
+}
+
+// storeForJob maps a restore Job event back to its Store
+func storeForJob(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	name, ok := labels[LabelStoreName]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: name, Namespace: labels[LabelStoreNamespace]},
+	}}
+}
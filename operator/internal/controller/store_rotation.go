@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+)
+
+// rotationWait returns how long until spec.credentials.rotateAfter elapses.
+// ok is false when no interval is configured.
+func rotationWait(store *infrav1alpha1.Store, now time.Time) (time.Duration, bool) {
+	if store.Spec.Credentials == nil || store.Spec.Credentials.RotateAfter == nil {
+		return 0, false
+	}
+	// Credentials are generated with the store, so creation is the first baseline
+	last := store.CreationTimestamp.Time
+	if store.Status.CredentialsRotatedAt != nil {
+		last = store.Status.CredentialsRotatedAt.Time
+	}
+	return last.Add(store.Spec.Credentials.RotateAfter.Duration).Sub(now), true
+}
+
+// rotationDue reports whether the annotation or the rotation interval asks for new passwords
+func rotationDue(store *infrav1alpha1.Store, now time.Time) bool {
+	if _, ok := store.Annotations[AnnotationRotateCredentials]; ok {
+		return true
+	}
+	wait, ok := rotationWait(store, now)
+	return ok && wait <= 0
+}
+
+// reconcileRotation changes a running store's passwords. The new values live
+// in the rotation Job's env Secret until the Job has applied them, so a failed
+// or interrupted rotation never leaves the -creds Secret out of step with the
+// database. It returns done once reconciliation may continue, and rotated when
+// creds now hold new passwords that the chart must be upgraded with.
+func (r *StoreReconciler) reconcileRotation(ctx context.Context, store *infrav1alpha1.Store, nsName string,
+	provider engine.Provider, creds map[string]string) (ctrl.Result, bool, bool, error) {
+
+	logger := log.FromContext(ctx)
+	jobName := rotationJobName(store)
+
+	// A suspended store has no database to talk to; pick the rotation up on resume
+	if store.Spec.Suspended {
+		return ctrl.Result{}, true, false, nil
+	}
+
+	var jobSecret corev1.Secret
+	err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: nsName}, &jobSecret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, false, false, err
+	}
+	inProgress := err == nil
+	if !inProgress && (store.Status.Phase != PhaseReady || !rotationDue(store, time.Now())) {
+		return ctrl.Result{}, true, false, nil
+	}
+
+	rotator, canRotate := provider.(engine.CredentialRotator)
+	dataProvider, hasData := provider.(engine.DataProvider)
+	if !canRotate || !hasData {
+		if store.Status.Reason != ReasonRotationFailed {
+			store.Status.Reason = ReasonRotationFailed
+			store.Status.Message = fmt.Sprintf("Engine %q does not support credential rotation", store.Spec.Engine)
+			if err := r.updateStatus(ctx, store); err != nil {
+				logger.Error(err, "unable to update Store status")
+				return ctrl.Result{}, false, false, err
+			}
+			r.Recorder.Event(store, corev1.EventTypeWarning, EventReasonFailed, store.Status.Message)
+			recordError(ReasonRotationFailed)
+		}
+		return ctrl.Result{}, true, false, nil
+	}
+	data := dataProvider.DataSpec(store.Name, r.Config)
+
+	// 1. Generate the new passwords next to the current ones
+	if !inProgress {
+		next, err := generateCredentials(provider)
+		if err != nil {
+			return ctrl.Result{}, false, false, err
+		}
+		secretData := make(map[string][]byte)
+		for _, key := range provider.CredentialKeys() {
+			name := engine.CredentialEnvName(key.Name)
+			secretData["OLD_"+name] = []byte(creds[key.Name])
+			secretData["NEW_"+name] = []byte(next[key.Name])
+		}
+		jobSecret = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      jobName,
+				Namespace: nsName,
+				Labels:    storeLabels(store),
+			},
+			Data: secretData,
+		}
+		if err := r.Create(ctx, &jobSecret); err != nil {
+			return ctrl.Result{}, false, false, err
+		}
+	}
+
+	// 2. Run the password change Job
+	var job batchv1.Job
+	if err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: nsName}, &job); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, false, false, err
+		}
+		// Failed rotations share the store's backoff and failure budget; once
+		// it is spent the store keeps its old passwords until retry-now is set
+		if r.retriesExhausted(store) {
+			logger.V(1).Info("Store has no retries left, skipping credential rotation", "failureCount", store.Status.FailureCount)
+			return ctrl.Result{}, true, false, nil
+		}
+		if wait := r.backoffRemaining(store, time.Now()); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, false, false, nil
+		}
+		job := buildRotationJob(store, nsName, data, provider.CredentialKeys(), rotator.RotationScript(store), int32(r.Config.BackupJobBackoffLimit))
+		if err := r.Create(ctx, job); err != nil && !apierrors.IsAlreadyExists(err) {
+			return ctrl.Result{}, false, false, err
+		}
+
+		logger.Info("Started credential rotation Job", "job", jobName, "namespace", nsName)
+		store.Status.Reason = ReasonRotating
+		store.Status.Message = "Rotating credentials"
+		if err := r.updateStatus(ctx, store); err != nil {
+			logger.Error(err, "unable to update Store status")
+			return ctrl.Result{}, false, false, err
+		}
+		return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, false, false, nil
+	}
+
+	condition, message, finished := jobFinished(&job)
+	if !finished {
+		return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, false, false, nil
+	}
+
+	if condition == batchv1.JobFailed {
+		// Keep the env Secret: the retry reuses the same new passwords, which
+		// the script handles if the first attempt got part of the way
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: nsName}}
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, false, false, err
+		}
+		store.Status.Reason = ReasonRotationFailed
+		store.Status.Message = fmt.Sprintf("Credential rotation Job failed: %s", message)
+		result := r.recordFailure(store)
+		if err := r.updateStatus(ctx, store); err != nil {
+			logger.Error(err, "unable to update Store status")
+			return ctrl.Result{}, false, false, err
+		}
+		r.Recorder.Eventf(store, corev1.EventTypeWarning, EventReasonFailed, "Credential rotation failed: %s", message)
+		recordError(ReasonRotationFailed)
+		r.recordGaveUp(store)
+		return result, false, false, nil
+	}
+
+	// 3. The database has the new passwords: persist them for the chart
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: store.Name + "-creds", Namespace: store.Namespace}, &secret); err != nil {
+		return ctrl.Result{}, false, false, err
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	for _, key := range provider.CredentialKeys() {
+		password := jobSecret.Data["NEW_"+engine.CredentialEnvName(key.Name)]
+		secret.Data[key.Name] = password
+		creds[key.Name] = string(password)
+	}
+	if err := r.Update(ctx, &secret); err != nil {
+		return ctrl.Result{}, false, false, err
+	}
+
+	if err := deleteDataJob(ctx, r.Client, nsName, jobName); err != nil {
+		return ctrl.Result{}, false, false, err
+	}
+
+	now := metav1.Now()
+	store.Status.CredentialsRotatedAt = &now
+	store.Status.FailureCount = 0
+	store.Status.Reason = ""
+	store.Status.Message = ""
+	if err := r.updateStatus(ctx, store); err != nil {
+		logger.Error(err, "unable to update Store status")
+		return ctrl.Result{}, false, false, err
+	}
+
+	if _, ok := store.Annotations[AnnotationRotateCredentials]; ok {
+		delete(store.Annotations, AnnotationRotateCredentials)
+		if err := r.Update(ctx, store); err != nil {
+			return ctrl.Result{}, false, false, err
+		}
+	}
+
+	logger.Info("Credentials rotated", "store", store.Name)
+	r.Recorder.Event(store, corev1.EventTypeNormal, EventReasonRotated, "Store credentials rotated")
+	return ctrl.Result{}, true, true, nil
+}
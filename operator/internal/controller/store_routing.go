@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+)
+
+var gatewayGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "Gateway"}
+
+// storeURL derives the store's public URL from its active routing mode. An
+// Ingress serves plain HTTP; in Gateway mode the scheme and port come from
+// the Gateway listener the HTTPRoute attaches to.
+func (r *StoreReconciler) storeURL(ctx context.Context, store *infrav1alpha1.Store, hostname string) string {
+	routing := engine.ResolveRouting(store, r.Config)
+	if routing.Mode != engine.RoutingModeGateway {
+		return "http://" + hostname
+	}
+
+	gateway := &unstructured.Unstructured{}
+	gateway.SetGroupVersionKind(gatewayGVK)
+	key := types.NamespacedName{Name: routing.Gateway.Name, Namespace: routing.Gateway.Namespace}
+	if err := r.Get(ctx, key, gateway); err != nil {
+		log.FromContext(ctx).V(1).Info("Gateway not readable, assuming plain HTTP", "gateway", key.String(), "error", err.Error())
+		return "http://" + hostname
+	}
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	return gatewayURL(listeners, routing.Gateway.SectionName, hostname)
+}
+
+// gatewayURL builds the URL served by the named listener or, without a
+// section name, by the Gateway's first HTTPS listener or else its first one
+func gatewayURL(listeners []interface{}, sectionName, hostname string) string {
+	var chosen map[string]interface{}
+	for _, l := range listeners {
+		listener, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if sectionName != "" {
+			if listener["name"] == sectionName {
+				chosen = listener
+				break
+			}
+			continue
+		}
+		if chosen == nil || (listener["protocol"] == "HTTPS" && chosen["protocol"] != "HTTPS") {
+			chosen = listener
+		}
+	}
+	if chosen == nil {
+		return "http://" + hostname
+	}
+
+	scheme, defaultPort := "http", int64(80)
+	if chosen["protocol"] == "HTTPS" {
+		scheme, defaultPort = "https", 443
+	}
+	if port, found, _ := unstructured.NestedInt64(chosen, "port"); found && port != defaultPort {
+		return fmt.Sprintf("%s://%s:%d", scheme, hostname, port)
+	}
+	return fmt.Sprintf("%s://%s", scheme, hostname)
+}
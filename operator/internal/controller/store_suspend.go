@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+)
+
+// reconcileSuspended keeps a suspended store scaled to zero. The namespace,
+// credentials and PVCs are left alone so the store resumes with its data.
+// statusChanged asks for the status to be written even without a transition.
+func (r *StoreReconciler) reconcileSuspended(ctx context.Context, store *infrav1alpha1.Store, nsName string, statusChanged bool) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if err := r.scaleWorkloadsToZero(ctx, nsName); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if store.Status.Phase != PhaseSuspended {
+		logger.Info("Store suspended", "namespace", nsName)
+		store.Status.Phase = PhaseSuspended
+		store.Status.Reason = ReasonSuspended
+		store.Status.Message = "Workloads scaled to zero; data is retained"
+		if err := r.updateStatus(ctx, store); err != nil {
+			logger.Error(err, "unable to update Store status")
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(store, corev1.EventTypeNormal, EventReasonSuspended, "Store %s suspended", store.Name)
+	} else if statusChanged {
+		if err := r.updateStatus(ctx, store); err != nil {
+			logger.Error(err, "unable to update Store status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// scaleWorkloadsToZero scales every Deployment and StatefulSet in the store
+// namespace to zero. Charts can't always do this themselves (the MariaDB
+// StatefulSet has a fixed replica count); the next Helm upgrade scales them back.
+func (r *StoreReconciler) scaleWorkloadsToZero(ctx context.Context, namespace string) error {
+	return r.scaleWorkloads(ctx, namespace, 0, func(current int32) bool { return current != 0 })
+}
+
+// scaleWorkloadsUp brings the Deployments and StatefulSets a suspension
+// scaled to zero back to one replica, for a store that is being deleted and
+// has no Helm upgrade coming to do it
+func (r *StoreReconciler) scaleWorkloadsUp(ctx context.Context, namespace string) error {
+	return r.scaleWorkloads(ctx, namespace, 1, func(current int32) bool { return current == 0 })
+}
+
+// scaleWorkloads sets the replicas of the namespace's Deployments and
+// StatefulSets whose current count change accepts
+func (r *StoreReconciler) scaleWorkloads(ctx context.Context, namespace string, replicas int32, change func(int32) bool) error {
+	current := func(replicas *int32) int32 {
+		if replicas == nil {
+			return 1
+		}
+		return *replicas
+	}
+
+	var deployments appsv1.DeploymentList
+	if err := r.List(ctx, &deployments, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		if !change(current(d.Spec.Replicas)) {
+			continue
+		}
+		patch := client.MergeFrom(d.DeepCopy())
+		d.Spec.Replicas = &replicas
+		if err := r.Patch(ctx, d, patch); err != nil {
+			return err
+		}
+	}
+
+	var statefulSets appsv1.StatefulSetList
+	if err := r.List(ctx, &statefulSets, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		s := &statefulSets.Items[i]
+		if !change(current(s.Spec.Replicas)) {
+			continue
+		}
+		patch := client.MergeFrom(s.DeepCopy())
+		s.Spec.Replicas = &replicas
+		if err := r.Patch(ctx, s, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+)
+
+// sampleUsage records the store namespace's quota usage and PVC sizes in
+// status.usage, at most once per UsageSampleInterval, and exports the latest
+// sample as gauges. It reports a status change; the caller persists the status.
+func (r *StoreReconciler) sampleUsage(ctx context.Context, store *infrav1alpha1.Store, nsName string) (bool, error) {
+	if usage := store.Status.Usage; usage != nil && time.Since(usage.SampledAt.Time) < r.Config.UsageSampleInterval {
+		// Still fresh; the gauges are restored after an operator restart
+		recordUsage(store, usage)
+		return false, nil
+	}
+
+	quota := &corev1.ResourceQuota{}
+	if err := r.Get(ctx, clientObjectKey(nsName, ResourceQuotaName), quota); client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	var pvcs corev1.PersistentVolumeClaimList
+	if err := r.List(ctx, &pvcs, client.InNamespace(nsName)); err != nil {
+		return false, err
+	}
+
+	storage := resource.NewQuantity(0, resource.BinarySI)
+	usage := &infrav1alpha1.StoreUsage{
+		Used:      quota.Status.Used.DeepCopy(),
+		Hard:      quota.Spec.Hard.DeepCopy(),
+		Storage:   storage,
+		SampledAt: metav1.Now(),
+	}
+	for _, pvc := range pvcs.Items {
+		size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		storage.Add(size)
+		usage.Volumes = append(usage.Volumes, infrav1alpha1.VolumeUsage{Name: pvc.Name, Size: size})
+	}
+
+	store.Status.Usage = usage
+	recordUsage(store, usage)
+	return true, nil
+}
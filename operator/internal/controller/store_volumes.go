@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+)
+
+// reconcileVolumes grows the store's existing PVCs to the plan's sizes and
+// returns the plan the release should be rendered with. A volume that can't
+// grow keeps its current size and storage class in the rendered values, so
+// the upgrade doesn't trip over an immutable field; the VolumesResized
+// condition says why. When an upgrade is due, StatefulSets whose claim
+// templates no longer match are deleted without their pods so the upgrade
+// can recreate them. The bool reports a condition change.
+func (r *StoreReconciler) reconcileVolumes(ctx context.Context, store *infrav1alpha1.Store, nsName, releaseName string,
+	provider engine.Provider, plan *infrav1alpha1.StorePlanSpec, upgrading bool) (*infrav1alpha1.StorePlanSpec, bool, error) {
+	volumes, ok := provider.(engine.VolumeProvider)
+	if !ok {
+		return plan, false, nil
+	}
+
+	render := plan.DeepCopy()
+	var blocked []string
+	claims := 0
+	for _, v := range volumes.Volumes(releaseName) {
+		p := engine.VolumePersistence(render, v)
+		if !engine.PersistenceEnabled(p, r.Config) {
+			continue
+		}
+
+		pvc := &corev1.PersistentVolumeClaim{}
+		err := r.Get(ctx, types.NamespacedName{Name: v.Claim, Namespace: nsName}, pvc)
+		switch {
+		case apierrors.IsNotFound(err):
+			// Not created yet; the release creates it at the plan's size
+		case err != nil:
+			return nil, false, err
+		default:
+			claims++
+			reasons, err := r.resizeClaim(ctx, store, pvc, p)
+			if err != nil {
+				return nil, false, err
+			}
+			blocked = append(blocked, reasons...)
+		}
+
+		if v.StatefulSet != "" && upgrading {
+			if err := r.refreshClaimTemplate(ctx, nsName, v, p); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	// New stores report the condition once their volumes exist
+	if claims == 0 {
+		return render, false, nil
+	}
+	if len(blocked) > 0 {
+		msg := strings.Join(blocked, "; ")
+		changed := setCondition(store, ConditionVolumesResized, metav1.ConditionFalse, ConditionReasonExpansionBlocked, msg)
+		if changed {
+			r.Recorder.Eventf(store, corev1.EventTypeWarning, EventReasonExpansionBlocked, "Volumes can't follow the plan: %s", msg)
+		}
+		return render, changed, nil
+	}
+	return render, setCondition(store, ConditionVolumesResized, metav1.ConditionTrue,
+		ConditionReasonSized, "Volumes match the plan's persistence settings"), nil
+}
+
+// resizeClaim expands a PVC to the plan's size when its StorageClass allows
+// it. Otherwise it points p at the claim's current size and class, and
+// returns why the claim can't follow the plan.
+func (r *StoreReconciler) resizeClaim(ctx context.Context, store *infrav1alpha1.Store,
+	pvc *corev1.PersistentVolumeClaim, p *infrav1alpha1.PlanPersistence) ([]string, error) {
+	logger := log.FromContext(ctx)
+	var blocked []string
+
+	// A claim's StorageClass is immutable
+	if class := pvc.Spec.StorageClassName; class != nil && p.StorageClassName != "" && p.StorageClassName != *class {
+		blocked = append(blocked, fmt.Sprintf("%s stays on StorageClass %s instead of %s", pvc.Name, *class, p.StorageClassName))
+		p.StorageClassName = *class
+	}
+	// and so are its access modes
+	if len(p.AccessModes) > 0 && !slices.Equal(p.AccessModes, pvc.Spec.AccessModes) {
+		blocked = append(blocked, fmt.Sprintf("%s keeps access modes %v instead of %v", pvc.Name, pvc.Spec.AccessModes, p.AccessModes))
+		p.AccessModes = pvc.Spec.AccessModes
+	}
+	if p.Size == nil {
+		return blocked, nil
+	}
+
+	current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	switch p.Size.Cmp(current) {
+	case 0:
+		return blocked, nil
+	case -1:
+		blocked = append(blocked, fmt.Sprintf("%s can't shrink from %s to %s", pvc.Name, current.String(), p.Size.String()))
+		p.Size = &current
+		return blocked, nil
+	}
+
+	// Only bound claims can be resized
+	if pvc.Status.Phase != corev1.ClaimBound {
+		blocked = append(blocked, fmt.Sprintf("%s can't grow from %s to %s until it is bound",
+			pvc.Name, current.String(), p.Size.String()))
+		p.Size = &current
+		return blocked, nil
+	}
+
+	expandable, err := r.storageClassExpands(ctx, pvc)
+	if err != nil {
+		return nil, err
+	}
+	if !expandable {
+		blocked = append(blocked, fmt.Sprintf("%s can't grow from %s to %s: its StorageClass doesn't allow volume expansion",
+			pvc.Name, current.String(), p.Size.String()))
+		p.Size = &current
+		return blocked, nil
+	}
+
+	logger.Info("Expanding PVC", "pvc", pvc.Name, "from", current.String(), "to", p.Size.String())
+	patch := client.MergeFrom(pvc.DeepCopy())
+	if pvc.Spec.Resources.Requests == nil {
+		pvc.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = *p.Size
+	if err := r.Patch(ctx, pvc, patch); err != nil {
+		return nil, err
+	}
+	r.Recorder.Eventf(store, corev1.EventTypeNormal, EventReasonVolumeExpanded,
+		"Expanding PVC %s from %s to %s", pvc.Name, current.String(), p.Size.String())
+	return blocked, nil
+}
+
+// storageClassExpands reports whether a PVC's StorageClass allows volume expansion
+func (r *StoreReconciler) storageClassExpands(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	class := &storagev1.StorageClass{}
+	if err := r.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, class); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return class.AllowVolumeExpansion != nil && *class.AllowVolumeExpansion, nil
+}
+
+// refreshClaimTemplate deletes a StatefulSet, leaving its pods and PVCs in
+// place, when its claim template is missing or sized differently from p.
+// StatefulSet claim templates are immutable; the upgrade recreates it.
+func (r *StoreReconciler) refreshClaimTemplate(ctx context.Context, nsName string, v engine.Volume, p *infrav1alpha1.PlanPersistence) error {
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Name: v.StatefulSet, Namespace: nsName}, sts); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !sts.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	var template *corev1.PersistentVolumeClaim
+	for i := range sts.Spec.VolumeClaimTemplates {
+		if t := &sts.Spec.VolumeClaimTemplates[i]; fmt.Sprintf("%s-%s-0", t.Name, sts.Name) == v.Claim {
+			template = t
+		}
+	}
+	if template != nil {
+		size := template.Spec.Resources.Requests[corev1.ResourceStorage]
+		if p == nil || p.Size == nil || p.Size.Cmp(size) == 0 {
+			return nil
+		}
+	}
+
+	log.FromContext(ctx).Info("Recreating StatefulSet for its new claim template", "statefulset", sts.Name)
+	return client.IgnoreNotFound(r.Delete(ctx, sts, client.PropagationPolicy(metav1.DeletePropagationOrphan)))
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+)
+
+// storeNamespaceLabels mark a store namespace with the Store that owns it
+func storeNamespaceLabels(store *infrav1alpha1.Store) map[string]string {
+	return map[string]string{
+		LabelManagedBy:      ManagedByValue,
+		LabelStoreName:      store.Name,
+		LabelStoreNamespace: store.Namespace,
+	}
+}
+
+// workloadLabels go on every pod in a store namespace; the manager caches
+// only Pods and Deployments that carry them
+func workloadLabels(store *infrav1alpha1.Store) map[string]string {
+	return map[string]string{
+		LabelStoreName:      store.Name,
+		LabelStoreNamespace: store.Namespace,
+	}
+}
+
+// WorkloadCache limits the manager's Pod and Deployment informers to objects
+// carrying workloadLabels, so the watches don't cache every pod in the cluster
+func WorkloadCache() (map[client.Object]cache.ByObject, error) {
+	req, err := labels.NewRequirement(LabelStoreName, selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+	byLabel := cache.ByObject{Label: labels.NewSelector().Add(*req)}
+	return map[client.Object]cache.ByObject{
+		&corev1.Pod{}:        byLabel,
+		&appsv1.Deployment{}: byLabel,
+	}, nil
+}
+
+// storeForWorkload maps a Pod or Deployment event in a store namespace to the
+// Store named by the namespace's labels
+func (r *StoreReconciler) storeForWorkload(ctx context.Context, obj client.Object) []reconcile.Request {
+	if !strings.HasPrefix(obj.GetNamespace(), StoreNamespacePrefix) {
+		return nil
+	}
+	var ns corev1.Namespace
+	if err := r.Get(ctx, types.NamespacedName{Name: obj.GetNamespace()}, &ns); err != nil {
+		return nil
+	}
+	name, ok := ns.Labels[LabelStoreName]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: name, Namespace: ns.Labels[LabelStoreNamespace]},
+	}}
+}
+
+// inStoreNamespace skips workloads outside store namespaces before they are mapped
+var inStoreNamespace = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	return strings.HasPrefix(obj.GetNamespace(), StoreNamespacePrefix)
+})
+
+// podReadinessChanged passes Pod creates and deletes and updates that flip
+// the Pod's phase or Ready condition; other status churn doesn't matter here
+var podReadinessChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldPod, ok := e.ObjectOld.(*corev1.Pod)
+		if !ok {
+			return true
+		}
+		newPod, ok := e.ObjectNew.(*corev1.Pod)
+		if !ok {
+			return true
+		}
+		return oldPod.Status.Phase != newPod.Status.Phase || isPodReadyCondition(oldPod) != isPodReadyCondition(newPod)
+	},
+}
+
+// deploymentAvailabilityChanged passes Deployment creates and deletes and
+// updates that change the desired or ready replica counts
+var deploymentAvailabilityChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldDeploy, ok := e.ObjectOld.(*appsv1.Deployment)
+		if !ok {
+			return true
+		}
+		newDeploy, ok := e.ObjectNew.(*appsv1.Deployment)
+		if !ok {
+			return true
+		}
+		return !equalReplicas(oldDeploy.Spec.Replicas, newDeploy.Spec.Replicas) ||
+			oldDeploy.Status.ReadyReplicas != newDeploy.Status.ReadyReplicas ||
+			oldDeploy.Status.AvailableReplicas != newDeploy.Status.AvailableReplicas
+	},
+}
+
+func isPodReadyCondition(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func equalReplicas(a, b *int32) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
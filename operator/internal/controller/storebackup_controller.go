@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/config"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+)
+
+// StoreBackupReconciler reconciles a StoreBackup object
+type StoreBackupReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Config   *config.OperatorConfig
+}
+
+// +kubebuilder:rbac:groups=infra.store.io,resources=storebackups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=infra.store.io,resources=storebackups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=infra.store.io,resources=storebackups/finalizers,verbs=update
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+func (r *StoreBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var backup infrav1alpha1.StoreBackup
+	if err := r.Get(ctx, req.NamespacedName, &backup); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	nsName := StoreNamespacePrefix + backup.Spec.StoreName
+	jobName := backupJobName(&backup)
+
+	// 1. DELETE LOGIC: the Job lives in the store namespace, so clean it up by hand
+	if !backup.DeletionTimestamp.IsZero() {
+		if containsString(backup.Finalizers, backupFinalizer) {
+			if err := deleteDataJob(ctx, r.Client, nsName, jobName); err != nil {
+				return ctrl.Result{}, err
+			}
+			backup.Finalizers = removeString(backup.Finalizers, backupFinalizer)
+			if err := r.Update(ctx, &backup); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !containsString(backup.Finalizers, backupFinalizer) {
+		backup.Finalizers = append(backup.Finalizers, backupFinalizer)
+		if err := r.Update(ctx, &backup); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Backups are one-shot
+	if backup.Status.Phase == BackupPhaseCompleted || backup.Status.Phase == BackupPhaseFailed {
+		return ctrl.Result{}, nil
+	}
+
+	// 2. START: create the Job if it doesn't exist yet
+	var job batchv1.Job
+	err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: nsName}, &job)
+	if apierrors.IsNotFound(err) {
+		return r.startBackup(ctx, &backup, nsName)
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// 3. OBSERVE: report progress until the Job finishes
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(nsName), client.MatchingLabels{batchv1.JobNameLabel: jobName}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	condition, message, finished := jobFinished(&job)
+	if !finished {
+		progress := jobProgress(pods.Items)
+		if progress != "" && progress != backup.Status.Progress {
+			backup.Status.Progress = progress
+			if err := r.Status().Update(ctx, &backup); err != nil {
+				logger.Error(err, "unable to update StoreBackup status")
+				return ctrl.Result{}, err
+			}
+		}
+		// Init container transitions don't touch the Job, so poll for progress
+		return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, nil
+	}
+
+	// The env Secret holds credentials; don't leave it behind
+	if err := deleteJobSecret(ctx, r.Client, nsName, jobName); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if condition == batchv1.JobFailed {
+		return ctrl.Result{}, r.failBackup(ctx, &backup, ReasonJobFailed, fmt.Sprintf("Backup Job failed: %s", message))
+	}
+
+	result, err := parseBackupResult(pods.Items)
+	if err != nil {
+		return ctrl.Result{}, r.failBackup(ctx, &backup, ReasonJobFailed, err.Error())
+	}
+
+	now := metav1.Now()
+	backup.Status.Phase = BackupPhaseCompleted
+	backup.Status.Progress = ""
+	backup.Status.Reason = ""
+	backup.Status.Message = ""
+	backup.Status.SizeBytes = result.SizeBytes
+	backup.Status.Checksum = result.Checksum
+	backup.Status.CompletionTime = &now
+	if err := r.Status().Update(ctx, &backup); err != nil {
+		logger.Error(err, "unable to update StoreBackup status")
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Eventf(&backup, corev1.EventTypeNormal, EventReasonBackupDone,
+		"Backup of %s written to %s (%d bytes)", backup.Spec.StoreName, backup.Status.Location, result.SizeBytes)
+	return ctrl.Result{}, nil
+}
+
+// startBackup validates the Store and creates the env Secret and backup Job
+func (r *StoreBackupReconciler) startBackup(ctx context.Context, backup *infrav1alpha1.StoreBackup, nsName string) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var store infrav1alpha1.Store
+	if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.StoreName, Namespace: backup.Namespace}, &store); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, r.failBackup(ctx, backup, ReasonStoreNotFound,
+				fmt.Sprintf("Store %q does not exist", backup.Spec.StoreName))
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Wait for the store to be up; dumping a half-provisioned database is
+	// pointless. A deleting store's final backup can't wait for that.
+	if store.Status.Phase != PhaseReady && !isDeletionBackup(&store, backup) {
+		if backup.Status.Reason != ReasonStoreNotReady {
+			backup.Status.Phase = BackupPhasePending
+			backup.Status.Reason = ReasonStoreNotReady
+			backup.Status.Message = fmt.Sprintf("Waiting for Store %q to be Ready", store.Name)
+			if err := r.Status().Update(ctx, backup); err != nil {
+				logger.Error(err, "unable to update StoreBackup status")
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, nil
+	}
+
+	provider, err := engine.Get(store.Spec.Engine)
+	if err != nil {
+		return ctrl.Result{}, r.failBackup(ctx, backup, ReasonUnknownEngine, err.Error())
+	}
+	dataProvider, ok := provider.(engine.DataProvider)
+	if !ok {
+		return ctrl.Result{}, r.failBackup(ctx, backup, ReasonBackupUnsupported,
+			fmt.Sprintf("Engine %q does not support backups", store.Spec.Engine))
+	}
+	data := dataProvider.DataSpec(store.Name, r.Config)
+
+	// Gather the secrets the Job needs into one Secret inside the store namespace
+	secretData, reason, err := dataJobSecretData(ctx, r.Client, &store, backup, data)
+	if err != nil {
+		if reason != "" {
+			return ctrl.Result{}, r.failBackup(ctx, backup, reason, err.Error())
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Content is optional: stores without persistence have no PVC to archive
+	var pvc corev1.PersistentVolumeClaim
+	hasContent := true
+	if err := r.Get(ctx, types.NamespacedName{Name: data.ContentClaim, Namespace: nsName}, &pvc); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		hasContent = false
+	}
+
+	jobName := backupJobName(backup)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: nsName,
+			Labels:    backupLabels(backup),
+		},
+		Data: secretData,
+	}
+	if err := r.Create(ctx, secret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+
+	job := buildBackupJob(backup, nsName, data, hasContent, contentPodLabels(&store, provider), r.Config.BackupUploaderImage, int32(r.Config.BackupJobBackoffLimit))
+	if err := r.Create(ctx, job); err != nil && !apierrors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Started backup Job", "job", jobName, "namespace", nsName, "content", hasContent)
+
+	now := metav1.Now()
+	backup.Status.Phase = BackupPhaseRunning
+	backup.Status.Reason = ""
+	backup.Status.Message = ""
+	if !hasContent {
+		backup.Status.Message = "Store has no content volume; backing up the database only"
+	}
+	backup.Status.JobName = jobName
+	backup.Status.Location = backupLocation(backup)
+	backup.Status.StartTime = &now
+	if err := r.Status().Update(ctx, backup); err != nil {
+		logger.Error(err, "unable to update StoreBackup status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.Config.BackupPollInterval}, nil
+}
+
+// failBackup moves a StoreBackup to the terminal Failed phase
+func (r *StoreBackupReconciler) failBackup(ctx context.Context, backup *infrav1alpha1.StoreBackup, reason, message string) error {
+	now := metav1.Now()
+	backup.Status.Phase = BackupPhaseFailed
+	backup.Status.Progress = ""
+	backup.Status.Reason = reason
+	backup.Status.Message = message
+	backup.Status.CompletionTime = &now
+	if err := r.Status().Update(ctx, backup); err != nil {
+		log.FromContext(ctx).Error(err, "unable to update StoreBackup status")
+		return err
+	}
+	r.Recorder.Event(backup, corev1.EventTypeWarning, EventReasonFailed, message)
+	return nil
+}
+
+// backupForJob maps a backup Job event back to its StoreBackup
+func backupForJob(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	name, ok := labels[LabelBackupName]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: name, Namespace: labels[LabelBackupNamespace]},
+	}}
+}
+
+func (r *StoreBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1alpha1.StoreBackup{}).
+		Watches(&batchv1.Job{}, handler.EnqueueRequestsFromMapFunc(backupForJob)).
+		Named("storebackup").
+		Complete(r)
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/config"
+	"github.com/Jovial-Kanwadia/store-operator/internal/engine"
+)
+
+var _ = Describe("StoreBackup Controller", func() {
+	ctx := context.Background()
+
+	newReconciler := func() *StoreBackupReconciler {
+		return &StoreBackupReconciler{
+			Client:   k8sClient,
+			Scheme:   k8sClient.Scheme(),
+			Recorder: record.NewFakeRecorder(10),
+			Config:   config.Load(),
+		}
+	}
+
+	It("should fail a backup whose Store does not exist", func() {
+		name := types.NamespacedName{Name: "backup-orphan", Namespace: "default"}
+		Expect(k8sClient.Create(ctx, &infrav1alpha1.StoreBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+			Spec: infrav1alpha1.StoreBackupSpec{
+				StoreName: "does-not-exist",
+				Target: infrav1alpha1.BackupTarget{
+					PVC: &infrav1alpha1.PVCBackupTarget{ClaimName: "backups"},
+				},
+			},
+		})).To(Succeed())
+
+		_, err := newReconciler().Reconcile(ctx, reconcile.Request{NamespacedName: name})
+		Expect(err).NotTo(HaveOccurred())
+
+		backup := &infrav1alpha1.StoreBackup{}
+		Expect(k8sClient.Get(ctx, name, backup)).To(Succeed())
+		Expect(backup.Status.Phase).To(Equal(BackupPhaseFailed))
+		Expect(backup.Status.Reason).To(Equal(ReasonStoreNotFound))
+	})
+
+	It("should run a backup Job and report its size and checksum", func() {
+		const storeName = "backup-src"
+		nsName := StoreNamespacePrefix + storeName
+		backupName := types.NamespacedName{Name: "nightly", Namespace: "default"}
+
+		By("creating a Ready store with credentials")
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}})).To(Succeed())
+		store := &infrav1alpha1.Store{
+			ObjectMeta: metav1.ObjectMeta{Name: storeName, Namespace: "default"},
+			Spec:       infrav1alpha1.StoreSpec{Engine: "woo", Plan: "small"},
+		}
+		Expect(k8sClient.Create(ctx, store)).To(Succeed())
+		store.Status.Phase = PhaseReady
+		Expect(k8sClient.Status().Update(ctx, store)).To(Succeed())
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: storeName + "-creds", Namespace: "default"},
+			StringData: map[string]string{engine.SecretKeyMariaDBRoot: "root-pw"},
+		})).To(Succeed())
+
+		By("creating the StoreBackup")
+		Expect(k8sClient.Create(ctx, &infrav1alpha1.StoreBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: backupName.Name, Namespace: backupName.Namespace},
+			Spec: infrav1alpha1.StoreBackupSpec{
+				StoreName: storeName,
+				Target: infrav1alpha1.BackupTarget{
+					PVC: &infrav1alpha1.PVCBackupTarget{ClaimName: "backups", Path: "nightly"},
+				},
+			},
+		})).To(Succeed())
+
+		reconciler := newReconciler()
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: backupName})
+		Expect(err).NotTo(HaveOccurred())
+
+		backup := &infrav1alpha1.StoreBackup{}
+		Expect(k8sClient.Get(ctx, backupName, backup)).To(Succeed())
+		Expect(backup.Status.Phase).To(Equal(BackupPhaseRunning))
+		Expect(backup.Status.Location).To(Equal("pvc://backups/nightly/backup-src-nightly.tar"))
+
+		By("checking the Job dumps the database without a content volume")
+		job := &batchv1.Job{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "backup-nightly", Namespace: nsName}, job)).To(Succeed())
+		var steps []string
+		for _, c := range job.Spec.Template.Spec.InitContainers {
+			steps = append(steps, c.Name)
+		}
+		Expect(steps).To(Equal([]string{BackupStepDumpDatabase, BackupStepPackage}))
+		Expect(job.Spec.Template.Spec.Containers[0].Name).To(Equal(BackupStepUpload))
+		Expect(job.Spec.Template.Spec.Affinity).To(BeNil())
+
+		jobSecret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "backup-nightly", Namespace: nsName}, jobSecret)).To(Succeed())
+		Expect(string(jobSecret.Data[jobSecretKeyDBPassword])).To(Equal("root-pw"))
+
+		By("simulating a successful Job run")
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup-nightly-abcde",
+				Namespace: nsName,
+				Labels:    map[string]string{batchv1.JobNameLabel: "backup-nightly"},
+			},
+			Spec: job.Spec.Template.Spec,
+		}
+		Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		pod.Status.Phase = corev1.PodSucceeded
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name: BackupStepUpload,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 0,
+				Message:  `{"sizeBytes":2048,"checksum":"sha256:deadbeef"}`,
+			}},
+		}}
+		Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+
+		now := metav1.Now()
+		job.Status.StartTime = &now
+		job.Status.CompletionTime = &now
+		job.Status.Succeeded = 1
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: backupName})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, backupName, backup)).To(Succeed())
+		Expect(backup.Status.Phase).To(Equal(BackupPhaseCompleted))
+		Expect(backup.Status.SizeBytes).To(Equal(int64(2048)))
+		Expect(backup.Status.Checksum).To(Equal("sha256:deadbeef"))
+
+		By("checking the credentials Secret was cleaned up")
+		err = k8sClient.Get(ctx, types.NamespacedName{Name: "backup-nightly", Namespace: nsName}, jobSecret)
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should schedule data Jobs that mount the content volume next to the store's pods", func() {
+		provider, err := engine.Get(engine.EngineWoo)
+		Expect(err).NotTo(HaveOccurred())
+		store := &infrav1alpha1.Store{
+			ObjectMeta: metav1.ObjectMeta{Name: "affine", Namespace: "default"},
+			Spec:       infrav1alpha1.StoreSpec{Engine: engine.EngineWoo, Plan: "small"},
+		}
+		backup := &infrav1alpha1.StoreBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "affine", Namespace: "default"},
+			Spec: infrav1alpha1.StoreBackupSpec{
+				StoreName: store.Name,
+				Target:    infrav1alpha1.BackupTarget{PVC: &infrav1alpha1.PVCBackupTarget{ClaimName: "backups"}},
+			},
+		}
+		data := provider.(engine.DataProvider).DataSpec(store.Name, config.Load())
+		contentPods := contentPodLabels(store, provider)
+		Expect(contentPods).To(HaveKeyWithValue(LabelStoreName, store.Name))
+		Expect(contentPods).To(HaveKeyWithValue(engine.WordPressAppLabel, engine.WordPressAppValue))
+
+		want := &corev1.Affinity{PodAffinity: &corev1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: contentPods},
+				TopologyKey:   corev1.LabelHostname,
+			}},
+		}}
+		clone := provider.(engine.Cloner).CloneSpec(store, store)
+		for name, job := range map[string]*batchv1.Job{
+			"backup":  buildBackupJob(backup, "store-affine", data, true, contentPods, "uploader", 1),
+			"restore": buildRestoreJob(store, backup, "store-affine", data, true, contentPods, "uploader", 1),
+			"clone": buildCloneJob(store, "store-affine", "store-src", "src.example.com", "affine.example.com",
+				data, clone, provider.CredentialKeys(), true, contentPods, "kubectl", 1),
+		} {
+			Expect(job.Spec.Template.Spec.Affinity).To(Equal(want), name)
+		}
+	})
+})
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Jovial-Kanwadia/store-operator/internal/config"
+	"github.com/Jovial-Kanwadia/store-operator/internal/helm"
+)
+
+// EngineMedusa is a Medusa commerce backend with PostgreSQL and Redis
+const EngineMedusa = "medusa"
+
+// Medusa secret keys
+const (
+	SecretKeyPostgresAdmin = "postgres-password"
+	SecretKeyPostgresUser  = "postgres-user-password"
+	SecretKeyMedusaAdmin   = "medusa-admin-password"
+	SecretKeyMedusaJWT     = "medusa-jwt-secret"
+	SecretKeyMedusaCookie  = "medusa-cookie-secret"
+)
+
+// Medusa password generation lengths
+const (
+	PostgresPasswordLength    = 20
+	MedusaAdminPasswordLength = 16
+	MedusaSecretLength        = 32
+)
+
+// Medusa Helm chart labels
+const (
+	MedusaAppLabel = "app.kubernetes.io/name"
+	MedusaAppValue = "medusa"
+)
+
+// MedusaDatabaseName is the PostgreSQL database created for the store
+const MedusaDatabaseName = "medusa"
+
+func init() {
+	register(medusaProvider{})
+}
+
+// medusaProvider provisions Medusa stores from the engine-medusa chart
+type medusaProvider struct{}
+
+func (medusaProvider) Name() string {
+	return EngineMedusa
+}
+
+func (medusaProvider) Chart(cfg *config.OperatorConfig) helm.ChartRef {
+	return helm.ChartRef{URL: cfg.MedusaChartPath, Name: cfg.MedusaChartName, Version: cfg.MedusaChartVersion}
+}
+
+// Configured requires MEDUSA_IMAGE: the chart has no default server image
+func (medusaProvider) Configured(cfg *config.OperatorConfig) error {
+	if cfg.MedusaImage == "" {
+		return fmt.Errorf("MEDUSA_IMAGE is not set")
+	}
+	return nil
+}
+
+func (medusaProvider) CredentialKeys() []CredentialKey {
+	return []CredentialKey{
+		{Name: SecretKeyPostgresAdmin, Length: PostgresPasswordLength},
+		{Name: SecretKeyPostgresUser, Length: PostgresPasswordLength},
+		{Name: SecretKeyMedusaAdmin, Length: MedusaAdminPasswordLength},
+		{Name: SecretKeyMedusaJWT, Length: MedusaSecretLength},
+		{Name: SecretKeyMedusaCookie, Length: MedusaSecretLength},
+	}
+}
+
+func (medusaProvider) ReadinessLabels() map[string]string {
+	return map[string]string{MedusaAppLabel: MedusaAppValue}
+}
+
+func (medusaProvider) Values(in RenderInput) map[string]interface{} {
+	cfg := in.Config
+	storeURL := fmt.Sprintf("http://%s", in.Hostname)
+	ingress, httpRoute := routingValues(in)
+	storeName, adminEmail := in.Store.Name, fmt.Sprintf("admin@%s", in.Hostname)
+	// Medusa takes its title and admin email from spec.site; the other
+	// settings are configured in the Medusa admin
+	if site := in.Store.Spec.Site; site != nil {
+		if site.Title != "" {
+			storeName = site.Title
+		}
+		if site.AdminEmail != "" {
+			adminEmail = site.AdminEmail
+		}
+	}
+	values := map[string]interface{}{
+		"image":   map[string]interface{}{"repository": cfg.MedusaImage},
+		"service": map[string]interface{}{"type": "ClusterIP"},
+		"medusa": map[string]interface{}{
+			"storeName":     storeName,
+			"adminEmail":    adminEmail,
+			"adminPassword": in.Credentials[SecretKeyMedusaAdmin],
+			"jwtSecret":     in.Credentials[SecretKeyMedusaJWT],
+			"cookieSecret":  in.Credentials[SecretKeyMedusaCookie],
+			"storeCors":     storeURL,
+			"adminCors":     storeURL,
+		},
+		"ingress":   ingress,
+		"httpRoute": httpRoute,
+		"postgresql": map[string]interface{}{
+			"auth": map[string]interface{}{
+				"postgresPassword": in.Credentials[SecretKeyPostgresAdmin],
+				"password":         in.Credentials[SecretKeyPostgresUser],
+				"database":         MedusaDatabaseName,
+			},
+			"primary": map[string]interface{}{
+				"persistence": persistenceValues(VolumePersistence(in.Plan, Volume{Database: true}), cfg),
+			},
+		},
+		"redis": map[string]interface{}{
+			"architecture": "standalone",
+			"auth":         map[string]interface{}{"enabled": false},
+		},
+		"podAnnotations": podAnnotations(in.Store),
+		"commonLabels":   commonLabels(in),
+		"livenessProbe": map[string]interface{}{
+			"initialDelaySeconds": cfg.LivenessProbeInitialDelay,
+			"periodSeconds":       cfg.LivenessProbePeriod,
+		},
+		"readinessProbe": map[string]interface{}{
+			"initialDelaySeconds": cfg.ReadinessProbeInitialDelay,
+			"periodSeconds":       cfg.ReadinessProbePeriod,
+		},
+	}
+
+	for k, v := range scalingValues(in) {
+		values[k] = v
+	}
+	return values
+}
+
+// Volumes is the PostgreSQL primary's data PVC; Medusa keeps no files
+func (medusaProvider) Volumes(release string) []Volume {
+	postgresql := release + "-postgresql"
+	return []Volume{{Claim: "data-" + postgresql + "-0", StatefulSet: postgresql, Database: true}}
+}
+
+// HealthEndpoint is Medusa's /health route on the server port
+func (medusaProvider) HealthEndpoint(release string) HealthEndpoint {
+	return HealthEndpoint{Service: medusaFullname(release), Port: 9000, Path: "/health", PortName: "http"}
+}
+
+// medusaFullname mirrors the chart's fullname helper
+func medusaFullname(release string) string {
+	if strings.Contains(release, MedusaAppValue) {
+		return release
+	}
+	return release + "-" + MedusaAppValue
+}
+
+// SupportingPods counts the PostgreSQL primary and Redis master
+func (medusaProvider) SupportingPods() int32 {
+	return 2
+}
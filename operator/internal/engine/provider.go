@@ -0,0 +1,390 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/config"
+	"github.com/Jovial-Kanwadia/store-operator/internal/helm"
+)
+
+// CredentialKey describes a generated secret value an engine needs
+type CredentialKey struct {
+	// Name is the key inside the store's -creds Secret
+	Name string
+	// Length is the number of characters to generate
+	Length int
+}
+
+// RenderInput carries everything an engine needs to render its Helm values
+type RenderInput struct {
+	Store       *infrav1alpha1.Store
+	Plan        *infrav1alpha1.StorePlanSpec
+	Hostname    string
+	Credentials map[string]string
+	Config      *config.OperatorConfig
+	// Labels go on every workload and pod the chart renders
+	Labels map[string]string
+}
+
+// Provider owns the engine-specific parts of provisioning a store:
+// chart location, values rendering, credential keys and readiness detection.
+type Provider interface {
+	// Name is the value users put in spec.engine
+	Name() string
+
+	// Chart returns the engine's default Helm chart; spec.chart overrides it per store
+	Chart(cfg *config.OperatorConfig) helm.ChartRef
+
+	// Values renders the Helm values for a store
+	Values(in RenderInput) map[string]interface{}
+
+	// CredentialKeys lists the passwords stored in the store's -creds Secret
+	CredentialKeys() []CredentialKey
+
+	// ReadinessLabels selects the pods that must be Ready before the store is
+	ReadinessLabels() map[string]string
+
+	// SupportingPods is how many pods the engine runs besides the application
+	// replicas (databases, caches); they count against the plan's maxPods
+	SupportingPods() int32
+}
+
+// DataSpec describes how backup and restore Jobs reach an engine's data.
+// Scripts run under bash with DB_HOST, DB_NAME, DB_PASSWORD and DUMP_FILE set.
+type DataSpec struct {
+	// Image provides the database client and archiving tools
+	Image string
+
+	// DatabaseHost is the database Service inside the store namespace
+	DatabaseHost string
+
+	// DatabaseName is the application database; system schemas are never dumped
+	// so a restore can't overwrite the target store's credentials
+	DatabaseName string
+
+	// PasswordKey is the -creds key holding the database admin password
+	PasswordKey string
+
+	// ContentClaim is the PVC holding uploaded content
+	ContentClaim string
+
+	// ContentDir is the directory inside ContentClaim to archive
+	ContentDir string
+
+	// DumpScript writes a gzipped dump of the application database to $DUMP_FILE
+	DumpScript string
+
+	// RestoreScript loads the gzipped dump at $DUMP_FILE into the database
+	RestoreScript string
+}
+
+// Volume is a PVC an engine's chart creates for a store
+type Volume struct {
+	// Claim is the PVC name
+	Claim string
+
+	// StatefulSet creates Claim from its volumeClaimTemplates; empty when the
+	// chart creates the PVC itself
+	StatefulSet string
+
+	// Database marks the database volume, sized by the plan's
+	// databasePersistence instead of its persistence
+	Database bool
+}
+
+// VolumeProvider is implemented by engines whose volumes follow the plan's
+// persistence sizes
+type VolumeProvider interface {
+	Volumes(release string) []Volume
+}
+
+// HealthEndpoint is an in-cluster HTTP endpoint that answers while the store
+// serves traffic
+type HealthEndpoint struct {
+	Service string
+	Port    int32
+	Path    string
+	// PortName is the named container port the Service targets; the store's
+	// NetworkPolicy admits the operator's probe on it
+	PortName string
+}
+
+// HealthChecker is implemented by engines whose Service can be probed over HTTP
+type HealthChecker interface {
+	HealthEndpoint(release string) HealthEndpoint
+}
+
+// DataProvider is implemented by engines whose data can be backed up and restored
+type DataProvider interface {
+	DataSpec(release string, cfg *config.OperatorConfig) DataSpec
+}
+
+// CredentialRotator is implemented by engines that can change their passwords
+// in place. The script runs in the DataSpec image with DB_HOST and DB_NAME set
+// and every credential key exposed as OLD_<KEY> and NEW_<KEY> (see CredentialEnvName).
+// It must be safe to re-run after a partial rotation.
+type CredentialRotator interface {
+	RotationScript(store *infrav1alpha1.Store) string
+}
+
+// CloneSpec says where a running store's data can be read from. A clone Job
+// reaches the source's pods with kubectl exec, so targets are exec targets
+// such as statefulset/shop-mariadb.
+type CloneSpec struct {
+	// DatabaseTarget and DatabaseContainer run the DataSpec's DumpScript
+	// against the database listening on 127.0.0.1
+	DatabaseTarget    string
+	DatabaseContainer string
+
+	// ContentTarget and ContentContainer mount the content volume with the
+	// DataSpec's ContentDir under ContentPath
+	ContentTarget    string
+	ContentContainer string
+	ContentPath      string
+
+	// RewriteScript points the copied database at the clone's hostname and
+	// resets the copied admin password to the clone's own. It runs in the
+	// DataSpec image with the clone's DB_HOST, DB_NAME and DB_PASSWORD,
+	// SOURCE_HOST and TARGET_HOST, and the clone's credentials as NEW_<KEY>
+	// (see CredentialEnvName) set.
+	RewriteScript string
+}
+
+// Cloner is implemented by engines whose running stores can be copied into a
+// new store; they must also be DataProviders
+type Cloner interface {
+	CloneSpec(source, store *infrav1alpha1.Store) CloneSpec
+}
+
+// PodAnnotationCredentialsRotatedAt rolls store pods when their passwords change
+const PodAnnotationCredentialsRotatedAt = "infra.store.io/credentials-rotated-at"
+
+// CredentialEnvName turns a credential key into an env var name, e.g.
+// mariadb-root-password becomes MARIADB_ROOT_PASSWORD
+func CredentialEnvName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// Configurable is implemented by engines that need operator configuration
+// before they can provision stores
+type Configurable interface {
+	// Configured returns why the engine can't be used with cfg, or nil
+	Configured(cfg *config.OperatorConfig) error
+}
+
+// SupportedEngines holds every engine this operator build can provision
+var SupportedEngines = map[string]Provider{}
+
+// register adds a provider to SupportedEngines; called from each engine's init
+func register(p Provider) {
+	SupportedEngines[p.Name()] = p
+}
+
+// Get returns the provider for an engine name
+func Get(name string) (Provider, error) {
+	if p, ok := SupportedEngines[name]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("unsupported engine %q", name)
+}
+
+// IsValidEngine checks if an engine name is supported
+func IsValidEngine(name string) bool {
+	_, ok := SupportedEngines[name]
+	return ok
+}
+
+// Names returns the supported engine names in sorted order
+func Names() []string {
+	names := make([]string, 0, len(SupportedEngines))
+	for name := range SupportedEngines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the provider for an engine name once it is configured
+func Resolve(name string, cfg *config.OperatorConfig) (Provider, error) {
+	p, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := p.(Configurable); ok {
+		if err := c.Configured(cfg); err != nil {
+			return nil, fmt.Errorf("engine %q is not configured: %w", name, err)
+		}
+	}
+	return p, nil
+}
+
+// Available returns the names of the engines configured in cfg, in sorted order
+func Available(cfg *config.OperatorConfig) []string {
+	var names []string
+	for _, name := range Names() {
+		if _, err := Resolve(name, cfg); err == nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// persistenceValues renders a chart persistence block, using the
+// operator-wide default when the plan leaves enabled unset
+func persistenceValues(p *infrav1alpha1.PlanPersistence, cfg *config.OperatorConfig) map[string]interface{} {
+	values := map[string]interface{}{
+		"enabled": PersistenceEnabled(p, cfg),
+	}
+	if p == nil {
+		return values
+	}
+	if p.Size != nil {
+		values["size"] = p.Size.String()
+	}
+	if p.StorageClassName != "" {
+		values["storageClass"] = p.StorageClassName
+	}
+	if len(p.AccessModes) > 0 {
+		modes := make([]interface{}, 0, len(p.AccessModes))
+		for _, mode := range p.AccessModes {
+			modes = append(modes, string(mode))
+		}
+		values["accessModes"] = modes
+	}
+	return values
+}
+
+// PersistenceEnabled reports whether a plan volume is persistent, falling
+// back to the operator's PERSISTENCE_ENABLED
+func PersistenceEnabled(p *infrav1alpha1.PlanPersistence, cfg *config.OperatorConfig) bool {
+	if p != nil && p.Enabled != nil {
+		return *p.Enabled
+	}
+	return cfg.PersistenceEnabled
+}
+
+// VolumePersistence returns the plan's settings for one of the engine's
+// volumes, or nil without a plan
+func VolumePersistence(plan *infrav1alpha1.StorePlanSpec, v Volume) *infrav1alpha1.PlanPersistence {
+	switch {
+	case plan == nil:
+		return nil
+	case v.Database:
+		return &plan.DatabasePersistence
+	}
+	return &plan.Persistence
+}
+
+// ContentReadWriteOnce reports whether one of the engine's application
+// volumes (every volume but the database's) is persistent without
+// ReadWriteMany, so replicas on different nodes can't all mount it
+func ContentReadWriteOnce(provider Provider, release string, plan *infrav1alpha1.StorePlanSpec, cfg *config.OperatorConfig) bool {
+	volumes, ok := provider.(VolumeProvider)
+	if !ok {
+		return false
+	}
+	for _, v := range volumes.Volumes(release) {
+		p := VolumePersistence(plan, v)
+		if v.Database || !PersistenceEnabled(p, cfg) {
+			continue
+		}
+		if !slices.Contains(p.AccessModes, corev1.ReadWriteMany) {
+			return true
+		}
+	}
+	return false
+}
+
+// Chart scaling keys shared by the engine charts
+const (
+	HelmKeyReplicaCount = "replicaCount"
+	HelmKeyAutoscaling  = "autoscaling"
+	HelmKeyPDB          = "pdb"
+)
+
+// scalingValues renders the application's replicaCount, autoscaling and pdb
+// values. spec.replicas pins the replica count and turns the plan's
+// autoscaling off, and so does suspending the store.
+func scalingValues(in RenderInput) map[string]interface{} {
+	values := map[string]interface{}{}
+	autoscaling := map[string]interface{}{"enabled": false}
+
+	if plan := in.Plan; plan != nil {
+		if plan.Replicas != nil {
+			values[HelmKeyReplicaCount] = *plan.Replicas
+		}
+		if a := plan.Autoscaling; a != nil && in.Store.Spec.Replicas == nil {
+			autoscaling = map[string]interface{}{
+				"enabled":     true,
+				"minReplicas": a.MinReplicas,
+				"maxReplicas": a.MaxReplicas,
+			}
+			if a.TargetCPU != nil {
+				autoscaling["targetCPU"] = *a.TargetCPU
+			}
+			if a.TargetMemory != nil {
+				autoscaling["targetMemory"] = *a.TargetMemory
+			}
+		}
+		if b := plan.DisruptionBudget; b != nil {
+			pdb := map[string]interface{}{"create": true}
+			if b.MinAvailable != nil {
+				pdb["minAvailable"] = b.MinAvailable.String()
+			}
+			if b.MaxUnavailable != nil {
+				pdb["maxUnavailable"] = b.MaxUnavailable.String()
+			}
+			values[HelmKeyPDB] = pdb
+		}
+	}
+
+	if in.Store.Spec.Replicas != nil {
+		values[HelmKeyReplicaCount] = *in.Store.Spec.Replicas
+	}
+	if in.Store.Spec.Suspended {
+		values[HelmKeyReplicaCount] = 0
+		autoscaling = map[string]interface{}{"enabled": false}
+	}
+	values[HelmKeyAutoscaling] = autoscaling
+	return values
+}
+
+// MaxReplicas is the most application replicas a store can run: spec.replicas,
+// else the plan's autoscaling ceiling, else the plan's replicas, else one
+func MaxReplicas(store *infrav1alpha1.Store, plan *infrav1alpha1.StorePlanSpec) int32 {
+	switch {
+	case store.Spec.Replicas != nil:
+		return *store.Spec.Replicas
+	case plan.Autoscaling != nil:
+		return plan.Autoscaling.MaxReplicas
+	case plan.Replicas != nil:
+		return *plan.Replicas
+	}
+	return 1
+}
+
+// commonLabels renders the labels charts put on all their objects and pod templates
+func commonLabels(in RenderInput) map[string]interface{} {
+	labels := map[string]interface{}{}
+	for k, v := range in.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// podAnnotations renders pod annotations that restart workloads after a
+// credential rotation, since the charts read passwords only at startup
+func podAnnotations(store *infrav1alpha1.Store) map[string]interface{} {
+	annotations := map[string]interface{}{}
+	if t := store.Status.CredentialsRotatedAt; t != nil {
+		annotations[PodAnnotationCredentialsRotatedAt] = t.UTC().Format(time.RFC3339)
+	}
+	return annotations
+}
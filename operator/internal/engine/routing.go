@@ -0,0 +1,92 @@
+package engine
+
+import (
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/config"
+)
+
+// Routing modes
+const (
+	RoutingModeIngress = "Ingress"
+	RoutingModeGateway = "Gateway"
+)
+
+// Routing is how a store is exposed, after spec.routing is applied to the
+// operator's defaults
+type Routing struct {
+	Mode             string
+	IngressClassName string
+	Annotations      map[string]string
+	Gateway          infrav1alpha1.GatewayRef
+}
+
+// ResolveRouting applies a store's spec.routing to the operator's defaults
+func ResolveRouting(store *infrav1alpha1.Store, cfg *config.OperatorConfig) Routing {
+	routing := Routing{
+		Mode:             cfg.RoutingMode,
+		IngressClassName: cfg.IngressClassName,
+		Annotations:      map[string]string{},
+		Gateway: infrav1alpha1.GatewayRef{
+			Name:        cfg.GatewayName,
+			Namespace:   cfg.GatewayNamespace,
+			SectionName: cfg.GatewaySectionName,
+		},
+	}
+	for k, v := range cfg.IngressAnnotations {
+		routing.Annotations[k] = v
+	}
+
+	if spec := store.Spec.Routing; spec != nil {
+		if spec.Mode != "" {
+			routing.Mode = spec.Mode
+		}
+		if spec.IngressClassName != "" {
+			routing.IngressClassName = spec.IngressClassName
+		}
+		for k, v := range spec.Annotations {
+			routing.Annotations[k] = v
+		}
+		if spec.Gateway != nil {
+			routing.Gateway = *spec.Gateway
+			if routing.Gateway.Namespace == "" {
+				routing.Gateway.Namespace = cfg.GatewayNamespace
+			}
+		}
+	}
+	if routing.Mode != RoutingModeGateway {
+		routing.Mode = RoutingModeIngress
+	}
+	return routing
+}
+
+// routingValues renders the chart's ingress and httpRoute blocks; exactly one
+// of them is enabled
+func routingValues(in RenderInput) (ingress, httpRoute map[string]interface{}) {
+	routing := ResolveRouting(in.Store, in.Config)
+	annotations := map[string]interface{}{}
+	for k, v := range routing.Annotations {
+		annotations[k] = v
+	}
+
+	ingress = map[string]interface{}{
+		"enabled":          routing.Mode == RoutingModeIngress,
+		"ingressClassName": routing.IngressClassName,
+		"hostname":         in.Hostname,
+		"annotations":      annotations,
+	}
+
+	parent := map[string]interface{}{
+		"name":      routing.Gateway.Name,
+		"namespace": routing.Gateway.Namespace,
+	}
+	if routing.Gateway.SectionName != "" {
+		parent["sectionName"] = routing.Gateway.SectionName
+	}
+	httpRoute = map[string]interface{}{
+		"enabled":     routing.Mode == RoutingModeGateway,
+		"hostnames":   []interface{}{in.Hostname},
+		"parentRefs":  []interface{}{parent},
+		"annotations": annotations,
+	}
+	return ingress, httpRoute
+}
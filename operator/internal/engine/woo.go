@@ -0,0 +1,294 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	infrav1alpha1 "github.com/Jovial-Kanwadia/store-operator/api/v1alpha1"
+	"github.com/Jovial-Kanwadia/store-operator/internal/config"
+	"github.com/Jovial-Kanwadia/store-operator/internal/helm"
+)
+
+// EngineWoo is WooCommerce on the Bitnami WordPress chart
+const EngineWoo = "woo"
+
+// WooCommerce secret keys
+const (
+	SecretKeyMariaDBRoot = "mariadb-root-password"
+	SecretKeyMariaDBUser = "mariadb-user-password"
+	SecretKeyWordPress   = "wordpress-password"
+)
+
+// WooCommerce password generation lengths
+const (
+	MariaDBRootPasswordLength = 20
+	MariaDBUserPasswordLength = 20
+	WordPressPasswordLength   = 16
+)
+
+// WordPress Helm chart labels
+const (
+	WordPressAppLabel = "app.kubernetes.io/name"
+	WordPressAppValue = "wordpress"
+)
+
+// Helm values keys (for documentation and consistency)
+const (
+	HelmKeyWordPressBlogName = "wordpressBlogName"
+	HelmKeyWordPressEmail    = "wordpressEmail"
+	HelmKeyWordPressUsername = "wordpressUsername"
+	HelmKeyPostInitScripts   = "customPostInitScripts"
+	HelmKeyService           = "service"
+	HelmKeyVolumePermissions = "volumePermissions"
+	HelmKeyWordPressPassword = "wordpressPassword"
+	HelmKeyIngress           = "ingress"
+	HelmKeyHTTPRoute         = "httpRoute"
+	HelmKeyMariaDB           = "mariadb"
+	HelmKeyPersistence       = "persistence"
+	HelmKeyLivenessProbe     = "livenessProbe"
+	HelmKeyReadinessProbe    = "readinessProbe"
+	HelmKeyPodAnnotations    = "podAnnotations"
+	HelmKeyCommonLabels      = "commonLabels"
+)
+
+// WooCommerce data locations inside the Bitnami chart
+const (
+	WordPressContentDir   = "wp-content"
+	WordPressDataDir      = "/bitnami/wordpress"
+	WordPressDatabaseName = "bitnami_wordpress"
+	WordPressDatabaseUser = "bn_wordpress"
+	WordPressUsername     = "user"
+	// WordPressSiteScript is the post-init script applying spec.site
+	WordPressSiteScript = "store-site.sh"
+)
+
+func init() {
+	register(wooProvider{})
+}
+
+// wooProvider provisions WooCommerce stores on the bundled WordPress chart
+type wooProvider struct{}
+
+func (wooProvider) Name() string {
+	return EngineWoo
+}
+
+func (wooProvider) Chart(cfg *config.OperatorConfig) helm.ChartRef {
+	return helm.ChartRef{URL: cfg.WordPressChartPath, Name: cfg.WordPressChartName, Version: cfg.WordPressChartVersion}
+}
+
+func (wooProvider) CredentialKeys() []CredentialKey {
+	return []CredentialKey{
+		{Name: SecretKeyMariaDBRoot, Length: MariaDBRootPasswordLength},
+		{Name: SecretKeyMariaDBUser, Length: MariaDBUserPasswordLength},
+		{Name: SecretKeyWordPress, Length: WordPressPasswordLength},
+	}
+}
+
+func (wooProvider) ReadinessLabels() map[string]string {
+	// Bitnami WordPress charts use this label by default
+	return map[string]string{WordPressAppLabel: WordPressAppValue}
+}
+
+func (wooProvider) Values(in RenderInput) map[string]interface{} {
+	cfg := in.Config
+	ingress, httpRoute := routingValues(in)
+	content := VolumePersistence(in.Plan, Volume{})
+	database := VolumePersistence(in.Plan, Volume{Database: true})
+	values := map[string]interface{}{
+		HelmKeyWordPressBlogName: in.Store.Name,
+		HelmKeyService:           map[string]interface{}{"type": "ClusterIP"},
+		HelmKeyVolumePermissions: map[string]interface{}{"enabled": false},
+
+		// Inject Credentials & Networking
+		HelmKeyWordPressPassword: in.Credentials[SecretKeyWordPress],
+		HelmKeyIngress:           ingress,
+		HelmKeyHTTPRoute:         httpRoute,
+		HelmKeyMariaDB: map[string]interface{}{
+			HelmKeyCommonLabels: commonLabels(in),
+			"auth": map[string]interface{}{
+				"rootPassword": in.Credentials[SecretKeyMariaDBRoot],
+				"password":     in.Credentials[SecretKeyMariaDBUser],
+			},
+			"primary": map[string]interface{}{
+				"persistence":         persistenceValues(database, cfg),
+				HelmKeyPodAnnotations: podAnnotations(in.Store),
+			},
+		},
+		HelmKeyPodAnnotations: podAnnotations(in.Store),
+		HelmKeyCommonLabels:   commonLabels(in),
+
+		// 1. Configure Persistence from the plan, falling back to Config
+		HelmKeyPersistence: persistenceValues(content, cfg),
+
+		// 2. Configure Probes from Config
+		HelmKeyLivenessProbe: map[string]interface{}{
+			"initialDelaySeconds": cfg.LivenessProbeInitialDelay,
+			"periodSeconds":       cfg.LivenessProbePeriod,
+		},
+		HelmKeyReadinessProbe: map[string]interface{}{
+			"initialDelaySeconds": cfg.ReadinessProbeInitialDelay,
+			"periodSeconds":       cfg.ReadinessProbePeriod,
+		},
+	}
+
+	// spec.site replaces the chart's blog name and admin user; the rest is
+	// applied by a post-init script on the first boot
+	if site := in.Store.Spec.Site; site != nil {
+		if site.Title != "" {
+			values[HelmKeyWordPressBlogName] = site.Title
+		}
+		if site.AdminEmail != "" {
+			values[HelmKeyWordPressEmail] = site.AdminEmail
+		}
+		if site.AdminUsername != "" {
+			values[HelmKeyWordPressUsername] = site.AdminUsername
+		}
+		if script := wooSiteScript(site); script != "" {
+			values[HelmKeyPostInitScripts] = map[string]interface{}{WordPressSiteScript: script}
+		}
+	}
+
+	// MariaDB's StatefulSet has no replica value; the operator scales it down
+	// when the store is suspended
+	for k, v := range scalingValues(in) {
+		values[k] = v
+	}
+	return values
+}
+
+// wooSiteScript renders the wp-cli commands setting the site's locale and
+// WooCommerce's currency and store address, or "" when none are set.
+// Language packs are downloaded from wordpress.org; when the store's egress
+// doesn't allow that, the locale is still recorded and takes effect once the
+// pack is installed.
+func wooSiteScript(site *infrav1alpha1.SiteSpec) string {
+	var commands []string
+	if site.Locale != "" {
+		commands = append(commands, fmt.Sprintf("wp language core install %[1]s --activate || wp option update WPLANG %[1]s",
+			shellQuote(site.Locale)))
+	}
+
+	var options [][2]string
+	if site.Currency != "" {
+		options = append(options, [2]string{"woocommerce_currency", site.Currency})
+	}
+	if a := site.Address; a != nil {
+		// WooCommerce stores the country and state as CC:STATE
+		country := a.Country
+		if a.State != "" {
+			country += ":" + a.State
+		}
+		options = append(options,
+			[2]string{"woocommerce_store_address", a.Line1},
+			[2]string{"woocommerce_store_address_2", a.Line2},
+			[2]string{"woocommerce_store_city", a.City},
+			[2]string{"woocommerce_store_postcode", a.Postcode},
+			[2]string{"woocommerce_default_country", country},
+		)
+	}
+	for _, o := range options {
+		if o[1] != "" {
+			commands = append(commands, fmt.Sprintf("wp option update %s %s", o[0], shellQuote(o[1])))
+		}
+	}
+
+	if len(commands) == 0 {
+		return ""
+	}
+	return "#!/bin/bash\nset -e\n" + strings.Join(commands, "\n") + "\n"
+}
+
+// shellQuote single-quotes s for bash
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// wordPressAdmin is the login of a store's WordPress admin user
+func wordPressAdmin(store *infrav1alpha1.Store) string {
+	if store.Spec.Site != nil && store.Spec.Site.AdminUsername != "" {
+		return store.Spec.Site.AdminUsername
+	}
+	return WordPressUsername
+}
+
+// SupportingPods counts the MariaDB primary
+func (wooProvider) SupportingPods() int32 {
+	return 1
+}
+
+// Volumes are the WordPress content PVC and the MariaDB primary's data PVC
+func (wooProvider) Volumes(release string) []Volume {
+	mariadb := release + "-mariadb"
+	return []Volume{
+		{Claim: wordPressFullname(release)},
+		{Claim: "data-" + mariadb + "-0", StatefulSet: mariadb, Database: true},
+	}
+}
+
+// HealthEndpoint is the WordPress homepage
+func (wooProvider) HealthEndpoint(release string) HealthEndpoint {
+	return HealthEndpoint{Service: wordPressFullname(release), Port: 80, Path: "/", PortName: "http"}
+}
+
+func (wooProvider) DataSpec(release string, cfg *config.OperatorConfig) DataSpec {
+	return DataSpec{
+		Image:         cfg.MariaDBClientImage,
+		DatabaseHost:  release + "-mariadb",
+		DatabaseName:  WordPressDatabaseName,
+		PasswordKey:   SecretKeyMariaDBRoot,
+		ContentClaim:  wordPressFullname(release),
+		ContentDir:    WordPressContentDir,
+		DumpScript:    `mariadb-dump -h "$DB_HOST" -uroot -p"$DB_PASSWORD" --single-transaction --routines --triggers "$DB_NAME" | gzip > "$DUMP_FILE"`,
+		RestoreScript: `gunzip -c "$DUMP_FILE" | mariadb -h "$DB_HOST" -uroot -p"$DB_PASSWORD" "$DB_NAME"`,
+	}
+}
+
+// RotationScript changes the MariaDB root and application passwords and the
+// WordPress admin password. It logs in with the new root password when a
+// previous attempt already got that far.
+func (wooProvider) RotationScript(store *infrav1alpha1.Store) string {
+	return fmt.Sprintf(`root="$OLD_MARIADB_ROOT_PASSWORD"
+if mariadb -h "$DB_HOST" -uroot -p"$NEW_MARIADB_ROOT_PASSWORD" -e 'SELECT 1' >/dev/null 2>&1; then
+  root="$NEW_MARIADB_ROOT_PASSWORD"
+fi
+mariadb -h "$DB_HOST" -uroot -p"$root" "$DB_NAME" <<SQL
+ALTER USER IF EXISTS 'root'@'%%' IDENTIFIED BY '$NEW_MARIADB_ROOT_PASSWORD';
+ALTER USER IF EXISTS 'root'@'localhost' IDENTIFIED BY '$NEW_MARIADB_ROOT_PASSWORD';
+ALTER USER IF EXISTS '%s'@'%%' IDENTIFIED BY '$NEW_MARIADB_USER_PASSWORD';
+UPDATE wp_users SET user_pass = MD5('$NEW_WORDPRESS_PASSWORD') WHERE user_login = '%s';
+SQL`, WordPressDatabaseUser, wordPressAdmin(store))
+}
+
+// CloneSpec reads the database from the MariaDB StatefulSet and wp-content
+// from the WordPress Deployment. The rewrite leaves serialized PHP values
+// alone, since replacing inside them would break their string lengths. The
+// copied admin keeps the source's login, so both logins get the clone's
+// admin password.
+func (wooProvider) CloneSpec(source, store *infrav1alpha1.Store) CloneSpec {
+	release := source.Name
+	return CloneSpec{
+		DatabaseTarget:    "statefulset/" + release + "-mariadb",
+		DatabaseContainer: "mariadb",
+		ContentTarget:     "deployment/" + wordPressFullname(release),
+		ContentContainer:  WordPressAppValue,
+		ContentPath:       WordPressDataDir,
+		RewriteScript: fmt.Sprintf(`mariadb -h "$DB_HOST" -uroot -p"$DB_PASSWORD" "$DB_NAME" <<SQL
+UPDATE wp_options SET option_value = REPLACE(option_value, '//$SOURCE_HOST', '//$TARGET_HOST')
+  WHERE option_name IN ('siteurl', 'home');
+UPDATE wp_posts SET post_content = REPLACE(post_content, '//$SOURCE_HOST', '//$TARGET_HOST'),
+  guid = REPLACE(guid, '//$SOURCE_HOST', '//$TARGET_HOST');
+UPDATE wp_postmeta SET meta_value = REPLACE(meta_value, '//$SOURCE_HOST', '//$TARGET_HOST')
+  WHERE meta_value NOT LIKE 'a:%%' AND meta_value NOT LIKE 'O:%%';
+UPDATE wp_users SET user_pass = MD5('$NEW_WORDPRESS_PASSWORD') WHERE user_login IN ('%s', '%s');
+SQL`, wordPressAdmin(source), wordPressAdmin(store)),
+	}
+}
+
+// wordPressFullname mirrors the chart's common.names.fullname helper
+func wordPressFullname(release string) string {
+	if strings.Contains(release, WordPressAppValue) {
+		return release
+	}
+	return release + "-" + WordPressAppValue
+}
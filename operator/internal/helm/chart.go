@@ -0,0 +1,280 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// ChartRef locates a Helm chart: a local path, an oci:// reference, or a
+// chart repository URL together with the chart's name in that repository.
+type ChartRef struct {
+	// URL is the local path, oci:// reference or http(s):// repository URL
+	URL string
+	// Name is the chart's name in a repository; OCI references and local paths carry it in URL
+	Name string
+	// Version pins the chart version; it is required for OCI and repository charts
+	Version string
+}
+
+// IsOCI reports whether the chart lives in an OCI registry
+func (c ChartRef) IsOCI() bool {
+	return strings.HasPrefix(c.URL, registry.OCIScheme+"://")
+}
+
+// IsRepository reports whether the chart lives in a chart repository
+func (c ChartRef) IsRepository() bool {
+	return strings.HasPrefix(c.URL, "http://") || strings.HasPrefix(c.URL, "https://")
+}
+
+// IsLocal reports whether the chart is a path on the operator's filesystem
+func (c ChartRef) IsLocal() bool {
+	return !c.IsOCI() && !c.IsRepository()
+}
+
+func (c ChartRef) String() string {
+	s := c.URL
+	if c.IsRepository() {
+		s = strings.TrimSuffix(c.URL, "/") + "/" + c.Name
+	}
+	if c.Version != "" {
+		s += "@" + c.Version
+	}
+	return s
+}
+
+// chartName is the name the chart is published under
+func (c ChartRef) chartName() string {
+	if c.IsRepository() {
+		return c.Name
+	}
+	return path.Base(strings.TrimPrefix(c.URL, registry.OCIScheme+"://"))
+}
+
+// ChartCache resolves chart references to local chart archives. Remote charts
+// are downloaded once per version, checked against the digest published by the
+// registry or repository index, and re-verified every time they are served
+// from the cache.
+type ChartCache struct {
+	mu  sync.Mutex
+	dir string
+
+	// PlainHTTP talks to OCI registries over HTTP, for local test registries
+	PlainHTTP bool
+}
+
+// NewChartCache returns a cache that keeps downloaded charts in dir
+func NewChartCache(dir string) *ChartCache {
+	return &ChartCache{dir: dir}
+}
+
+// Locate returns a path loader.Load accepts for ref. Local charts are used in
+// place; their version is checked when ref pins one.
+func (c *ChartCache) Locate(ctx context.Context, ref ChartRef) (string, error) {
+	if ref.IsLocal() {
+		path := strings.TrimPrefix(ref.URL, "file://")
+		if ref.Version != "" {
+			if err := checkChartVersion(path, ref.Version); err != nil {
+				return "", err
+			}
+		}
+		return path, nil
+	}
+
+	if ref.Version == "" {
+		return "", fmt.Errorf("chart %s: a version is required for remote charts", ref)
+	}
+	if ref.IsRepository() && ref.Name == "" {
+		return "", fmt.Errorf("chart %s: a chart name is required for repository charts", ref)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	archive := c.archivePath(ref)
+	if err := verifyArchive(archive); err == nil {
+		return archive, nil
+	}
+
+	var data []byte
+	var digest string
+	var err error
+	if ref.IsOCI() {
+		data, digest, err = c.pullOCI(ref)
+	} else {
+		data, digest, err = c.pullRepository(ctx, ref)
+	}
+	if err != nil {
+		return "", fmt.Errorf("chart %s: %w", ref, err)
+	}
+
+	sum := sha256Hex(data)
+	if sum != digest {
+		return "", fmt.Errorf("chart %s: digest mismatch: downloaded %s, published %s", ref, sum, digest)
+	}
+	chart, err := loader.LoadArchive(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("chart %s: %w", ref, err)
+	}
+	if chart.Metadata.Version != ref.Version {
+		return "", fmt.Errorf("chart %s: archive has version %s", ref, chart.Metadata.Version)
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(archive, data); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(archive+".sha256", []byte(sum)); err != nil {
+		return "", err
+	}
+	return archive, nil
+}
+
+// archivePath names the cached archive after the chart and a hash of its
+// source, so the same chart from two registries never collides
+func (c *ChartCache) archivePath(ref ChartRef) string {
+	source := sha256Hex([]byte(ref.String()))[:12]
+	return filepath.Join(c.dir, fmt.Sprintf("%s-%s-%s.tgz", ref.chartName(), ref.Version, source))
+}
+
+// pullOCI downloads the chart layer; the digest comes from the manifest
+func (c *ChartCache) pullOCI(ref ChartRef) ([]byte, string, error) {
+	opts := []registry.ClientOption{registry.ClientOptWriter(&bytes.Buffer{})}
+	if c.PlainHTTP {
+		opts = append(opts, registry.ClientOptPlainHTTP())
+	}
+	client, err := registry.NewClient(opts...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	target := strings.TrimPrefix(ref.URL, registry.OCIScheme+"://") + ":" + ref.Version
+	result, err := client.Pull(target, registry.PullOptWithChart(true))
+	if err != nil {
+		return nil, "", err
+	}
+	return result.Chart.Data, strings.TrimPrefix(result.Chart.Digest, "sha256:"), nil
+}
+
+// pullRepository looks the version up in the repository index and downloads
+// the archive it points at; the digest comes from the index entry
+func (c *ChartCache) pullRepository(ctx context.Context, ref ChartRef) ([]byte, string, error) {
+	get, err := getter.NewHTTPGetter()
+	if err != nil {
+		return nil, "", err
+	}
+
+	indexURL, err := repo.ResolveReferenceURL(ref.URL, "index.yaml")
+	if err != nil {
+		return nil, "", err
+	}
+	buf, err := get.Get(indexURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetching repository index: %w", err)
+	}
+	index, err := loadIndex(c.dir, buf.Bytes())
+	if err != nil {
+		return nil, "", err
+	}
+
+	entry, err := index.Get(ref.Name, ref.Version)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(entry.URLs) == 0 {
+		return nil, "", fmt.Errorf("repository index lists no URL for %s %s", ref.Name, ref.Version)
+	}
+	if entry.Digest == "" {
+		return nil, "", fmt.Errorf("repository index has no digest for %s %s", ref.Name, ref.Version)
+	}
+
+	chartURL, err := repo.ResolveReferenceURL(ref.URL, entry.URLs[0])
+	if err != nil {
+		return nil, "", err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+	buf, err = get.Get(chartURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetching chart archive: %w", err)
+	}
+	return buf.Bytes(), entry.Digest, nil
+}
+
+// loadIndex parses a repository index through a scratch file, which is the
+// only form the repo package loads and validates
+func loadIndex(dir string, data []byte) (*repo.IndexFile, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(dir, "index-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return repo.LoadIndexFile(f.Name())
+}
+
+// verifyArchive checks a cached archive against the digest recorded with it
+func verifyArchive(archive string) error {
+	want, err := os.ReadFile(archive + ".sha256")
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		return err
+	}
+	if sha256Hex(data) != strings.TrimSpace(string(want)) {
+		return fmt.Errorf("cached chart %s is corrupt", archive)
+	}
+	return nil
+}
+
+// checkChartVersion loads a local chart and compares its version with the pin
+func checkChartVersion(path, version string) error {
+	chart, err := loader.Load(path)
+	if err != nil {
+		return err
+	}
+	if chart.Metadata.Version != version {
+		return fmt.Errorf("chart %s has version %s, want %s", path, chart.Metadata.Version, version)
+	}
+	return nil
+}
+
+func writeFileAtomic(name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,127 @@
+package helm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// serveRepo packages a one-template chart into a directory, indexes it and
+// serves the directory over HTTP like a static chart repository
+func serveRepo(t *testing.T, version string) (*httptest.Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "engine-woo", Version: version},
+		Templates: []*chart.File{{
+			Name: "templates/config.yaml",
+			Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"),
+		}},
+	}
+	archive, err := chartutil.Save(c, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := provenance.DigestFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(server.Close)
+
+	index := repo.NewIndexFile()
+	if err := index.MustAdd(c.Metadata, filepath.Base(archive), server.URL, digest); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.WriteFile(filepath.Join(dir, "index.yaml"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return server, dir
+}
+
+func TestChartCacheRepository(t *testing.T) {
+	server, _ := serveRepo(t, "1.2.0")
+	cache := NewChartCache(t.TempDir())
+	ref := ChartRef{URL: server.URL, Name: "engine-woo", Version: "1.2.0"}
+
+	path, err := cache.Locate(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("Locate: %v", err)
+	}
+	c, err := loader.Load(path)
+	if err != nil {
+		t.Fatalf("loading cached chart: %v", err)
+	}
+	if c.Metadata.Version != "1.2.0" {
+		t.Fatalf("cached chart has version %s", c.Metadata.Version)
+	}
+
+	// A verified archive is served from the cache without the repository
+	server.Close()
+	if again, err := cache.Locate(context.Background(), ref); err != nil || again != path {
+		t.Fatalf("cache miss after download: %q, %v", again, err)
+	}
+
+	// A corrupted archive is not served
+	if err := os.WriteFile(path, []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Locate(context.Background(), ref); err == nil {
+		t.Fatal("expected a corrupt cache entry to be refetched, not served")
+	}
+}
+
+func TestChartCacheRejectsDigestMismatch(t *testing.T) {
+	server, dir := serveRepo(t, "1.2.0")
+	index, err := repo.LoadIndexFile(filepath.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.Entries["engine-woo"][0].Digest = strings.Repeat("0", 64)
+	if err := index.WriteFile(filepath.Join(dir, "index.yaml"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewChartCache(t.TempDir())
+	_, err = cache.Locate(context.Background(), ChartRef{URL: server.URL, Name: "engine-woo", Version: "1.2.0"})
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected a digest mismatch, got %v", err)
+	}
+}
+
+func TestChartCacheRequiresVersion(t *testing.T) {
+	cache := NewChartCache(t.TempDir())
+	for _, ref := range []ChartRef{
+		{URL: "https://charts.example.com", Name: "engine-woo"},
+		{URL: "oci://registry.example.com/charts/engine-woo"},
+	} {
+		if _, err := cache.Locate(context.Background(), ref); err == nil {
+			t.Errorf("expected %s without a version to be rejected", ref)
+		}
+	}
+}
+
+func TestChartCacheLocalVersion(t *testing.T) {
+	_, dir := serveRepo(t, "1.2.0")
+	archive := filepath.Join(dir, "engine-woo-1.2.0.tgz")
+	cache := NewChartCache(t.TempDir())
+
+	if path, err := cache.Locate(context.Background(), ChartRef{URL: archive, Version: "1.2.0"}); err != nil || path != archive {
+		t.Fatalf("local chart: %q, %v", path, err)
+	}
+	if _, err := cache.Locate(context.Background(), ChartRef{URL: archive, Version: "1.3.0"}); err == nil {
+		t.Fatal("expected a local chart with another version to be rejected")
+	}
+}
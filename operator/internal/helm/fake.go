@@ -0,0 +1,160 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeChartVersion is the chart version FakeReleaseManager reports by default
+const FakeChartVersion = "0.1.0"
+
+// FakeRelease is a release recorded by FakeReleaseManager
+type FakeRelease struct {
+	Release
+	Chart ChartRef
+}
+
+// FakeReleaseManager is an in-memory ReleaseManager for tests. Set InstallErr
+// or UninstallErr to make the next calls fail, UpgradeErr to fail upgrades of
+// an installed release the way Helm does (recording a failed revision), and
+// ChartVersions to report a version other than FakeChartVersion for an
+// unpinned chart URL.
+type FakeReleaseManager struct {
+	mu sync.Mutex
+	// releases holds each release's revision history, oldest first
+	releases map[string][]FakeRelease
+
+	InstallErr    error
+	UpgradeErr    error
+	UninstallErr  error
+	ChartVersions map[string]string
+	// LastOptions are the options of the most recent InstallOrUpgrade call
+	LastOptions InstallOptions
+}
+
+var _ ReleaseManager = &FakeReleaseManager{}
+
+// NewFakeReleaseManager returns a FakeReleaseManager with no releases
+func NewFakeReleaseManager() *FakeReleaseManager {
+	return &FakeReleaseManager{
+		releases:      map[string][]FakeRelease{},
+		ChartVersions: map[string]string{},
+	}
+}
+
+func (f *FakeReleaseManager) InstallOrUpgrade(_ context.Context, releaseName, namespace string, chart ChartRef,
+	values map[string]interface{}, opts InstallOptions) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.LastOptions = opts
+	if f.InstallErr != nil {
+		return 0, f.InstallErr
+	}
+	key := namespace + "/" + releaseName
+	history := f.releases[key]
+	status := StatusDeployed
+	var manifest string
+	if len(history) > 0 {
+		current := history[len(history)-1]
+		manifest = current.Manifest
+		if f.UpgradeErr != nil {
+			status = StatusFailed
+		}
+	}
+	rel := FakeRelease{
+		Release: Release{
+			Revision:     len(history) + 1,
+			Status:       status,
+			ChartVersion: f.chartVersion(chart),
+			Values:       values,
+			Manifest:     manifest,
+		},
+		Chart: chart,
+	}
+	f.releases[key] = append(history, rel)
+	if status != StatusDeployed {
+		return rel.Revision, f.UpgradeErr
+	}
+	return rel.Revision, nil
+}
+
+func (f *FakeReleaseManager) Rollback(_ context.Context, releaseName, namespace string, revision int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := namespace + "/" + releaseName
+	history := f.releases[key]
+	if revision < 1 || revision > len(history) {
+		return 0, fmt.Errorf("release %s has no revision %d", releaseName, revision)
+	}
+	rel := history[revision-1]
+	rel.Revision = len(history) + 1
+	rel.Status = StatusDeployed
+	f.releases[key] = append(history, rel)
+	return rel.Revision, nil
+}
+
+func (f *FakeReleaseManager) Uninstall(_ context.Context, releaseName, namespace string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.UninstallErr != nil {
+		return f.UninstallErr
+	}
+	delete(f.releases, namespace+"/"+releaseName)
+	return nil
+}
+
+func (f *FakeReleaseManager) Get(_ context.Context, releaseName, namespace string) (*Release, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	history := f.releases[namespace+"/"+releaseName]
+	if len(history) == 0 {
+		return nil, nil
+	}
+	rel := history[len(history)-1]
+	return &rel.Release, nil
+}
+
+func (f *FakeReleaseManager) ChartVersion(_ context.Context, chart ChartRef) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.chartVersion(chart), nil
+}
+
+func (f *FakeReleaseManager) chartVersion(chart ChartRef) string {
+	if chart.Version != "" {
+		return chart.Version
+	}
+	if v, ok := f.ChartVersions[chart.URL]; ok {
+		return v
+	}
+	return FakeChartVersion
+}
+
+// Release returns the latest revision of the recorded release, if installed
+func (f *FakeReleaseManager) Release(releaseName, namespace string) (FakeRelease, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	history := f.releases[namespace+"/"+releaseName]
+	if len(history) == 0 {
+		return FakeRelease{}, false
+	}
+	return history[len(history)-1], true
+}
+
+// Modify edits the latest revision of a recorded release in place, simulating changes made outside the operator
+func (f *FakeReleaseManager) Modify(releaseName, namespace string, fn func(*FakeRelease)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	history := f.releases[namespace+"/"+releaseName]
+	if len(history) > 0 {
+		fn(&history[len(history)-1])
+	}
+}
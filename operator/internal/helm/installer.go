@@ -12,6 +12,21 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+// MaxHistory is how many revisions of a release Helm keeps. Drift repairs,
+// plan changes and rollbacks each add one; the oldest are pruned.
+const MaxHistory = 10
+
+// InstallOptions tune a single InstallOrUpgrade call
+type InstallOptions struct {
+	// Wait makes an upgrade wait until the release's workloads are ready;
+	// installs never wait, the reconciler polls readiness instead
+	Wait bool
+	// Timeout bounds the wait
+	Timeout time.Duration
+}
+
+// InstallOrUpgrade installs or upgrades the release and returns the revision
+// it created. A failed upgrade still returns the revision Helm recorded for it.
 func InstallOrUpgrade(
 	ctx context.Context,
 	restConfig *rest.Config,
@@ -19,7 +34,8 @@ func InstallOrUpgrade(
 	namespace string,
 	chartPath string,
 	values map[string]interface{},
-) error {
+	opts InstallOptions,
+) (int, error) {
 
 	settings := cli.New()
 	settings.SetNamespace(namespace)
@@ -34,12 +50,17 @@ func InstallOrUpgrade(
 			fmt.Printf(format+"\n", v...)
 		},
 	); err != nil {
-		return err
+		return 0, err
 	}
 
 	chart, err := loader.Load(chartPath)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
 	}
 
 	// 1. Check if the release already exists
@@ -50,10 +71,16 @@ func InstallOrUpgrade(
 		fmt.Printf("Helm: Release %s exists, upgrading...\n", releaseName)
 		upgrade := action.NewUpgrade(actionConfig)
 		upgrade.Namespace = namespace
-		upgrade.Wait = false
-		upgrade.Timeout = 5 * time.Minute
-		_, err := upgrade.Run(releaseName, chart, values)
-		return err
+		upgrade.Wait = opts.Wait
+		upgrade.Timeout = timeout
+		upgrade.MaxHistory = MaxHistory
+		rel, err := upgrade.RunWithContext(ctx, releaseName, chart, values)
+		if err != nil {
+			// The failed revision is recorded even though Run doesn't always return it
+			revision, _ := GetReleaseRevision(restConfig, releaseName, namespace)
+			return revision, err
+		}
+		return rel.Version, nil
 	}
 
 	// 2. Release does not exist -> INSTALL
@@ -62,7 +89,35 @@ func InstallOrUpgrade(
 	install.Namespace = namespace
 	install.ReleaseName = releaseName
 	install.Wait = false
-	install.Timeout = 5 * time.Minute
-	_, err = install.Run(chart, values)
-	return err
+	install.Timeout = timeout
+	rel, err := install.RunWithContext(ctx, chart, values)
+	if err != nil {
+		return 0, err
+	}
+	return rel.Version, nil
+}
+
+// Rollback rolls the release back to revision and returns the revision the
+// rollback created. It doesn't wait; the reconciler checks readiness afterwards.
+func Rollback(restConfig *rest.Config, releaseName, namespace string, revision int) (int, error) {
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(
+		NewRESTGetter(restConfig),
+		namespace,
+		os.Getenv("HELM_DRIVER"),
+		func(format string, v ...interface{}) {
+			fmt.Printf(format+"\n", v...)
+		},
+	); err != nil {
+		return 0, err
+	}
+
+	rollback := action.NewRollback(actionConfig)
+	rollback.Version = revision
+	rollback.Timeout = 5 * time.Minute
+	rollback.MaxHistory = MaxHistory
+	if err := rollback.Run(releaseName); err != nil {
+		return 0, fmt.Errorf("helm rollback failed: %w", err)
+	}
+	return GetReleaseRevision(restConfig, releaseName, namespace)
 }
@@ -0,0 +1,156 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/rest"
+)
+
+// Helm release statuses the operator acts on
+var (
+	StatusDeployed = release.StatusDeployed.String()
+	StatusFailed   = release.StatusFailed.String()
+)
+
+// Release is the deployed state of a store's Helm release
+type Release struct {
+	// Revision is the release version Helm recorded
+	Revision int
+	// Status is the Helm release status, e.g. deployed or failed
+	Status string
+	// ChartVersion is the version of the chart the release was installed from
+	ChartVersion string
+	// Values are the user-supplied values of the release
+	Values map[string]interface{}
+	// Manifest is the rendered multi-document YAML Helm applied
+	Manifest string
+}
+
+// ReleaseManager installs, upgrades and removes the Helm release behind a store
+type ReleaseManager interface {
+	// InstallOrUpgrade installs the release, or upgrades it if it already
+	// exists, and returns the revision it created. A failed upgrade returns
+	// the revision Helm recorded for the attempt.
+	InstallOrUpgrade(ctx context.Context, releaseName, namespace string, chart ChartRef, values map[string]interface{}, opts InstallOptions) (int, error)
+
+	// Rollback restores an earlier revision and returns the revision it created
+	Rollback(ctx context.Context, releaseName, namespace string, revision int) (int, error)
+
+	// Uninstall removes the release; a release that doesn't exist is not an error
+	Uninstall(ctx context.Context, releaseName, namespace string) error
+
+	// Get returns the latest revision of the release, or nil if it isn't installed
+	Get(ctx context.Context, releaseName, namespace string) (*Release, error)
+
+	// ChartVersion returns the version of the chart a release of chart would deploy
+	ChartVersion(ctx context.Context, chart ChartRef) (string, error)
+}
+
+// SDKReleaseManager manages releases in a cluster through the Helm SDK,
+// pulling remote charts through a ChartCache
+type SDKReleaseManager struct {
+	restConfig *rest.Config
+	charts     *ChartCache
+}
+
+// NewSDKReleaseManager returns a ReleaseManager talking to the cluster behind restConfig
+func NewSDKReleaseManager(restConfig *rest.Config, charts *ChartCache) *SDKReleaseManager {
+	return &SDKReleaseManager{restConfig: restConfig, charts: charts}
+}
+
+func (m *SDKReleaseManager) InstallOrUpgrade(ctx context.Context, releaseName, namespace string, chart ChartRef,
+	values map[string]interface{}, opts InstallOptions) (int, error) {
+	chartPath, err := m.charts.Locate(ctx, chart)
+	if err != nil {
+		return 0, err
+	}
+	return InstallOrUpgrade(ctx, m.restConfig, releaseName, namespace, chartPath, values, opts)
+}
+
+func (m *SDKReleaseManager) Rollback(_ context.Context, releaseName, namespace string, revision int) (int, error) {
+	return Rollback(m.restConfig, releaseName, namespace, revision)
+}
+
+func (m *SDKReleaseManager) Uninstall(_ context.Context, releaseName, namespace string) error {
+	return UninstallRelease(m.restConfig, releaseName, namespace)
+}
+
+func (m *SDKReleaseManager) Get(_ context.Context, releaseName, namespace string) (*Release, error) {
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(
+		NewRESTGetter(m.restConfig),
+		namespace,
+		os.Getenv("HELM_DRIVER"),
+		func(string, ...interface{}) {},
+	); err != nil {
+		return nil, err
+	}
+
+	rel, err := action.NewGet(actionConfig).Run(releaseName)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("helm get failed: %w", err)
+	}
+
+	out := &Release{
+		Revision: rel.Version,
+		Values:   rel.Config,
+		Manifest: rel.Manifest,
+	}
+	if rel.Info != nil {
+		out.Status = rel.Info.Status.String()
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		out.ChartVersion = rel.Chart.Metadata.Version
+	}
+	return out, nil
+}
+
+func (m *SDKReleaseManager) ChartVersion(_ context.Context, chart ChartRef) (string, error) {
+	// Locate refuses to serve a pinned chart with any other version
+	if chart.Version != "" {
+		return chart.Version, nil
+	}
+	c, err := loader.Load(strings.TrimPrefix(chart.URL, "file://"))
+	if err != nil {
+		return "", err
+	}
+	return c.Metadata.Version, nil
+}
+
+// ManifestObjects decodes the objects in a release manifest
+func ManifestObjects(manifest string) ([]*unstructured.Unstructured, error) {
+	docs := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	objects := make([]*unstructured.Unstructured, 0, len(docs))
+	for _, k := range keys {
+		obj := &unstructured.Unstructured{}
+		if err := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(docs[k]), 4096).Decode(&obj.Object); err != nil {
+			return nil, fmt.Errorf("decoding manifest: %w", err)
+		}
+		if obj.GetKind() == "" {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
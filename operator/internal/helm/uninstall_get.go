@@ -54,13 +54,16 @@ func GetReleaseRevision(restConfig *rest.Config, releaseName, namespace string)
 	}
 
 	hist := action.NewHistory(actionConfig)
-	hist.Max = 1
-	rel, err := hist.Run(releaseName)
+	rels, err := hist.Run(releaseName)
 	if err != nil {
 		return -1, err
 	}
-	if len(rel) == 0 {
-		return -1, nil
+	// History isn't ordered; the highest version is the latest
+	revision := -1
+	for _, rel := range rels {
+		if rel.Version > revision {
+			revision = rel.Version
+		}
 	}
-	return rel[0].Version, nil
+	return revision, nil
 }